@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/services"
+
+	configLoader "github.com/andiksetyawan/config"
+)
+
+const usage = `Usage: dbsyncctl <command> [arguments]
+
+Commands:
+  triggers install [table ...]    install changelog triggers on master tables
+                                  (default: SYNC_TRIGGER_TABLES, or all tables)
+  triggers uninstall [table ...]  remove changelog triggers (default: all, also drops the changelog table)
+  triggers status                 list tables with triggers and pending changelog entries
+  schema dump [dir]               write master tables as CREATE TABLE files (default: SYNC_DESIRED_SCHEMA_DIR)
+  schema plan [dir]               show the DDL that makes the backup match the files in dir
+  schema apply [dir] [--approve]  run that DDL on the backup; --approve also runs changes
+                                  that wait for approval (destructive, narrowing)
+`
+
+func main() {
+	if len(os.Args) < 3 || (os.Args[1] != "triggers" && os.Args[1] != "schema") {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := &config.AppConfig{}
+	loader := configLoader.New(
+		configLoader.WithEnvPath(".env"),
+	)
+
+	if err := loader.Load(cfg); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	masterDB, err := config.OpenDatabase("master", cfg.MasterDB)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer masterDB.Close()
+
+	source, err := dialect.New(cfg.MasterDB.Driver)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	if os.Args[1] == "schema" {
+		runSchema(cfg, masterDB, source, os.Args[2], os.Args[3:])
+	} else {
+		runTriggers(cfg, masterDB, source, os.Args[2], os.Args[3:])
+	}
+}
+
+func runTriggers(cfg *config.AppConfig, masterDB *sql.DB, source dialect.Dialect, command string, args []string) {
+	changelog := services.NewChangelogService(masterDB, source)
+
+	switch command {
+	case "install":
+		tables := args
+		if len(tables) == 0 {
+			tables = cfg.Sync.TriggerTables
+		}
+		if len(tables) == 0 {
+			var err error
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			tables, err = source.ListTables(ctx, masterDB)
+			cancel()
+			if err != nil {
+				log.Fatalf("Failed to list master tables: %v", err)
+			}
+		}
+
+		if err := changelog.Install(tables); err != nil {
+			log.Fatalf("Install failed: %v", err)
+		}
+
+	case "uninstall":
+		if err := changelog.Uninstall(args); err != nil {
+			log.Fatalf("Uninstall failed: %v", err)
+		}
+
+	case "status":
+		tables, err := changelog.InstalledTables()
+		if err != nil {
+			log.Fatalf("Status failed: %v", err)
+		}
+
+		fmt.Printf("Tables with changelog triggers (%d):\n", len(tables))
+		for _, table := range tables {
+			fmt.Printf("  %s\n", table)
+		}
+
+		if len(tables) > 0 {
+			pending, err := changelog.Pending()
+			if err != nil {
+				log.Fatalf("Status failed: %v", err)
+			}
+			fmt.Printf("Pending changelog entries: %d\n", pending)
+		}
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func runSchema(cfg *config.AppConfig, masterDB *sql.DB, source dialect.Dialect, command string, args []string) {
+	dir := cfg.Sync.DesiredSchemaDir
+	approve := false
+	for _, arg := range args {
+		if arg == "--approve" {
+			approve = true
+		} else {
+			dir = arg
+		}
+	}
+
+	backupDB, err := config.OpenDatabase("backup", cfg.BackupDB)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer backupDB.Close()
+
+	target, err := dialect.New(cfg.BackupDB.Driver)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	history := services.NewSchemaHistoryService(backupDB, target)
+
+	if command == "dump" {
+		schema := services.NewSchemaService(masterDB, backupDB, source, target, history, cfg)
+		count, err := schema.DumpSchema(dir)
+		if err != nil {
+			log.Fatalf("Dump failed: %v", err)
+		}
+		fmt.Printf("Wrote %d tables to %s\n", count, dir)
+		return
+	}
+
+	if command != "plan" && command != "apply" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	scratchDB, err := config.OpenDesiredDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer scratchDB.Close()
+
+	schema := services.NewDesiredSchemaService(scratchDB, backupDB, target, history, cfg)
+	if err := schema.LoadDesiredSchema(dir); err != nil {
+		log.Fatalf("Load failed: %v", err)
+	}
+
+	plans, err := schema.PlanAllSchemas()
+	if err != nil {
+		log.Fatalf("Plan failed: %v", err)
+	}
+
+	if command == "plan" {
+		if len(plans) == 0 {
+			fmt.Println("Backup schema matches the desired schema")
+			return
+		}
+		for _, plan := range plans {
+			fmt.Printf("-- %s (%s)\n", plan.TableName, plan.Action)
+			for _, change := range plan.Changes {
+				if change.RequiresApproval {
+					fmt.Println("-- requires approval:")
+				}
+				fmt.Printf("%s;\n", change.Statement)
+			}
+			fmt.Println()
+		}
+		return
+	}
+
+	for _, plan := range plans {
+		if !plan.Blocked {
+			continue
+		}
+		if !approve {
+			log.Printf("Table %s has changes waiting for approval, run with --approve to apply them", plan.TableName)
+			continue
+		}
+		if _, err := schema.ApproveChanges(plan.TableName, ""); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	if err := schema.SyncAllSchemas(); err != nil {
+		log.Fatalf("Apply failed: %v", err)
+	}
+}
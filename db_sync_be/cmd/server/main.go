@@ -39,7 +39,10 @@ func main() {
 	defer backupDB.Close()
 
 	// Create application instance with dependency injection
-	application := app.NewApplication(cfg, masterDB, backupDB)
+	application, err := app.NewApplication(cfg, masterDB, backupDB)
+	if err != nil {
+		log.Fatalf("Failed to create application: %v", err)
+	}
 	defer application.Close()
 
 	// Create handler with dependencies
@@ -52,7 +55,22 @@ func main() {
 	http.HandleFunc("/api/sync/stop", middleware.CORS(handler.StopSyncHandler))
 	http.HandleFunc("/api/sync/status", middleware.CORS(handler.StatusHandler))
 	http.HandleFunc("/api/sync/config", middleware.CORS(handler.ConfigHandler))
+	http.HandleFunc("/api/sync/conflicts", middleware.CORS(handler.ConflictListHandler))
+	http.HandleFunc("/api/sync/conflicts/resolve", middleware.CORS(handler.ConflictResolveHandler))
 	http.HandleFunc("/api/schema/sync", middleware.CORS(handler.SchemaSyncHandler))
+	http.HandleFunc("/api/schema/diff", middleware.CORS(handler.SchemaDiffHandler))
+	http.HandleFunc("/api/schema/approve", middleware.CORS(handler.SchemaApproveHandler))
+	http.HandleFunc("/api/schema/history", middleware.CORS(handler.SchemaHistoryHandler))
+	http.HandleFunc("/api/schema/dependencies", middleware.CORS(handler.DependencyHandler))
+	http.HandleFunc("/api/schema/renames", middleware.CORS(handler.RenameListHandler))
+	http.HandleFunc("/api/schema/renames/resolve", middleware.CORS(handler.RenameResolveHandler))
+	http.HandleFunc("/api/schema/table-renames", middleware.CORS(handler.TableRenameListHandler))
+	http.HandleFunc("/api/schema/table-renames/resolve", middleware.CORS(handler.TableRenameResolveHandler))
+	http.HandleFunc("/api/schema/snapshots", middleware.CORS(handler.SnapshotListHandler))
+	http.HandleFunc("/api/schema/snapshots/restore", middleware.CORS(handler.SnapshotRestoreHandler))
+	http.HandleFunc("/api/quarantine", middleware.CORS(handler.QuarantineListHandler))
+	http.HandleFunc("/api/quarantine/retry", middleware.CORS(handler.QuarantineRetryHandler))
+	http.HandleFunc("/api/quarantine/discard", middleware.CORS(handler.QuarantineDiscardHandler))
 
 	// Get port from config
 	port := cfg.Server.Port
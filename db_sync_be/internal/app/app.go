@@ -3,6 +3,7 @@ package app
 import (
 	"database/sql"
 	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
 	"db-sync-scheduler/internal/services"
 )
 
@@ -12,27 +13,48 @@ type Application struct {
 	BackupDB      *sql.DB
 	SyncService   *services.SyncService
 	SchemaService *services.SchemaService
+	SchemaHistory *services.SchemaHistoryService
+	Quarantine    *services.QuarantineService
+	Conflicts     *services.ConflictService
+	Changelog     *services.ChangelogService
 }
 
-func NewApplication(cfg *config.AppConfig, masterDB, backupDB *sql.DB) *Application {
+func NewApplication(cfg *config.AppConfig, masterDB, backupDB *sql.DB) (*Application, error) {
+	source, err := dialect.New(cfg.MasterDB.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := dialect.New(cfg.BackupDB.Driver)
+	if err != nil {
+		return nil, err
+	}
+
 	app := &Application{
 		Config:   cfg,
 		MasterDB: masterDB,
 		BackupDB: backupDB,
 	}
 
-	app.SchemaService = services.NewSchemaService(masterDB, backupDB)
+	app.SchemaHistory = services.NewSchemaHistoryService(backupDB, target)
+	app.SchemaService = services.NewSchemaService(masterDB, backupDB, source, target, app.SchemaHistory, cfg)
+	app.Quarantine = services.NewQuarantineService(backupDB, target)
+	app.Conflicts = services.NewConflictService(backupDB)
+	app.Changelog = services.NewChangelogService(masterDB, source)
 	app.SyncService = services.NewSyncService(
 		masterDB,
 		backupDB,
 		app.SchemaService,
+		app.Quarantine,
+		app.Conflicts,
+		app.Changelog,
 		cfg.Sync.Schedule,
 		cfg.Sync.BatchSize,
 		cfg.Sync.AutoSchemaSync,
 		cfg,
 	)
 
-	return app
+	return app, nil
 }
 
 func (app *Application) Close() {
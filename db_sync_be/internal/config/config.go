@@ -19,12 +19,123 @@ type SyncConfig struct {
 	AutoSchemaSync bool `env:"AUTO_SCHEMA_SYNC" envDefault:"true"`
 
 	EnableChecksumSync bool `env:"ENABLE_CHECKSUM_SYNC" envDefault:"true"`
+
+	QuarantineEnabled bool `env:"QUARANTINE_ENABLED" envDefault:"true"`
+
+	// Mode: one_way (master → backup) atau bidirectional
+	Mode string `env:"MODE" envDefault:"one_way"`
+
+	// ConflictResolution: master_wins, last_writer_wins atau manual
+	ConflictResolution string `env:"CONFLICT_RESOLUTION" envDefault:"master_wins"`
+
+	ConflictTimestampColumn string `env:"CONFLICT_TIMESTAMP_COLUMN" envDefault:"updated_at"`
+
+	// CaptureMode: polling (cron), binlog (CDC dari binlog master MySQL) atau
+	// trigger (changelog yang diisi trigger di master)
+	CaptureMode string `env:"CAPTURE_MODE" envDefault:"polling"`
+
+	// BinlogServerID harus unik di antara replica master
+	BinlogServerID uint32 `env:"BINLOG_SERVER_ID" envDefault:"1001"`
+
+	BinlogUseGTID bool `env:"BINLOG_USE_GTID" envDefault:"false"`
+
+	// TriggerTables adalah tabel default untuk `dbsyncctl triggers install`, kosong berarti semua tabel
+	TriggerTables []string `env:"TRIGGER_TABLES" envSeparator:","`
+
+	// SnapshotScope: none, run (satu snapshot master untuk semua tabel) atau
+	// level (satu snapshot per level dependency FK)
+	SnapshotScope string `env:"SNAPSHOT_SCOPE" envDefault:"none"`
+
+	// DeferForeignKeys membuat tabel backup tanpa FK, FK ditambahkan dan
+	// divalidasi setelah initial load selesai
+	DeferForeignKeys bool `env:"DEFER_FOREIGN_KEYS" envDefault:"false"`
+
+	// DroppedColumnPolicy: keep, drop atau deprecate (rename ke _deprecated_<nama>)
+	// untuk kolom backup yang sudah tidak ada di master
+	DroppedColumnPolicy string `env:"DROPPED_COLUMN_POLICY" envDefault:"keep"`
+
+	// DroppedPartitionPolicy: keep atau drop untuk partisi RANGE/LIST backup yang
+	// sudah di-drop di master (mis. rotasi partisi bulanan)
+	DroppedPartitionPolicy string `env:"DROPPED_PARTITION_POLICY" envDefault:"keep"`
+
+	// DroppedTablePolicy: keep, archive (rename ke _archived_<nama>) atau drop
+	// untuk tabel backup yang sudah tidak ada di master
+	DroppedTablePolicy string `env:"DROPPED_TABLE_POLICY" envDefault:"keep"`
+
+	// RenameDetection: off, auto atau confirm (rename menunggu persetujuan lewat API),
+	// berlaku untuk rename kolom dan rename tabel
+	RenameDetection string `env:"RENAME_DETECTION" envDefault:"off"`
+
+	// KeepColumnOrder menyamakan urutan kolom backup dengan master (hanya MySQL)
+	KeepColumnOrder bool `env:"KEEP_COLUMN_ORDER" envDefault:"true"`
+
+	// View, routine (procedure dan function) dan event ikut disalin ke backup
+	// jika master dan backup memakai dialect yang sama
+	SyncViews    bool `env:"VIEWS" envDefault:"true"`
+	SyncRoutines bool `env:"ROUTINES" envDefault:"true"`
+	SyncEvents   bool `env:"EVENTS" envDefault:"true"`
+
+	// BackupTriggers: skip (trigger master tidak disalin) atau disabled (disalin
+	// dalam keadaan nonaktif, hanya PostgreSQL) supaya trigger tidak jalan dua kali
+	BackupTriggers string `env:"BACKUP_TRIGGERS" envDefault:"skip"`
+
+	// ObjectDefiner menggantikan DEFINER view, trigger, routine dan event MySQL,
+	// mis. `app`@`%`; kosong berarti DEFINER dihapus (menjadi user koneksi backup)
+	ObjectDefiner string `env:"OBJECT_DEFINER"`
+
+	// DesiredSchemaDir berisi file CREATE TABLE (schema-as-code) yang dipakai
+	// `dbsyncctl schema dump|plan|apply` sebagai schema target backup
+	DesiredSchemaDir string `env:"DESIRED_SCHEMA_DIR" envDefault:"schema"`
+
+	// DesiredSchemaDatabase adalah database scratch di server backup tempat file
+	// desired schema dimuat sebelum dibandingkan, isinya dihapus setiap dimuat.
+	// Wajib untuk backup mysql dan postgres; backup SQLite memakai database in-memory.
+	DesiredSchemaDatabase string `env:"DESIRED_SCHEMA_DATABASE"`
+
+	// SchemaMigrationDir: jika diisi, DDL tabel ditulis sebagai file migration
+	// up/down di direktori ini dan tidak dijalankan di backup
+	SchemaMigrationDir string `env:"SCHEMA_MIGRATION_DIR"`
+
+	// SchemaMigrationFormat: golang-migrate (000001_x.up.sql/.down.sql) atau
+	// flyway (V1__x.sql/U1__x.sql)
+	SchemaMigrationFormat string `env:"SCHEMA_MIGRATION_FORMAT" envDefault:"golang-migrate"`
+
+	// SchemaSnapshotMode: off, table (salinan tabel di backup) atau file (dump
+	// JSON lines di SchemaSnapshotDir), diambil sebelum DDL yang bisa menghapus data
+	SchemaSnapshotMode string `env:"SCHEMA_SNAPSHOT_MODE" envDefault:"off"`
+
+	SchemaSnapshotDir string `env:"SCHEMA_SNAPSHOT_DIR" envDefault:"snapshots"`
+
+	// SchemaSnapshotKeep: jumlah snapshot terbaru yang disimpan per tabel dan
+	// SchemaSnapshotMaxAgeDays: umur maksimal snapshot; 0 berarti tanpa batas
+	SchemaSnapshotKeep       int `env:"SCHEMA_SNAPSHOT_KEEP" envDefault:"3"`
+	SchemaSnapshotMaxAgeDays int `env:"SCHEMA_SNAPSHOT_MAX_AGE_DAYS" envDefault:"30"`
+
+	// RequireSchemaApproval menahan semua DDL tabel sampai disetujui lewat API.
+	// DDL destruktif atau yang menyempitkan tipe kolom selalu perlu approval.
+	RequireSchemaApproval bool `env:"REQUIRE_SCHEMA_APPROVAL" envDefault:"false"`
 }
 
 type DatabaseConfig struct {
+	// Driver: mysql, sqlite atau postgres, dipakai untuk memilih dialect
+	Driver   string `env:"DRIVER" envDefault:"mysql"`
+	Path     string `env:"PATH" envDefault:"backup.db"`
+	SSLMode  string `env:"SSL_MODE" envDefault:"disable"`
 	Host     string `env:"HOST" envDefault:"localhost"`
-	Port     string `env:"PORT" envDefault:"3306"`
+	Port     string `env:"PORT"` // Kosong: port default driver, lihat PortOrDefault
 	User     string `env:"USER" envDefault:"root"`
 	Password string `env:"PASSWORD" envDefault:"password"`
 	Name     string `env:"NAME" envDefault:"master_db"`
 }
+
+// PortOrDefault mengembalikan Port, atau port default driver jika Port kosong:
+// 5432 untuk postgres, 3306 untuk mysql
+func (c DatabaseConfig) PortOrDefault() string {
+	if c.Port != "" {
+		return c.Port
+	}
+	if c.Driver == "postgres" {
+		return "5432"
+	}
+	return "3306"
+}
@@ -0,0 +1,21 @@
+package config
+
+import "testing"
+
+func TestDatabaseConfigPortOrDefault(t *testing.T) {
+	tests := []struct {
+		cfg  DatabaseConfig
+		want string
+	}{
+		{DatabaseConfig{Driver: "mysql"}, "3306"},
+		{DatabaseConfig{Driver: "postgres"}, "5432"},
+		{DatabaseConfig{Driver: "postgres", Port: "6432"}, "6432"},
+		{DatabaseConfig{Driver: "mysql", Port: "3307"}, "3307"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cfg.PortOrDefault(); got != tt.want {
+			t.Errorf("%s port %q: PortOrDefault() = %q, want %q", tt.cfg.Driver, tt.cfg.Port, got, tt.want)
+		}
+	}
+}
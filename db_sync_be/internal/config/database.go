@@ -2,63 +2,105 @@ package config
 
 import (
 	"database/sql"
+	"db-sync-scheduler/internal/dialect"
 	"fmt"
 	"log"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 func InitDatabase(cfg *AppConfig) (*sql.DB, *sql.DB, error) {
-	var err error
+	masterDB, err := OpenDatabase("master", cfg.MasterDB)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	masterDSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		cfg.MasterDB.User,
-		cfg.MasterDB.Password,
-		cfg.MasterDB.Host,
-		cfg.MasterDB.Port,
-		cfg.MasterDB.Name,
-	)
+	log.Printf("Connected to Master Database (%s)", describeDatabase(cfg.MasterDB))
 
-	masterDB, err := sql.Open("mysql", masterDSN)
+	backupDB, err := OpenDatabase("backup", cfg.BackupDB)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open master database: %v", err)
+		masterDB.Close() // Close master DB jika backup gagal
+		return nil, nil, err
 	}
 
-	if err = masterDB.Ping(); err != nil {
-		return nil, nil, fmt.Errorf("failed to ping master database: %v", err)
-	}
+	log.Printf("Connected to Backup Database (%s)", describeDatabase(cfg.BackupDB))
 
-	masterDB.SetMaxOpenConns(10)
-	masterDB.SetMaxIdleConns(5)
+	return masterDB, backupDB, nil
+}
 
-	log.Printf("Connected to Master Database (%s:%s/%s)",
-		cfg.MasterDB.Host, cfg.MasterDB.Port, cfg.MasterDB.Name)
+// OpenDatabase membuka koneksi sesuai dialect yang dipilih di konfigurasi
+func OpenDatabase(label string, dbCfg DatabaseConfig) (*sql.DB, error) {
+	d, err := dialect.New(dbCfg.Driver)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s database driver: %v", label, err)
+	}
 
-	backupDSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		cfg.BackupDB.User,
-		cfg.BackupDB.Password,
-		cfg.BackupDB.Host,
-		cfg.BackupDB.Port,
-		cfg.BackupDB.Name,
-	)
+	dsn := d.DSN(dialect.ConnectionInfo{
+		Host:     dbCfg.Host,
+		Port:     dbCfg.PortOrDefault(),
+		User:     dbCfg.User,
+		Password: dbCfg.Password,
+		Name:     dbCfg.Name,
+		Path:     dbCfg.Path,
+		SSLMode:  dbCfg.SSLMode,
+	})
 
-	backupDB, err := sql.Open("mysql", backupDSN)
+	db, err := sql.Open(d.DriverName(), dsn)
 	if err != nil {
-		masterDB.Close() // Close master DB jika backup gagal
-		return nil, nil, fmt.Errorf("failed to open backup database: %v", err)
+		return nil, fmt.Errorf("failed to open %s database: %v", label, err)
 	}
 
-	if err = backupDB.Ping(); err != nil {
-		masterDB.Close() // Close master DB jika backup gagal
-		return nil, nil, fmt.Errorf("failed to ping backup database: %v", err)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping %s database: %v", label, err)
 	}
 
 	// Set connection pool settings
-	backupDB.SetMaxOpenConns(10)
-	backupDB.SetMaxIdleConns(5)
+	if d.Name() == "sqlite" {
+		// SQLite hanya mengizinkan satu writer
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(10)
+		db.SetMaxIdleConns(5)
+	}
 
-	log.Printf("Connected to Backup Database (%s:%s/%s)",
-		cfg.BackupDB.Host, cfg.BackupDB.Port, cfg.BackupDB.Name)
+	return db, nil
+}
 
-	return masterDB, backupDB, nil
+// OpenDesiredDatabase membuka database scratch untuk desired schema dengan
+// driver dan server yang sama dengan backup
+func OpenDesiredDatabase(cfg *AppConfig) (*sql.DB, error) {
+	dbCfg := cfg.BackupDB
+
+	if dbCfg.Driver == "sqlite" {
+		dbCfg.Path = ":memory:"
+		return OpenDatabase("desired schema", dbCfg)
+	}
+
+	name := cfg.Sync.DesiredSchemaDatabase
+	if name == "" {
+		return nil, fmt.Errorf("SYNC_DESIRED_SCHEMA_DATABASE is required for a %s backup", describeDatabase(dbCfg))
+	}
+
+	// Isi database scratch dihapus setiap desired schema dimuat
+	sameServer := cfg.MasterDB.Host == dbCfg.Host && cfg.MasterDB.PortOrDefault() == dbCfg.PortOrDefault()
+	if name == dbCfg.Name || (sameServer && name == cfg.MasterDB.Name) {
+		return nil, fmt.Errorf("desired schema database %s must not be the master or backup database", name)
+	}
+
+	dbCfg.Name = name
+	return OpenDatabase("desired schema", dbCfg)
+}
+
+func describeDatabase(dbCfg DatabaseConfig) string {
+	switch dbCfg.Driver {
+	case "sqlite":
+		return "sqlite:" + dbCfg.Path
+	case "postgres":
+		return fmt.Sprintf("postgres %s:%s/%s", dbCfg.Host, dbCfg.PortOrDefault(), dbCfg.Name)
+	default:
+		return fmt.Sprintf("%s:%s/%s", dbCfg.Host, dbCfg.PortOrDefault(), dbCfg.Name)
+	}
 }
@@ -0,0 +1,326 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect membungkus semua perbedaan SQL antar database: koneksi, quoting,
+// introspeksi schema, DDL, upsert dan checksum. Master (source) dan backup
+// (target) masing-masing memilih dialect dari konfigurasi.
+type Dialect interface {
+	Name() string
+	DriverName() string
+	DSN(conn ConnectionInfo) string
+	QuoteIdentifier(name string) string
+	// Rebind mengubah placeholder ? ke format placeholder dialect
+	Rebind(query string) string
+
+	ListTables(ctx context.Context, db *sql.DB) ([]string, error)
+	TableExists(ctx context.Context, db *sql.DB, tableName string) (bool, error)
+	GetColumns(ctx context.Context, db *sql.DB, tableName string) ([]models.ColumnInfo, error)
+	GetPrimaryKeyColumns(ctx context.Context, db *sql.DB, tableName string) ([]string, error)
+	GetForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]models.ForeignKey, error)
+	// GetIndexes mengembalikan index selain primary key, index berbasis ekspresi dilewati
+	GetIndexes(ctx context.Context, db *sql.DB, tableName string) ([]models.IndexInfo, error)
+	// ShowCreateTable mengembalikan DDL asli tabel, kosong jika dialect tidak mendukungnya
+	ShowCreateTable(ctx context.Context, db *sql.DB, tableName string) (string, error)
+	// GetSchemaObjects mengembalikan view, trigger, routine dan event beserta DDL
+	// aslinya; nama schema sendiri dihapus dari DDL supaya bisa dipakai di backup
+	GetSchemaObjects(ctx context.Context, db *sql.DB) ([]models.SchemaObject, error)
+
+	// CreateTableStatement membuat DDL tabel; sourceCreate adalah DDL asli dari
+	// source dengan dialect yang sama (boleh kosong)
+	CreateTableStatement(tableName string, columns []models.ColumnInfo, sourceCreate string) string
+	RenameTableStatement(oldName, newName string) string
+	// AddColumnStatement menambah kolom setelah kolom after (kosong berarti kolom
+	// pertama); dialect yang tidak mendukung posisi kolom menaruhnya di akhir
+	AddColumnStatement(tableName string, col models.ColumnInfo, after string) string
+	ColumnsDifferent(masterCol, backupCol models.ColumnInfo) bool
+	// ModifyColumnStatement mengembalikan false jika dialect tidak bisa mengubah kolom
+	ModifyColumnStatement(tableName string, col models.ColumnInfo) (string, bool)
+	// RenameColumnStatement mengganti nama kolom oldName menjadi col.ColumnName,
+	// col adalah definisi kolom setelah rename
+	RenameColumnStatement(tableName, oldName string, col models.ColumnInfo) string
+	DropColumnStatement(tableName, columnName string) string
+	// DropNotNullStatement membuat kolom boleh NULL, false jika dialect tidak bisa
+	DropNotNullStatement(tableName string, col models.ColumnInfo) (string, bool)
+	// MoveColumnStatement memindahkan kolom setelah kolom after (kosong berarti
+	// kolom pertama), false jika dialect tidak mendukung urutan kolom
+	MoveColumnStatement(tableName string, col models.ColumnInfo, after string) (string, bool)
+	// GetTableOptions mengembalikan opsi tabel dan partisi, nil jika dialect tidak punya
+	GetTableOptions(ctx context.Context, db *sql.DB, tableName string) (*models.TableOptions, error)
+	// TableOptionStatement mengubah satu opsi tabel (TableOption*) menjadi nilai di opts
+	TableOptionStatement(tableName, option string, opts models.TableOptions) (string, bool)
+	PartitionStatement(tableName string, change models.PartitionChange) (string, bool)
+	// AlterClause mengambil klausa dari statement ALTER TABLE supaya beberapa
+	// perubahan bisa digabung, false jika statement tidak bisa digabung
+	AlterClause(tableName, stmt string) (string, bool)
+	AlterTableStatement(tableName string, clauses []string) string
+	// OnlineAlterOptions adalah opsi ALTER non-blocking yang dicoba berurutan,
+	// kosong jika dialect tidak punya opsi tersebut
+	OnlineAlterOptions() []string
+	// UnsupportedAlter mengecek apakah error berarti opsi ALTER tidak didukung
+	UnsupportedAlter(err error) bool
+	// ShadowTableStatements membuat tabel bayangan dengan struktur yang sama dan
+	// menukarnya dengan tabel asli, false jika dialect tidak mendukung copy-and-swap
+	ShadowTableStatements(tableName, shadowName, oldName string) (create, swap string, ok bool)
+	// AdaptIndex menyesuaikan index master dengan kemampuan dialect (mis. tanpa
+	// prefix length), false jika jenis index tidak didukung
+	AdaptIndex(idx models.IndexInfo) (models.IndexInfo, bool)
+	CreateIndexStatement(tableName string, idx models.IndexInfo) string
+	// IndexName mengembalikan nama index yang dipakai CreateIndexStatement
+	IndexName(tableName, indexName string) string
+	DropIndexStatement(tableName string, idx models.IndexInfo) string
+	// AddForeignKeyStatement dan DropForeignKeyStatement mengembalikan false jika
+	// dialect tidak bisa mengubah FK setelah tabel dibuat
+	AddForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool)
+	DropForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool)
+	// StripForeignKeys menghapus definisi FK dari DDL asli source
+	StripForeignKeys(createStmt string) string
+	// ObjectDefinition membuat DDL object untuk backup; definer menggantikan
+	// DEFINER asli (kosong berarti dihapus) dan event selalu dibuat DISABLE
+	ObjectDefinition(obj models.SchemaObject, definer string) string
+	DropObjectStatement(obj models.SchemaObject) string
+	// DisableTriggerStatement mengembalikan false jika dialect tidak bisa menonaktifkan trigger
+	DisableTriggerStatement(obj models.SchemaObject) (string, bool)
+
+	UpsertStatement(tableName string, columns, pkColumns []string) string
+	// ChecksumExpression mengembalikan ekspresi checksum per baris (alias row_checksum),
+	// kosong jika checksum harus dihitung di aplikasi
+	ChecksumExpression(columns []string) string
+	// SnapshotStatements memulai transaksi read-only dengan snapshot konsisten di
+	// satu koneksi, kosong jika dialect tidak mendukungnya
+	SnapshotStatements() []string
+	// ForeignKeyChecksStatements mematikan dan menyalakan kembali pengecekan FK
+	// untuk satu sesi, kosong jika dialect tidak mendukungnya
+	ForeignKeyChecksStatements() (disable, enable string)
+}
+
+// Opsi tabel untuk TableOptionStatement
+const (
+	TableOptionEngine    = "engine"
+	TableOptionCollation = "collation"
+	TableOptionRowFormat = "row_format"
+	TableOptionComment   = "comment"
+)
+
+// Aksi PartitionChange, sama dengan Kind SchemaChange-nya
+const (
+	PartitionBy         = "partition_by"
+	RemovePartitioning  = "remove_partitioning"
+	AddPartition        = "add_partition"
+	DropPartition       = "drop_partition"
+	ReorganizePartition = "reorganize_partition"
+)
+
+// ConnectionInfo berisi parameter koneksi yang dipakai untuk membuat DSN
+type ConnectionInfo struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	Path     string
+	SSLMode  string
+}
+
+// New memilih dialect berdasarkan nama driver di konfigurasi
+func New(driver string) (Dialect, error) {
+	switch driver {
+	case "", "mysql":
+		return mysqlDialect{}, nil
+	case "sqlite":
+		return sqliteDialect{}, nil
+	case "postgres":
+		return postgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// InternalColumn membuat definisi kolom untuk tabel internal db_sync
+func InternalColumn(name, dataType, columnType string, notNull, primary bool) models.ColumnInfo {
+	col := models.ColumnInfo{
+		ColumnName: name,
+		DataType:   dataType,
+		ColumnType: columnType,
+		IsNullable: "YES",
+	}
+	if notNull {
+		col.IsNullable = "NO"
+	}
+	if primary {
+		col.ColumnKey = "PRI"
+	}
+	return col
+}
+
+// PrimaryKeyColumns mengembalikan kolom PK sesuai urutan kolom
+func PrimaryKeyColumns(columns []models.ColumnInfo) []string {
+	var pk []string
+	for _, col := range columns {
+		if col.ColumnKey == "PRI" {
+			pk = append(pk, col.ColumnName)
+		}
+	}
+	return pk
+}
+
+// QuoteIdentifiers meng-quote dan menggabungkan beberapa identifier dengan koma
+func QuoteIdentifiers(d Dialect, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.QuoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// rebindDollar mengganti placeholder ? dengan $1, $2, ... (di luar string literal)
+func rebindDollar(query string) string {
+	var sb strings.Builder
+	inQuote := false
+	n := 0
+
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			sb.WriteRune(r)
+		case r == '?' && !inQuote:
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+// indexBuilder menggabungkan baris introspeksi (satu baris per kolom index)
+// menjadi IndexInfo dengan urutan index dan kolom dipertahankan
+type indexBuilder struct {
+	indexes []models.IndexInfo
+	pos     map[string]int
+	skipped map[string]bool
+}
+
+func newIndexBuilder() *indexBuilder {
+	return &indexBuilder{pos: make(map[string]int), skipped: make(map[string]bool)}
+}
+
+func (b *indexBuilder) add(name, kind string, col models.IndexColumn) {
+	i, ok := b.pos[name]
+	if !ok {
+		i = len(b.indexes)
+		b.pos[name] = i
+		b.indexes = append(b.indexes, models.IndexInfo{IndexName: name, Kind: kind})
+	}
+	b.indexes[i].Columns = append(b.indexes[i].Columns, col)
+}
+
+// skip menandai index yang tidak bisa direplikasi, mis. index berbasis ekspresi
+func (b *indexBuilder) skip(name string) {
+	b.skipped[name] = true
+}
+
+func (b *indexBuilder) result() []models.IndexInfo {
+	var indexes []models.IndexInfo
+	for _, idx := range b.indexes {
+		if !b.skipped[idx.IndexName] {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// scopedIndexName memberi prefix nama tabel, untuk dialect yang nama index-nya
+// harus unik per schema (MySQL hanya per tabel)
+func scopedIndexName(tableName, indexName string) string {
+	if strings.HasPrefix(indexName, tableName+"_") {
+		return indexName
+	}
+	return tableName + "_" + indexName
+}
+
+// createIndexStatement membuat CREATE [UNIQUE] INDEX standar SQL
+func createIndexStatement(d Dialect, tableName string, idx models.IndexInfo) string {
+	var cols []string
+	for _, col := range idx.Columns {
+		def := d.QuoteIdentifier(col.ColumnName)
+		if col.Descending {
+			def += " DESC"
+		}
+		cols = append(cols, def)
+	}
+
+	unique := ""
+	if idx.Kind == models.IndexKindUnique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique,
+		d.QuoteIdentifier(d.IndexName(tableName, idx.IndexName)), d.QuoteIdentifier(tableName), strings.Join(cols, ", "))
+}
+
+// adaptPlainIndex dipakai dialect yang hanya mendukung index btree tanpa prefix length
+func adaptPlainIndex(idx models.IndexInfo) (models.IndexInfo, bool) {
+	if idx.Kind == models.IndexKindFulltext || idx.Kind == models.IndexKindSpatial {
+		return idx, false
+	}
+
+	adapted := idx
+	adapted.Columns = make([]models.IndexColumn, len(idx.Columns))
+	for i, col := range idx.Columns {
+		col.SubPart = 0
+		adapted.Columns[i] = col
+	}
+	return adapted, true
+}
+
+// GroupForeignKeys menggabungkan baris per kolom menjadi constraint utuh,
+// urutan constraint dan kolom dipertahankan
+func GroupForeignKeys(fks []models.ForeignKey) []models.ForeignKeyConstraint {
+	var constraints []models.ForeignKeyConstraint
+	pos := make(map[string]int)
+
+	for _, fk := range fks {
+		i, ok := pos[fk.ConstraintName]
+		if !ok {
+			i = len(constraints)
+			pos[fk.ConstraintName] = i
+			constraints = append(constraints, models.ForeignKeyConstraint{
+				ConstraintName:      fk.ConstraintName,
+				TableName:           fk.TableName,
+				ReferencedTableName: fk.ReferencedTableName,
+				UpdateRule:          fk.UpdateRule,
+				DeleteRule:          fk.DeleteRule,
+			})
+		}
+		constraints[i].Columns = append(constraints[i].Columns, fk.ColumnName)
+		constraints[i].ReferencedColumns = append(constraints[i].ReferencedColumns, fk.ReferencedColumnName)
+	}
+
+	return constraints
+}
+
+// foreignKeyClause membuat "CONSTRAINT ... FOREIGN KEY ... REFERENCES ..." standar SQL
+func foreignKeyClause(d Dialect, fk models.ForeignKeyConstraint) string {
+	clause := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		d.QuoteIdentifier(fk.ConstraintName), QuoteIdentifiers(d, fk.Columns),
+		d.QuoteIdentifier(fk.ReferencedTableName), QuoteIdentifiers(d, fk.ReferencedColumns))
+
+	if fk.DeleteRule != "" && fk.DeleteRule != "NO ACTION" {
+		clause += " ON DELETE " + fk.DeleteRule
+	}
+	if fk.UpdateRule != "" && fk.UpdateRule != "NO ACTION" {
+		clause += " ON UPDATE " + fk.UpdateRule
+	}
+
+	return clause
+}
@@ -0,0 +1,578 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+func (mysqlDialect) DSN(conn ConnectionInfo) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		conn.User,
+		conn.Password,
+		conn.Host,
+		conn.Port,
+		conn.Name,
+	)
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) ListTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	query := `SELECT TABLE_NAME
+	          FROM information_schema.TABLES
+	          WHERE TABLE_SCHEMA = DATABASE()
+	          AND TABLE_TYPE = 'BASE TABLE'
+	          ORDER BY TABLE_NAME`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableName)
+	}
+
+	return tables, rows.Err()
+}
+
+func (mysqlDialect) TableExists(ctx context.Context, db *sql.DB, tableName string) (bool, error) {
+	query := `SELECT COUNT(*)
+	          FROM information_schema.TABLES
+	          WHERE TABLE_SCHEMA = DATABASE()
+	          AND TABLE_NAME = ?`
+
+	var count int
+	if err := db.QueryRowContext(ctx, query, tableName).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (mysqlDialect) GetPrimaryKeyColumns(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	query := `SELECT COLUMN_NAME
+	          FROM information_schema.KEY_COLUMN_USAGE
+	          WHERE TABLE_SCHEMA = DATABASE()
+	          AND TABLE_NAME = ?
+	          AND CONSTRAINT_NAME = 'PRIMARY'
+	          ORDER BY ORDINAL_POSITION`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pkColumns []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			return nil, err
+		}
+		pkColumns = append(pkColumns, columnName)
+	}
+
+	return pkColumns, rows.Err()
+}
+
+func (mysqlDialect) GetForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]models.ForeignKey, error) {
+	query := `SELECT
+	            kcu.TABLE_NAME,
+	            kcu.COLUMN_NAME,
+	            kcu.REFERENCED_TABLE_NAME,
+	            kcu.REFERENCED_COLUMN_NAME,
+	            kcu.CONSTRAINT_NAME,
+	            rc.UPDATE_RULE,
+	            rc.DELETE_RULE
+	          FROM information_schema.KEY_COLUMN_USAGE kcu
+	          JOIN information_schema.REFERENTIAL_CONSTRAINTS rc
+	            ON rc.CONSTRAINT_SCHEMA = kcu.TABLE_SCHEMA
+	            AND rc.TABLE_NAME = kcu.TABLE_NAME
+	            AND rc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME
+	          WHERE kcu.TABLE_SCHEMA = DATABASE()
+	          AND kcu.TABLE_NAME = ?
+	          AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
+	          ORDER BY kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []models.ForeignKey
+	for rows.Next() {
+		var fk models.ForeignKey
+		err := rows.Scan(
+			&fk.TableName,
+			&fk.ColumnName,
+			&fk.ReferencedTableName,
+			&fk.ReferencedColumnName,
+			&fk.ConstraintName,
+			&fk.UpdateRule,
+			&fk.DeleteRule,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fks = append(fks, fk)
+	}
+
+	return fks, rows.Err()
+}
+
+func (mysqlDialect) GetIndexes(ctx context.Context, db *sql.DB, tableName string) ([]models.IndexInfo, error) {
+	query := `SELECT INDEX_NAME, NON_UNIQUE, INDEX_TYPE, COLUMN_NAME, SUB_PART, COLLATION
+	          FROM information_schema.STATISTICS
+	          WHERE TABLE_SCHEMA = DATABASE()
+	          AND TABLE_NAME = ?
+	          AND INDEX_NAME <> 'PRIMARY'
+	          ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	builder := newIndexBuilder()
+	for rows.Next() {
+		var (
+			name, indexType string
+			nonUnique       int
+			column          sql.NullString
+			subPart         sql.NullInt64
+			collation       sql.NullString
+		)
+		if err := rows.Scan(&name, &nonUnique, &indexType, &column, &subPart, &collation); err != nil {
+			return nil, err
+		}
+
+		// Functional index (MySQL 8) tidak punya COLUMN_NAME
+		if !column.Valid {
+			builder.skip(name)
+			continue
+		}
+
+		kind := ""
+		switch {
+		case indexType == "FULLTEXT":
+			kind = models.IndexKindFulltext
+		case indexType == "SPATIAL":
+			kind = models.IndexKindSpatial
+		case nonUnique == 0:
+			kind = models.IndexKindUnique
+		}
+
+		builder.add(name, kind, models.IndexColumn{
+			ColumnName: column.String,
+			SubPart:    int(subPart.Int64),
+			Descending: collation.String == "D",
+		})
+	}
+
+	return builder.result(), rows.Err()
+}
+
+func (d mysqlDialect) ShowCreateTable(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	query := fmt.Sprintf("SHOW CREATE TABLE %s", d.QuoteIdentifier(tableName))
+
+	var table, createStmt string
+	if err := db.QueryRowContext(ctx, query).Scan(&table, &createStmt); err != nil {
+		return "", err
+	}
+
+	return createStmt, nil
+}
+
+// GetSchemaObjects membaca DDL lewat SHOW CREATE, butuh privilege yang cukup
+// (SHOW VIEW, TRIGGER, EVENT dan SELECT di mysql.proc/routines)
+func (d mysqlDialect) GetSchemaObjects(ctx context.Context, db *sql.DB) ([]models.SchemaObject, error) {
+	query := `SELECT 'VIEW', TABLE_NAME, '' FROM information_schema.VIEWS WHERE TABLE_SCHEMA = DATABASE()
+	          UNION ALL
+	          SELECT 'TRIGGER', TRIGGER_NAME, EVENT_OBJECT_TABLE FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = DATABASE()
+	          UNION ALL
+	          SELECT ROUTINE_TYPE, ROUTINE_NAME, '' FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = DATABASE()
+	          UNION ALL
+	          SELECT 'EVENT', EVENT_NAME, '' FROM information_schema.EVENTS WHERE EVENT_SCHEMA = DATABASE()`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []models.SchemaObject
+	for rows.Next() {
+		var obj models.SchemaObject
+		if err := rows.Scan(&obj.Type, &obj.Name, &obj.TableName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var schema string
+	if err := db.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&schema); err != nil {
+		return nil, err
+	}
+
+	// Kolom DDL di hasil SHOW CREATE berbeda untuk setiap jenis object
+	createColumn := map[string]string{
+		models.ObjectTypeView:      "Create View",
+		models.ObjectTypeTrigger:   "SQL Original Statement",
+		models.ObjectTypeProcedure: "Create Procedure",
+		models.ObjectTypeFunction:  "Create Function",
+		models.ObjectTypeEvent:     "Create Event",
+	}
+
+	for i, obj := range objects {
+		query := fmt.Sprintf("SHOW CREATE %s %s", obj.Type, d.QuoteIdentifier(obj.Name))
+		def, err := showCreateColumn(ctx, db, query, createColumn[obj.Type])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s %s: %v", strings.ToLower(obj.Type), obj.Name, err)
+		}
+		// SHOW CREATE VIEW menulis tabel sebagai `schema`.`tabel`
+		objects[i].Definition = strings.ReplaceAll(def, d.QuoteIdentifier(schema)+".", "")
+	}
+
+	return objects, nil
+}
+
+// showCreateColumn mengambil satu kolom dari hasil SHOW CREATE berdasarkan nama.
+// Kolom bernilai NULL jika user tidak punya privilege untuk melihat DDL.
+func showCreateColumn(ctx context.Context, db *sql.DB, query, column string) (string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", sql.ErrNoRows
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", err
+	}
+
+	for i, name := range columns {
+		if name != column {
+			continue
+		}
+		if !values[i].Valid {
+			return "", fmt.Errorf("definition not visible, check privileges")
+		}
+		return values[i].String, nil
+	}
+
+	return "", fmt.Errorf("column %q not found", column)
+}
+
+func (d mysqlDialect) CreateTableStatement(tableName string, columns []models.ColumnInfo, sourceCreate string) string {
+	// Source juga MySQL, DDL bisa disalin apa adanya
+	if sourceCreate != "" {
+		return sourceCreate
+	}
+
+	var defs []string
+	for _, col := range columns {
+		defs = append(defs, fmt.Sprintf("%s %s", d.QuoteIdentifier(col.ColumnName), mysqlColumnDefinition(col)))
+	}
+	if pk := PrimaryKeyColumns(columns); len(pk) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", QuoteIdentifiers(d, pk)))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", d.QuoteIdentifier(tableName), strings.Join(defs, ",\n  "))
+}
+
+func (d mysqlDialect) AddColumnStatement(tableName string, col models.ColumnInfo, after string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s %s", d.QuoteIdentifier(tableName),
+		d.QuoteIdentifier(col.ColumnName), mysqlColumnDefinition(col), d.columnPosition(after))
+}
+
+func (d mysqlDialect) ModifyColumnStatement(tableName string, col models.ColumnInfo) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", d.QuoteIdentifier(tableName),
+		d.QuoteIdentifier(col.ColumnName), mysqlColumnDefinition(col)), true
+}
+
+func (d mysqlDialect) RenameColumnStatement(tableName, oldName string, col models.ColumnInfo) string {
+	// CHANGE COLUMN juga jalan di MySQL 5.7 yang belum punya RENAME COLUMN
+	return fmt.Sprintf("ALTER TABLE %s CHANGE COLUMN %s %s %s", d.QuoteIdentifier(tableName),
+		d.QuoteIdentifier(oldName), d.QuoteIdentifier(col.ColumnName), mysqlColumnDefinition(col))
+}
+
+func (d mysqlDialect) DropColumnStatement(tableName, columnName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", d.QuoteIdentifier(tableName), d.QuoteIdentifier(columnName))
+}
+
+func (d mysqlDialect) DropNotNullStatement(tableName string, col models.ColumnInfo) (string, bool) {
+	col.IsNullable = "YES"
+	return d.ModifyColumnStatement(tableName, col)
+}
+
+func (d mysqlDialect) MoveColumnStatement(tableName string, col models.ColumnInfo, after string) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s %s", d.QuoteIdentifier(tableName),
+		d.QuoteIdentifier(col.ColumnName), mysqlColumnDefinition(col), d.columnPosition(after)), true
+}
+
+// columnPosition membuat klausa FIRST atau AFTER untuk ADD/MODIFY COLUMN
+func (d mysqlDialect) columnPosition(after string) string {
+	if after == "" {
+		return "FIRST"
+	}
+	return "AFTER " + d.QuoteIdentifier(after)
+}
+
+func (d mysqlDialect) AlterClause(tableName, stmt string) (string, bool) {
+	prefix := "ALTER TABLE " + d.QuoteIdentifier(tableName) + " "
+	if !strings.HasPrefix(stmt, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(stmt, prefix), true
+}
+
+func (d mysqlDialect) AlterTableStatement(tableName string, clauses []string) string {
+	return fmt.Sprintf("ALTER TABLE %s %s", d.QuoteIdentifier(tableName), strings.Join(clauses, ", "))
+}
+
+// OnlineAlterOptions: INSTANT (MySQL 8.0.12+, MariaDB 10.3+) tidak menyalin data,
+// INPLACE dengan LOCK=NONE tetap mengizinkan baca dan tulis selama ALTER
+func (mysqlDialect) OnlineAlterOptions() []string {
+	return []string{"ALGORITHM=INSTANT", "ALGORITHM=INPLACE, LOCK=NONE"}
+}
+
+// UnsupportedAlter mengenali ER_ALTER_OPERATION_NOT_SUPPORTED(_REASON) dan syntax
+// error dari server yang belum mengenal ALGORITHM=INSTANT (MySQL 5.7)
+func (mysqlDialect) UnsupportedAlter(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case 1064, 1845, 1846:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d mysqlDialect) RenameTableStatement(oldName, newName string) string {
+	return fmt.Sprintf("RENAME TABLE %s TO %s", d.QuoteIdentifier(oldName), d.QuoteIdentifier(newName))
+}
+
+func (d mysqlDialect) ShadowTableStatements(tableName, shadowName, oldName string) (string, string, bool) {
+	create := fmt.Sprintf("CREATE TABLE %s LIKE %s", d.QuoteIdentifier(shadowName), d.QuoteIdentifier(tableName))
+	// RENAME TABLE dengan beberapa pasangan berjalan atomik
+	swap := fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s",
+		d.QuoteIdentifier(tableName), d.QuoteIdentifier(oldName), d.QuoteIdentifier(shadowName), d.QuoteIdentifier(tableName))
+	return create, swap, true
+}
+
+func (mysqlDialect) AdaptIndex(idx models.IndexInfo) (models.IndexInfo, bool) {
+	return idx, true
+}
+
+func (mysqlDialect) IndexName(tableName, indexName string) string {
+	return indexName
+}
+
+func (d mysqlDialect) CreateIndexStatement(tableName string, idx models.IndexInfo) string {
+	var cols []string
+	for _, col := range idx.Columns {
+		def := d.QuoteIdentifier(col.ColumnName)
+		if col.SubPart > 0 {
+			def += fmt.Sprintf("(%d)", col.SubPart)
+		}
+		if col.Descending {
+			def += " DESC"
+		}
+		cols = append(cols, def)
+	}
+
+	kind := ""
+	if idx.Kind != "" {
+		kind = idx.Kind + " "
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s ADD %sINDEX %s (%s)",
+		d.QuoteIdentifier(tableName), kind, d.QuoteIdentifier(idx.IndexName), strings.Join(cols, ", "))
+}
+
+func (d mysqlDialect) DropIndexStatement(tableName string, idx models.IndexInfo) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", d.QuoteIdentifier(tableName), d.QuoteIdentifier(idx.IndexName))
+}
+
+func (d mysqlDialect) AddForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", d.QuoteIdentifier(tableName), foreignKeyClause(d, fk)), true
+}
+
+func (d mysqlDialect) DropForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", d.QuoteIdentifier(tableName), d.QuoteIdentifier(fk.ConstraintName)), true
+}
+
+// StripForeignKeys menghapus baris CONSTRAINT ... FOREIGN KEY dari SHOW CREATE TABLE
+func (mysqlDialect) StripForeignKeys(createStmt string) string {
+	lines := strings.Split(createStmt, "\n")
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "CONSTRAINT ") && strings.Contains(trimmed, " FOREIGN KEY ") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	// Definisi terakhir sebelum ") ENGINE=..." tidak boleh diakhiri koma
+	for i := 1; i < len(kept); i++ {
+		if strings.HasPrefix(strings.TrimSpace(kept[i]), ")") {
+			kept[i-1] = strings.TrimSuffix(kept[i-1], ",")
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+func (d mysqlDialect) UpsertStatement(tableName string, columns, pkColumns []string) string {
+	isPK := make(map[string]bool)
+	for _, pk := range pkColumns {
+		isPK[pk] = true
+	}
+
+	var placeholders []string
+	var updates []string
+	for _, col := range columns {
+		placeholders = append(placeholders, "?")
+
+		// Untuk ON DUPLICATE KEY UPDATE
+		if !isPK[col] {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", d.QuoteIdentifier(col), d.QuoteIdentifier(col)))
+		}
+	}
+
+	// Tabel yang semua kolomnya PK cukup di-update ke nilai yang sama
+	if len(updates) == 0 && len(pkColumns) > 0 {
+		updates = append(updates, fmt.Sprintf("%s = %s", d.QuoteIdentifier(pkColumns[0]), d.QuoteIdentifier(pkColumns[0])))
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		d.QuoteIdentifier(tableName),
+		QuoteIdentifiers(d, columns),
+		strings.Join(placeholders, ", "),
+		strings.Join(updates, ", "),
+	)
+}
+
+// ChecksumExpression membuat ekspresi MD5 per baris, COALESCE dipakai untuk handle NULL
+func (d mysqlDialect) ChecksumExpression(columns []string) string {
+	var concatColumns []string
+	for _, col := range columns {
+		concatColumns = append(concatColumns, fmt.Sprintf("COALESCE(%s, '')", d.QuoteIdentifier(col)))
+	}
+	return fmt.Sprintf("MD5(CONCAT_WS('|', %s)) as row_checksum", strings.Join(concatColumns, ", "))
+}
+
+// SnapshotStatements memakai CONSISTENT SNAPSHOT supaya snapshot InnoDB dibuat
+// saat transaksi dimulai, bukan saat query pertama
+func (mysqlDialect) SnapshotStatements() []string {
+	return []string{
+		"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+	}
+}
+
+func (mysqlDialect) ForeignKeyChecksStatements() (string, string) {
+	return "SET FOREIGN_KEY_CHECKS = 0", "SET FOREIGN_KEY_CHECKS = 1"
+}
+
+// mysqlColumnDefinition membuat definisi kolom MySQL (tanpa nama kolom)
+var (
+	mysqlDefinerPattern     = regexp.MustCompile("DEFINER\\s*=\\s*(`[^`]*`@`[^`]*`|'[^']*'@'[^']*'|CURRENT_USER(\\(\\))?|\\S+)\\s*")
+	mysqlEventStatusPattern = regexp.MustCompile(`\s(ENABLE|DISABLE ON (SLAVE|REPLICA)|DISABLE)\s`)
+)
+
+func (mysqlDialect) ObjectDefinition(obj models.SchemaObject, definer string) string {
+	def := obj.Definition
+
+	// Tanpa DEFINER, object dimiliki user koneksi backup
+	if loc := mysqlDefinerPattern.FindStringIndex(def); loc != nil {
+		replacement := ""
+		if definer != "" {
+			replacement = "DEFINER=" + definer + " "
+		}
+		def = def[:loc[0]] + replacement + def[loc[1]:]
+	}
+
+	// Event di backup tidak boleh jalan, datanya sudah datang dari master
+	if obj.Type == models.ObjectTypeEvent {
+		do := strings.Index(def, " DO ")
+		if do < 0 {
+			return def
+		}
+		head := def[:do+1]
+		if loc := mysqlEventStatusPattern.FindStringIndex(head); loc != nil {
+			head = head[:loc[0]] + " DISABLE " + head[loc[1]:]
+		} else {
+			head += "DISABLE "
+		}
+		def = head + def[do+1:]
+	}
+
+	return def
+}
+
+func (d mysqlDialect) DropObjectStatement(obj models.SchemaObject) string {
+	return fmt.Sprintf("DROP %s IF EXISTS %s", obj.Type, d.QuoteIdentifier(obj.Name))
+}
+
+// DisableTriggerStatement tidak didukung, MySQL tidak punya trigger yang nonaktif
+func (mysqlDialect) DisableTriggerStatement(obj models.SchemaObject) (string, bool) {
+	return "", false
+}
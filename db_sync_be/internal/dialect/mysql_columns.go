@@ -0,0 +1,370 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// Ekspresi waktu yang boleh menjadi DEFAULT tanpa tanda kurung, juga di MySQL 5.7
+	timestampDefaultPattern = regexp.MustCompile(`(?i)^(current_timestamp|now|localtime|localtimestamp)\s*(?:\(\s*(\d*)\s*\))?$`)
+	onUpdatePattern         = regexp.MustCompile(`(?i)on update (\w+\s*(?:\(\s*\d*\s*\))?)`)
+	mariaDBVersionPattern   = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+).*MariaDB`)
+	integerWidthPattern     = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	bitOrHexLiteralPattern  = regexp.MustCompile(`(?i)^(?:b'[01]*'|0x[0-9a-f]+)$`)
+
+	// mysqlVersions menyimpan hasil SELECT VERSION() per pool koneksi (*sql.DB)
+	mysqlVersions sync.Map
+)
+
+// GetColumns membaca seluruh kolom information_schema.COLUMNS supaya kolom yang
+// hanya ada di versi tertentu (SRS_ID di MySQL 8, IS_GENERATED di MariaDB) tidak
+// membuat query gagal. Default dan EXTRA dinormalkan ke bentuk yang sama untuk
+// MySQL 5.7, 8.x dan MariaDB.
+func (mysqlDialect) GetColumns(ctx context.Context, db *sql.DB, tableName string) ([]models.ColumnInfo, error) {
+	version, err := mysqlServerVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	quotedDefaults := mariaDBQuotedDefaults(version)
+
+	query := `SELECT *
+	          FROM information_schema.COLUMNS
+	          WHERE TABLE_SCHEMA = DATABASE()
+	          AND TABLE_NAME = ?
+	          ORDER BY ORDINAL_POSITION`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []models.ColumnInfo
+	for rows.Next() {
+		values := make([]sql.NullString, len(names))
+		dest := make([]interface{}, len(names))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		field := make(map[string]sql.NullString, len(names))
+		for i, name := range names {
+			field[strings.ToUpper(name)] = values[i]
+		}
+
+		col := models.ColumnInfo{
+			ColumnName:           field["COLUMN_NAME"].String,
+			DataType:             field["DATA_TYPE"].String,
+			ColumnType:           field["COLUMN_TYPE"].String,
+			IsNullable:           field["IS_NULLABLE"].String,
+			ColumnKey:            field["COLUMN_KEY"].String,
+			ColumnDefault:        nullableString(field["COLUMN_DEFAULT"]),
+			Extra:                field["EXTRA"].String,
+			GenerationExpression: field["GENERATION_EXPRESSION"].String,
+			CharacterSetName:     nullableString(field["CHARACTER_SET_NAME"]),
+			CollationName:        nullableString(field["COLLATION_NAME"]),
+			ColumnComment:        field["COLUMN_COMMENT"].String,
+			SrsID:                nullableString(field["SRS_ID"]),
+		}
+		normalizeMySQLColumn(&col, quotedDefaults)
+		columns = append(columns, col)
+	}
+
+	return columns, rows.Err()
+}
+
+// mysqlServerVersion membaca versi server sekali per pool koneksi, GetColumns
+// dipanggil untuk setiap tabel di setiap siklus sync
+func mysqlServerVersion(ctx context.Context, db *sql.DB) (string, error) {
+	if version, ok := mysqlVersions.Load(db); ok {
+		return version.(string), nil
+	}
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return "", err
+	}
+	mysqlVersions.Store(db, version)
+	return version, nil
+}
+
+func (mysqlDialect) ColumnsDifferent(masterCol, backupCol models.ColumnInfo) bool {
+	if comparableColumnType(masterCol.ColumnType) != comparableColumnType(backupCol.ColumnType) ||
+		masterCol.IsNullable != backupCol.IsNullable ||
+		masterCol.Extra != backupCol.Extra ||
+		masterCol.GenerationExpression != backupCol.GenerationExpression ||
+		masterCol.ColumnComment != backupCol.ColumnComment ||
+		!equalStringPtr(masterCol.SrsID, backupCol.SrsID) {
+		return true
+	}
+
+	// Kolom generated tidak punya default
+	if masterCol.GenerationExpression == "" &&
+		(!equalStringPtr(masterCol.ColumnDefault, backupCol.ColumnDefault) || masterCol.DefaultExpression != backupCol.DefaultExpression) {
+		return true
+	}
+
+	// Charset dan collation hanya dibandingkan jika master menyediakannya
+	if masterCol.CharacterSetName != nil && !equalStringPtr(masterCol.CharacterSetName, backupCol.CharacterSetName) {
+		return true
+	}
+	return masterCol.CollationName != nil && !equalStringPtr(masterCol.CollationName, backupCol.CollationName)
+}
+
+// comparableColumnType membuang display width integer yang tidak lagi ditampilkan
+// MySQL 8.0.19+, kecuali tinyint(1) (boolean) dan kolom ZEROFILL
+func comparableColumnType(columnType string) string {
+	lower := strings.ToLower(columnType)
+	if strings.HasPrefix(lower, "tinyint(1)") || strings.Contains(lower, "zerofill") {
+		return lower
+	}
+	return integerWidthPattern.ReplaceAllString(lower, "$1")
+}
+
+// mariaDBQuotedDefaults: sejak MariaDB 10.2.7 COLUMN_DEFAULT berisi literal SQL
+// (string ber-quote, NULL sebagai 'NULL'), bukan nilai mentah seperti MySQL
+func mariaDBQuotedDefaults(version string) bool {
+	match := mariaDBVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return major > 10 || (major == 10 && (minor > 2 || (minor == 2 && patch >= 7)))
+}
+
+// normalizeMySQLColumn menyamakan metadata kolom antar versi: default dipisah
+// menjadi nilai literal atau ekspresi, DEFAULT_GENERATED dibuang dari EXTRA,
+// CURRENT_TIMESTAMP ditulis dalam satu bentuk dan utf8mb3 disebut utf8.
+func normalizeMySQLColumn(col *models.ColumnInfo, quotedDefaults bool) {
+	if quotedDefaults && col.ColumnDefault != nil && *col.ColumnDefault == "NULL" {
+		col.ColumnDefault = nil
+	}
+
+	if col.ColumnDefault != nil {
+		value := *col.ColumnDefault
+		expression := false
+
+		if quotedDefaults {
+			switch {
+			case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+				value = unescapeMySQLString(value[1 : len(value)-1])
+			default:
+				_, err := strconv.ParseFloat(value, 64)
+				expression = err != nil && !mysqlBitOrHexLiteral(col.DataType, value)
+			}
+		} else {
+			// MySQL 5.7 tidak menandai CURRENT_TIMESTAMP dengan DEFAULT_GENERATED
+			expression = strings.Contains(strings.ToUpper(col.Extra), "DEFAULT_GENERATED") ||
+				(mysqlTemporalType(col.DataType) && timestampDefaultPattern.MatchString(value))
+		}
+
+		if expression {
+			value = canonicalTimestamp(unescapeExpression(value))
+		}
+		col.ColumnDefault = &value
+		col.DefaultExpression = expression
+	}
+
+	col.GenerationExpression = unescapeExpression(col.GenerationExpression)
+	col.Extra = normalizeMySQLExtra(col.Extra)
+
+	if col.CharacterSetName != nil && *col.CharacterSetName == "utf8mb3" {
+		charset := "utf8"
+		col.CharacterSetName = &charset
+	}
+	if col.CollationName != nil && strings.HasPrefix(*col.CollationName, "utf8mb3_") {
+		collation := "utf8_" + strings.TrimPrefix(*col.CollationName, "utf8mb3_")
+		col.CollationName = &collation
+	}
+}
+
+// normalizeMySQLExtra menyusun ulang EXTRA dari atribut yang dikenal:
+// auto_increment, on update, VIRTUAL/STORED GENERATED dan INVISIBLE
+func normalizeMySQLExtra(extra string) string {
+	lower := strings.ToLower(extra)
+	var parts []string
+
+	if strings.Contains(lower, "auto_increment") {
+		parts = append(parts, "auto_increment")
+	}
+	if match := onUpdatePattern.FindStringSubmatch(extra); match != nil {
+		parts = append(parts, "on update "+canonicalTimestamp(match[1]))
+	}
+	switch {
+	case strings.Contains(lower, "virtual generated"):
+		parts = append(parts, "VIRTUAL GENERATED")
+	case strings.Contains(lower, "stored generated"), strings.Contains(lower, "persistent generated"):
+		parts = append(parts, "STORED GENERATED")
+	}
+	if strings.Contains(lower, "invisible") {
+		parts = append(parts, "INVISIBLE")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// canonicalTimestamp menulis CURRENT_TIMESTAMP, now(), current_timestamp(3) dst.
+// sebagai CURRENT_TIMESTAMP atau CURRENT_TIMESTAMP(n)
+func canonicalTimestamp(expr string) string {
+	match := timestampDefaultPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if match == nil {
+		return expr
+	}
+	if match[2] == "" || match[2] == "0" {
+		return "CURRENT_TIMESTAMP"
+	}
+	return "CURRENT_TIMESTAMP(" + match[2] + ")"
+}
+
+// unescapeExpression membuang escape quote yang ditambahkan MySQL 8 pada
+// GENERATION_EXPRESSION dan default ekspresi, mis. _utf8mb4\'abc\'
+func unescapeExpression(expr string) string {
+	return strings.ReplaceAll(expr, `\'`, `'`)
+}
+
+func unescapeMySQLString(value string) string {
+	return strings.NewReplacer(`''`, `'`, `\'`, `'`, `\\`, `\`).Replace(value)
+}
+
+// mysqlColumnDefinition menyusun definisi kolom lengkap untuk CREATE, ADD,
+// MODIFY dan CHANGE COLUMN dari metadata information_schema.COLUMNS
+func mysqlColumnDefinition(col models.ColumnInfo) string {
+	parts := []string{col.ColumnType}
+
+	if col.CharacterSetName != nil {
+		parts = append(parts, "CHARACTER SET "+*col.CharacterSetName)
+	}
+	if col.CollationName != nil {
+		parts = append(parts, "COLLATE "+*col.CollationName)
+	}
+
+	extra := strings.ToUpper(col.Extra)
+	generated := ""
+	switch {
+	case strings.Contains(extra, "VIRTUAL GENERATED"):
+		generated = "VIRTUAL"
+	case strings.Contains(extra, "STORED GENERATED"):
+		generated = "STORED"
+	}
+	if generated != "" && col.GenerationExpression != "" {
+		parts = append(parts, fmt.Sprintf("GENERATED ALWAYS AS (%s) %s", col.GenerationExpression, generated))
+	}
+
+	if col.IsNullable == "NO" {
+		parts = append(parts, "NOT NULL")
+	} else if strings.EqualFold(col.DataType, "timestamp") {
+		// Tanpa explicit_defaults_for_timestamp, TIMESTAMP tanpa NULL menjadi NOT NULL
+		parts = append(parts, "NULL")
+	}
+
+	if generated == "" && col.ColumnDefault != nil {
+		parts = append(parts, "DEFAULT "+mysqlDefault(col))
+	}
+
+	if strings.Contains(extra, "AUTO_INCREMENT") {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+	if match := onUpdatePattern.FindStringSubmatch(col.Extra); match != nil {
+		parts = append(parts, "ON UPDATE "+canonicalTimestamp(match[1]))
+	}
+
+	if col.ColumnComment != "" {
+		parts = append(parts, "COMMENT "+mysqlQuote(col.ColumnComment))
+	}
+	if col.SrsID != nil {
+		parts = append(parts, "SRID "+*col.SrsID)
+	}
+	if strings.Contains(extra, "INVISIBLE") {
+		parts = append(parts, "INVISIBLE")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// mysqlDefault menulis DEFAULT sesuai jenisnya: ekspresi dalam tanda kurung
+// (MySQL 8.0.13+, kecuali CURRENT_TIMESTAMP), angka apa adanya, sisanya string literal
+func mysqlDefault(col models.ColumnInfo) string {
+	value := *col.ColumnDefault
+
+	if col.DefaultExpression {
+		if timestampDefaultPattern.MatchString(value) {
+			return value
+		}
+		return "(" + value + ")"
+	}
+
+	if mysqlBitOrHexLiteral(col.DataType, value) {
+		return value
+	}
+	if mysqlNumericType(col.DataType) {
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value
+		}
+	}
+	return mysqlQuote(value)
+}
+
+func mysqlQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(value) + "'"
+}
+
+// mysqlBitOrHexLiteral mengenali b'0101' dan 0x1F yang dipakai MySQL 8 untuk default
+// BIT dan BINARY. Kolom tipe lain (mis. varchar dengan default '0x1F') tetap string.
+func mysqlBitOrHexLiteral(dataType, value string) bool {
+	switch strings.ToLower(dataType) {
+	case "bit", "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return bitOrHexLiteralPattern.MatchString(value)
+	default:
+		return false
+	}
+}
+
+func mysqlNumericType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint",
+		"decimal", "numeric", "float", "double", "real", "year":
+		return true
+	default:
+		return false
+	}
+}
+
+func mysqlTemporalType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "timestamp", "datetime":
+		return true
+	default:
+		return false
+	}
+}
+
+func nullableString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,245 @@
+package dialect
+
+import (
+	"testing"
+
+	"db-sync-scheduler/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestMariaDBQuotedDefaults(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"5.7.44-log", false},
+		{"8.0.36", false},
+		{"10.2.6-MariaDB", false},
+		{"10.2.7-MariaDB", true},
+		{"10.6.16-MariaDB-1:10.6.16+maria~ubu2004", true},
+		{"11.2.2-MariaDB", true},
+	}
+
+	for _, tt := range tests {
+		if got := mariaDBQuotedDefaults(tt.version); got != tt.want {
+			t.Errorf("mariaDBQuotedDefaults(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeMySQLColumn(t *testing.T) {
+	tests := []struct {
+		name           string
+		col            models.ColumnInfo
+		quotedDefaults bool
+		wantDefault    *string
+		wantExpression bool
+		wantExtra      string
+	}{
+		{
+			name:           "mysql 5.7 current_timestamp without DEFAULT_GENERATED",
+			col:            models.ColumnInfo{DataType: "timestamp", ColumnDefault: strPtr("CURRENT_TIMESTAMP"), Extra: "on update CURRENT_TIMESTAMP"},
+			wantDefault:    strPtr("CURRENT_TIMESTAMP"),
+			wantExpression: true,
+			wantExtra:      "on update CURRENT_TIMESTAMP",
+		},
+		{
+			name:           "mysql 8 current_timestamp with DEFAULT_GENERATED",
+			col:            models.ColumnInfo{DataType: "datetime", ColumnDefault: strPtr("CURRENT_TIMESTAMP(3)"), Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP(3)"},
+			wantDefault:    strPtr("CURRENT_TIMESTAMP(3)"),
+			wantExpression: true,
+			wantExtra:      "on update CURRENT_TIMESTAMP(3)",
+		},
+		{
+			name:           "mariadb current_timestamp()",
+			col:            models.ColumnInfo{DataType: "timestamp", ColumnDefault: strPtr("current_timestamp()"), Extra: "on update current_timestamp()"},
+			quotedDefaults: true,
+			wantDefault:    strPtr("CURRENT_TIMESTAMP"),
+			wantExpression: true,
+			wantExtra:      "on update CURRENT_TIMESTAMP",
+		},
+		{
+			name:        "mysql 5.7 string default",
+			col:         models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr("CURRENT_TIMESTAMP")},
+			wantDefault: strPtr("CURRENT_TIMESTAMP"),
+		},
+		{
+			name:           "mysql 8 expression default with escaped quotes",
+			col:            models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr(`concat(_utf8mb4\'a\',_utf8mb4\'b\')`), Extra: "DEFAULT_GENERATED"},
+			wantDefault:    strPtr("concat(_utf8mb4'a',_utf8mb4'b')"),
+			wantExpression: true,
+		},
+		{
+			name:           "mariadb quoted string default",
+			col:            models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr(`'it''s'`)},
+			quotedDefaults: true,
+			wantDefault:    strPtr("it's"),
+		},
+		{
+			name:           "mariadb NULL default",
+			col:            models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr("NULL")},
+			quotedDefaults: true,
+		},
+		{
+			name:           "mariadb numeric default",
+			col:            models.ColumnInfo{DataType: "int", ColumnDefault: strPtr("0")},
+			quotedDefaults: true,
+			wantDefault:    strPtr("0"),
+		},
+		{
+			name:           "mariadb bit default",
+			col:            models.ColumnInfo{DataType: "bit", ColumnDefault: strPtr("b'1'")},
+			quotedDefaults: true,
+			wantDefault:    strPtr("b'1'"),
+		},
+		{
+			name:           "mariadb expression default",
+			col:            models.ColumnInfo{DataType: "char", ColumnDefault: strPtr("uuid()")},
+			quotedDefaults: true,
+			wantDefault:    strPtr("uuid()"),
+			wantExpression: true,
+		},
+		{
+			name:      "mariadb persistent generated",
+			col:       models.ColumnInfo{DataType: "int", Extra: "PERSISTENT GENERATED"},
+			wantExtra: "STORED GENERATED",
+		},
+		{
+			name:      "mysql 8 invisible auto_increment",
+			col:       models.ColumnInfo{DataType: "bigint", Extra: "auto_increment INVISIBLE"},
+			wantExtra: "auto_increment INVISIBLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := tt.col
+			normalizeMySQLColumn(&col, tt.quotedDefaults)
+
+			if !equalStringPtr(col.ColumnDefault, tt.wantDefault) {
+				t.Errorf("default = %v, want %v", derefString(col.ColumnDefault), derefString(tt.wantDefault))
+			}
+			if col.DefaultExpression != tt.wantExpression {
+				t.Errorf("DefaultExpression = %v, want %v", col.DefaultExpression, tt.wantExpression)
+			}
+			if col.Extra != tt.wantExtra {
+				t.Errorf("Extra = %q, want %q", col.Extra, tt.wantExtra)
+			}
+		})
+	}
+}
+
+func TestNormalizeMySQLColumnUTF8MB3(t *testing.T) {
+	col := models.ColumnInfo{
+		DataType:         "varchar",
+		CharacterSetName: strPtr("utf8mb3"),
+		CollationName:    strPtr("utf8mb3_general_ci"),
+	}
+	normalizeMySQLColumn(&col, false)
+
+	if *col.CharacterSetName != "utf8" || *col.CollationName != "utf8_general_ci" {
+		t.Errorf("charset %s collation %s, want utf8 utf8_general_ci", *col.CharacterSetName, *col.CollationName)
+	}
+}
+
+func TestMySQLDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		col  models.ColumnInfo
+		want string
+	}{
+		{"current_timestamp expression", models.ColumnInfo{DataType: "timestamp", ColumnDefault: strPtr("CURRENT_TIMESTAMP(6)"), DefaultExpression: true}, "CURRENT_TIMESTAMP(6)"},
+		{"function expression", models.ColumnInfo{DataType: "char", ColumnDefault: strPtr("uuid()"), DefaultExpression: true}, "(uuid())"},
+		{"numeric", models.ColumnInfo{DataType: "decimal", ColumnDefault: strPtr("0.00")}, "0.00"},
+		{"numeric text in varchar", models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr("10")}, "'10'"},
+		{"bit literal", models.ColumnInfo{DataType: "bit", ColumnDefault: strPtr("b'101'")}, "b'101'"},
+		{"hex literal", models.ColumnInfo{DataType: "binary", ColumnDefault: strPtr("0x1F")}, "0x1F"},
+		{"hex literal in varbinary", models.ColumnInfo{DataType: "varbinary", ColumnDefault: strPtr("0xCAFE")}, "0xCAFE"},
+		{"hex text in varchar", models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr("0x1F")}, "'0x1F'"},
+		{"bit text in char", models.ColumnInfo{DataType: "char", ColumnDefault: strPtr("b'1'")}, "'b''1'''"},
+		{"invalid hex in binary", models.ColumnInfo{DataType: "binary", ColumnDefault: strPtr("0xZZ")}, "'0xZZ'"},
+		{"string with quote and backslash", models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr(`it's C:\tmp`)}, `'it''s C:\\tmp'`},
+		{"empty string", models.ColumnInfo{DataType: "varchar", ColumnDefault: strPtr("")}, "''"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mysqlDefault(tt.col); got != tt.want {
+				t.Errorf("mysqlDefault() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLColumnDefinition(t *testing.T) {
+	tests := []struct {
+		name string
+		col  models.ColumnInfo
+		want string
+	}{
+		{
+			name: "varchar with charset, default and comment",
+			col: models.ColumnInfo{
+				ColumnType: "varchar(50)", DataType: "varchar", IsNullable: "NO",
+				ColumnDefault: strPtr("active"), CharacterSetName: strPtr("utf8mb4"),
+				CollationName: strPtr("utf8mb4_0900_ai_ci"), ColumnComment: "customer's status",
+			},
+			want: "varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'active' COMMENT 'customer''s status'",
+		},
+		{
+			name: "auto_increment primary key",
+			col:  models.ColumnInfo{ColumnType: "int unsigned", DataType: "int", IsNullable: "NO", Extra: "auto_increment"},
+			want: "int unsigned NOT NULL AUTO_INCREMENT",
+		},
+		{
+			name: "nullable timestamp keeps explicit NULL",
+			col: models.ColumnInfo{
+				ColumnType: "timestamp", DataType: "timestamp", IsNullable: "YES",
+				ColumnDefault: strPtr("CURRENT_TIMESTAMP"), DefaultExpression: true, Extra: "on update CURRENT_TIMESTAMP",
+			},
+			want: "timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP",
+		},
+		{
+			name: "stored generated column has no default",
+			col: models.ColumnInfo{
+				ColumnType: "decimal(10,2)", DataType: "decimal", IsNullable: "YES",
+				ColumnDefault: strPtr("0"), Extra: "STORED GENERATED", GenerationExpression: "`quantityOrdered` * `priceEach`",
+			},
+			want: "decimal(10,2) GENERATED ALWAYS AS (`quantityOrdered` * `priceEach`) STORED",
+		},
+		{
+			name: "virtual generated column",
+			col: models.ColumnInfo{
+				ColumnType: "varchar(101)", DataType: "varchar", IsNullable: "YES",
+				Extra: "VIRTUAL GENERATED", GenerationExpression: "concat(`first`,' ',`last`)",
+			},
+			want: "varchar(101) GENERATED ALWAYS AS (concat(`first`,' ',`last`)) VIRTUAL",
+		},
+		{
+			name: "mysql 8 spatial column with SRID",
+			col:  models.ColumnInfo{ColumnType: "point", DataType: "point", IsNullable: "NO", SrsID: strPtr("4326")},
+			want: "point NOT NULL SRID 4326",
+		},
+		{
+			name: "mysql 8 invisible column",
+			col:  models.ColumnInfo{ColumnType: "int", DataType: "int", IsNullable: "YES", Extra: "INVISIBLE"},
+			want: "int INVISIBLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mysqlColumnDefinition(tt.col); got != tt.want {
+				t.Errorf("mysqlColumnDefinition()\n got: %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
@@ -0,0 +1,142 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var rowFormatPattern = regexp.MustCompile(`(?i)row_format=(\w+)`)
+
+// GetTableOptions membaca opsi tabel dari information_schema.TABLES dan partisi
+// dari information_schema.PARTITIONS (satu baris per partisi, subpartisi diabaikan)
+func (mysqlDialect) GetTableOptions(ctx context.Context, db *sql.DB, tableName string) (*models.TableOptions, error) {
+	query := `SELECT t.ENGINE, c.CHARACTER_SET_NAME, t.TABLE_COLLATION, t.CREATE_OPTIONS, t.TABLE_COMMENT
+	          FROM information_schema.TABLES t
+	          LEFT JOIN information_schema.COLLATION_CHARACTER_SET_APPLICABILITY c ON c.COLLATION_NAME = t.TABLE_COLLATION
+	          WHERE t.TABLE_SCHEMA = DATABASE()
+	          AND t.TABLE_NAME = ?
+	          LIMIT 1`
+
+	var engine, charset, collation, createOptions, comment sql.NullString
+	err := db.QueryRowContext(ctx, query, tableName).Scan(&engine, &charset, &collation, &createOptions, &comment)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &models.TableOptions{
+		Engine:    engine.String,
+		Charset:   charset.String,
+		Collation: collation.String,
+		Comment:   comment.String,
+	}
+	if match := rowFormatPattern.FindStringSubmatch(createOptions.String); match != nil {
+		opts.RowFormat = strings.ToUpper(match[1])
+	}
+	if opts.Charset == "utf8mb3" {
+		opts.Charset = "utf8"
+		opts.Collation = "utf8_" + strings.TrimPrefix(opts.Collation, "utf8mb3_")
+	}
+
+	partitionQuery := `SELECT PARTITION_NAME, PARTITION_METHOD, PARTITION_EXPRESSION, PARTITION_DESCRIPTION
+	                   FROM information_schema.PARTITIONS
+	                   WHERE TABLE_SCHEMA = DATABASE()
+	                   AND TABLE_NAME = ?
+	                   AND PARTITION_NAME IS NOT NULL
+	                   AND (SUBPARTITION_ORDINAL_POSITION IS NULL OR SUBPARTITION_ORDINAL_POSITION = 1)
+	                   ORDER BY PARTITION_ORDINAL_POSITION`
+
+	rows, err := db.QueryContext(ctx, partitionQuery, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, method, expression, description sql.NullString
+		if err := rows.Scan(&name, &method, &expression, &description); err != nil {
+			return nil, err
+		}
+		if opts.Partitioning == nil {
+			opts.Partitioning = &models.Partitioning{Method: method.String, Expression: expression.String}
+		}
+		opts.Partitioning.Partitions = append(opts.Partitioning.Partitions, models.Partition{
+			Name:        name.String,
+			Description: description.String,
+		})
+	}
+
+	return opts, rows.Err()
+}
+
+func (d mysqlDialect) TableOptionStatement(tableName, option string, opts models.TableOptions) (string, bool) {
+	var clause string
+	switch option {
+	case TableOptionEngine:
+		clause = "ENGINE=" + opts.Engine
+	case TableOptionCollation:
+		clause = fmt.Sprintf("DEFAULT CHARSET=%s COLLATE=%s", opts.Charset, opts.Collation)
+	case TableOptionRowFormat:
+		clause = "ROW_FORMAT=DEFAULT"
+		if opts.RowFormat != "" {
+			clause = "ROW_FORMAT=" + opts.RowFormat
+		}
+	case TableOptionComment:
+		clause = "COMMENT=" + mysqlQuote(opts.Comment)
+	default:
+		return "", false
+	}
+	return fmt.Sprintf("ALTER TABLE %s %s", d.QuoteIdentifier(tableName), clause), true
+}
+
+// PartitionStatement membuat ALTER TABLE untuk satu operasi partisi. Operasi
+// partisi MySQL tidak bisa digabung dengan perubahan lain dalam satu ALTER.
+func (d mysqlDialect) PartitionStatement(tableName string, change models.PartitionChange) (string, bool) {
+	table := d.QuoteIdentifier(tableName)
+	p := change.Partitioning
+
+	switch change.Action {
+	case PartitionBy:
+		clause := fmt.Sprintf("PARTITION BY %s (%s)", p.Method, p.Expression)
+		if strings.HasSuffix(p.Method, "HASH") || strings.HasSuffix(p.Method, "KEY") {
+			return fmt.Sprintf("ALTER TABLE %s %s PARTITIONS %d", table, clause, len(p.Partitions)), true
+		}
+		return fmt.Sprintf("ALTER TABLE %s %s (%s)", table, clause, mysqlPartitionDefinitions(d, p)), true
+	case RemovePartitioning:
+		return fmt.Sprintf("ALTER TABLE %s REMOVE PARTITIONING", table), true
+	case AddPartition:
+		return fmt.Sprintf("ALTER TABLE %s ADD PARTITION (%s)", table, mysqlPartitionDefinitions(d, p)), true
+	case DropPartition:
+		names := make([]string, len(p.Partitions))
+		for i, part := range p.Partitions {
+			names[i] = d.QuoteIdentifier(part.Name)
+		}
+		return fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", table, strings.Join(names, ", ")), true
+	case ReorganizePartition:
+		return fmt.Sprintf("ALTER TABLE %s REORGANIZE PARTITION %s INTO (%s)",
+			table, d.QuoteIdentifier(change.Reorganize), mysqlPartitionDefinitions(d, p)), true
+	default:
+		return "", false
+	}
+}
+
+// mysqlPartitionDefinitions menulis daftar PARTITION ... VALUES untuk RANGE dan LIST
+func mysqlPartitionDefinitions(d mysqlDialect, p models.Partitioning) string {
+	defs := make([]string, len(p.Partitions))
+	for i, part := range p.Partitions {
+		def := "PARTITION " + d.QuoteIdentifier(part.Name)
+		switch {
+		case p.Method == "RANGE" && part.Description == "MAXVALUE":
+			def += " VALUES LESS THAN MAXVALUE"
+		case strings.HasPrefix(p.Method, "RANGE"):
+			def += " VALUES LESS THAN (" + part.Description + ")"
+		case strings.HasPrefix(p.Method, "LIST"):
+			def += " VALUES IN (" + part.Description + ")"
+		}
+		defs[i] = def
+	}
+	return strings.Join(defs, ", ")
+}
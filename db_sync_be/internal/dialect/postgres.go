@@ -0,0 +1,698 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// postgresDialect mendukung PostgreSQL sebagai source maupun target. Tipe kolom
+// MySQL dipetakan ke nama tipe kanonik PostgreSQL (sesuai format_type) supaya
+// perbandingan schema tidak menghasilkan perbedaan palsu.
+type postgresDialect struct{}
+
+var (
+	typeLengthPattern  = regexp.MustCompile(`\(([^)]*)\)`)
+	datetimePrecision  = regexp.MustCompile(`^(datetime|timestamp|time)\((\d)\)`)
+	enumValuesPattern  = regexp.MustCompile(`^(?i)enum\((.*)\)$`)
+	tinyintBoolPattern = regexp.MustCompile(`^(?i)tinyint\(1\)`)
+)
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (postgresDialect) DSN(conn ConnectionInfo) string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(conn.User, conn.Password),
+		Host:   conn.Host + ":" + conn.Port,
+		Path:   "/" + conn.Name,
+	}
+
+	sslMode := conn.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn.RawQuery = url.Values{"sslmode": []string{sslMode}}.Encode()
+
+	return dsn.String()
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) Rebind(query string) string {
+	return rebindDollar(query)
+}
+
+func (postgresDialect) ListTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	query := `SELECT table_name
+	          FROM information_schema.tables
+	          WHERE table_schema = current_schema()
+	          AND table_type = 'BASE TABLE'
+	          ORDER BY table_name`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableName)
+	}
+
+	return tables, rows.Err()
+}
+
+func (postgresDialect) TableExists(ctx context.Context, db *sql.DB, tableName string) (bool, error) {
+	query := `SELECT COUNT(*)
+	          FROM information_schema.tables
+	          WHERE table_schema = current_schema()
+	          AND table_name = $1`
+
+	var count int
+	if err := db.QueryRowContext(ctx, query, tableName).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (postgresDialect) GetColumns(ctx context.Context, db *sql.DB, tableName string) ([]models.ColumnInfo, error) {
+	query := `SELECT
+	            a.attname,
+	            t.typname,
+	            format_type(a.atttypid, a.atttypmod),
+	            CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END,
+	            CASE WHEN EXISTS (
+	              SELECT 1 FROM pg_index i
+	              WHERE i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)
+	            ) THEN 'PRI' ELSE '' END,
+	            pg_get_expr(d.adbin, d.adrelid)
+	          FROM pg_attribute a
+	          JOIN pg_class c ON c.oid = a.attrelid
+	          JOIN pg_namespace n ON n.oid = c.relnamespace
+	          JOIN pg_type t ON t.oid = a.atttypid
+	          LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	          WHERE n.nspname = current_schema()
+	          AND c.relname = $1
+	          AND a.attnum > 0
+	          AND NOT a.attisdropped
+	          ORDER BY a.attnum`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnInfo
+	for rows.Next() {
+		var col models.ColumnInfo
+		err := rows.Scan(
+			&col.ColumnName,
+			&col.DataType,
+			&col.ColumnType,
+			&col.IsNullable,
+			&col.ColumnKey,
+			&col.ColumnDefault,
+		)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+
+	return columns, rows.Err()
+}
+
+func (postgresDialect) GetPrimaryKeyColumns(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	query := `SELECT a.attname
+	          FROM pg_index i
+	          JOIN pg_class c ON c.oid = i.indrelid
+	          JOIN pg_namespace n ON n.oid = c.relnamespace
+	          CROSS JOIN LATERAL unnest(i.indkey) WITH ORDINALITY AS k(attnum, ord)
+	          JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+	          WHERE i.indisprimary
+	          AND n.nspname = current_schema()
+	          AND c.relname = $1
+	          ORDER BY k.ord`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pkColumns []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			return nil, err
+		}
+		pkColumns = append(pkColumns, columnName)
+	}
+
+	return pkColumns, rows.Err()
+}
+
+func (postgresDialect) GetForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]models.ForeignKey, error) {
+	// conkey dan confkey di-unnest bersamaan supaya kolom FK komposit tetap berpasangan
+	query := `SELECT
+	            t.relname,
+	            a.attname,
+	            rt.relname,
+	            ra.attname,
+	            c.conname,
+	            c.confupdtype,
+	            c.confdeltype
+	          FROM pg_constraint c
+	          JOIN pg_class t ON t.oid = c.conrelid
+	          JOIN pg_namespace n ON n.oid = t.relnamespace
+	          JOIN pg_class rt ON rt.oid = c.confrelid
+	          CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+	          JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+	          JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refattnum
+	          WHERE c.contype = 'f'
+	          AND n.nspname = current_schema()
+	          AND t.relname = $1
+	          ORDER BY c.conname, k.ord`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []models.ForeignKey
+	for rows.Next() {
+		var fk models.ForeignKey
+		var updateType, deleteType string
+		err := rows.Scan(
+			&fk.TableName,
+			&fk.ColumnName,
+			&fk.ReferencedTableName,
+			&fk.ReferencedColumnName,
+			&fk.ConstraintName,
+			&updateType,
+			&deleteType,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fk.UpdateRule = postgresReferentialAction(updateType)
+		fk.DeleteRule = postgresReferentialAction(deleteType)
+		fks = append(fks, fk)
+	}
+
+	return fks, rows.Err()
+}
+
+// postgresReferentialAction memetakan kode confupdtype/confdeltype ke nama aksi
+func postgresReferentialAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return "NO ACTION"
+	}
+}
+
+func (postgresDialect) GetIndexes(ctx context.Context, db *sql.DB, tableName string) ([]models.IndexInfo, error) {
+	// Index ekspresi (attnum 0) dan partial index dilewati
+	query := `SELECT ic.relname, i.indisunique, a.attname, (i.indoption[(k.ord - 1)::int] & 1) = 1
+	          FROM pg_index i
+	          JOIN pg_class c ON c.oid = i.indrelid
+	          JOIN pg_class ic ON ic.oid = i.indexrelid
+	          JOIN pg_namespace n ON n.oid = c.relnamespace
+	          CROSS JOIN LATERAL unnest(i.indkey) WITH ORDINALITY AS k(attnum, ord)
+	          JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+	          WHERE NOT i.indisprimary
+	          AND i.indpred IS NULL
+	          AND NOT (0 = ANY (i.indkey::int2[]))
+	          AND n.nspname = current_schema()
+	          AND c.relname = $1
+	          ORDER BY ic.relname, k.ord`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	builder := newIndexBuilder()
+	for rows.Next() {
+		var (
+			name, column       string
+			unique, descending bool
+		)
+		if err := rows.Scan(&name, &unique, &column, &descending); err != nil {
+			return nil, err
+		}
+
+		kind := ""
+		if unique {
+			kind = models.IndexKindUnique
+		}
+		builder.add(name, kind, models.IndexColumn{ColumnName: column, Descending: descending})
+	}
+
+	return builder.result(), rows.Err()
+}
+
+func (postgresDialect) ShowCreateTable(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	// PostgreSQL tidak punya SHOW CREATE TABLE, DDL dibuat dari ColumnInfo
+	return "", nil
+}
+
+// GetSchemaObjects membaca view, function, procedure dan trigger di schema
+// aktif; function milik extension dilewati karena ikut dibuat oleh extension-nya
+func (t postgresDialect) GetSchemaObjects(ctx context.Context, db *sql.DB) ([]models.SchemaObject, error) {
+	query := `SELECT 'VIEW', viewname, '', '', definition
+	          FROM pg_views
+	          WHERE schemaname = current_schema()
+	          UNION ALL
+	          SELECT CASE p.prokind WHEN 'p' THEN 'PROCEDURE' ELSE 'FUNCTION' END, p.proname, '',
+	                 pg_get_function_identity_arguments(p.oid), pg_get_functiondef(p.oid)
+	          FROM pg_proc p
+	          JOIN pg_namespace n ON n.oid = p.pronamespace
+	          WHERE n.nspname = current_schema()
+	          AND p.prokind IN ('f', 'p')
+	          AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')
+	          UNION ALL
+	          SELECT 'TRIGGER', tg.tgname, c.relname, '', pg_get_triggerdef(tg.oid)
+	          FROM pg_trigger tg
+	          JOIN pg_class c ON c.oid = tg.tgrelid
+	          JOIN pg_namespace n ON n.oid = c.relnamespace
+	          WHERE n.nspname = current_schema()
+	          AND NOT tg.tgisinternal`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []models.SchemaObject
+	for rows.Next() {
+		var obj models.SchemaObject
+		if err := rows.Scan(&obj.Type, &obj.Name, &obj.TableName, &obj.Arguments, &obj.Definition); err != nil {
+			return nil, err
+		}
+		// pg_views hanya berisi query-nya
+		if obj.Type == models.ObjectTypeView {
+			obj.Definition = fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s",
+				t.QuoteIdentifier(obj.Name), strings.TrimSuffix(strings.TrimSpace(obj.Definition), ";"))
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, rows.Err()
+}
+
+func (t postgresDialect) CreateTableStatement(tableName string, columns []models.ColumnInfo, sourceCreate string) string {
+	if sourceCreate != "" {
+		return sourceCreate
+	}
+
+	var defs []string
+	for _, col := range columns {
+		def := fmt.Sprintf("%s %s", t.QuoteIdentifier(col.ColumnName), postgresColumnType(col))
+		if col.IsNullable == "NO" {
+			def += " NOT NULL"
+		}
+		if dflt, ok := postgresDefault(col); ok {
+			def += " DEFAULT " + dflt
+		}
+		if check := t.enumCheck(col); check != "" {
+			def += " " + check
+		}
+		defs = append(defs, def)
+	}
+
+	if pk := PrimaryKeyColumns(columns); len(pk) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", QuoteIdentifiers(t, pk)))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", t.QuoteIdentifier(tableName), strings.Join(defs, ",\n  "))
+}
+
+func (t postgresDialect) AddColumnStatement(tableName string, col models.ColumnInfo, after string) string {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+		t.QuoteIdentifier(tableName), t.QuoteIdentifier(col.ColumnName), postgresColumnType(col))
+
+	// NOT NULL tanpa default akan gagal pada tabel yang sudah berisi data
+	if dflt, ok := postgresDefault(col); ok {
+		if col.IsNullable == "NO" {
+			stmt += " NOT NULL"
+		}
+		stmt += " DEFAULT " + dflt
+	}
+
+	if check := t.enumCheck(col); check != "" {
+		stmt += " " + check
+	}
+
+	return stmt
+}
+
+func (postgresDialect) ColumnsDifferent(masterCol, backupCol models.ColumnInfo) bool {
+	return postgresColumnType(masterCol) != backupCol.ColumnType ||
+		masterCol.IsNullable != backupCol.IsNullable
+}
+
+func (t postgresDialect) ModifyColumnStatement(tableName string, col models.ColumnInfo) (string, bool) {
+	column := t.QuoteIdentifier(col.ColumnName)
+	pgType := postgresColumnType(col)
+
+	nullability := "DROP NOT NULL"
+	if col.IsNullable == "NO" {
+		nullability = "SET NOT NULL"
+	}
+
+	// Integer tidak bisa di-cast langsung ke boolean; lewat integer supaya kolom
+	// smallint/integer (mis. dari tinyint) maupun boolean sama-sama bisa diubah
+	using := column + "::" + pgType
+	if pgType == "boolean" {
+		using = column + "::integer <> 0"
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s, ALTER COLUMN %s %s",
+		t.QuoteIdentifier(tableName), column, pgType, using, column, nullability), true
+}
+
+func (t postgresDialect) RenameColumnStatement(tableName, oldName string, col models.ColumnInfo) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", t.QuoteIdentifier(tableName),
+		t.QuoteIdentifier(oldName), t.QuoteIdentifier(col.ColumnName))
+}
+
+func (t postgresDialect) DropColumnStatement(tableName, columnName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.QuoteIdentifier(tableName), t.QuoteIdentifier(columnName))
+}
+
+func (t postgresDialect) DropNotNullStatement(tableName string, col models.ColumnInfo) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL",
+		t.QuoteIdentifier(tableName), t.QuoteIdentifier(col.ColumnName)), true
+}
+
+// MoveColumnStatement tidak didukung, PostgreSQL tidak bisa mengubah urutan kolom
+func (postgresDialect) MoveColumnStatement(tableName string, col models.ColumnInfo, after string) (string, bool) {
+	return "", false
+}
+
+func (t postgresDialect) AlterClause(tableName, stmt string) (string, bool) {
+	prefix := "ALTER TABLE " + t.QuoteIdentifier(tableName) + " "
+	// RENAME tidak bisa digabung dengan aksi ALTER TABLE lainnya
+	if !strings.HasPrefix(stmt, prefix) || strings.HasPrefix(strings.TrimPrefix(stmt, prefix), "RENAME ") {
+		return "", false
+	}
+	return strings.TrimPrefix(stmt, prefix), true
+}
+
+func (t postgresDialect) AlterTableStatement(tableName string, clauses []string) string {
+	return fmt.Sprintf("ALTER TABLE %s %s", t.QuoteIdentifier(tableName), strings.Join(clauses, ", "))
+}
+
+// OnlineAlterOptions kosong, PostgreSQL memilih sendiri apakah tabel perlu ditulis ulang
+// GetTableOptions nil, engine, charset tabel dan partisi tidak disinkronkan
+func (postgresDialect) GetTableOptions(ctx context.Context, db *sql.DB, tableName string) (*models.TableOptions, error) {
+	return nil, nil
+}
+
+func (postgresDialect) TableOptionStatement(tableName, option string, opts models.TableOptions) (string, bool) {
+	return "", false
+}
+
+func (postgresDialect) PartitionStatement(tableName string, change models.PartitionChange) (string, bool) {
+	return "", false
+}
+
+func (postgresDialect) OnlineAlterOptions() []string {
+	return nil
+}
+
+func (postgresDialect) UnsupportedAlter(err error) bool {
+	return false
+}
+
+func (t postgresDialect) RenameTableStatement(oldName, newName string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", t.QuoteIdentifier(oldName), t.QuoteIdentifier(newName))
+}
+
+func (postgresDialect) ShadowTableStatements(tableName, shadowName, oldName string) (string, string, bool) {
+	return "", "", false
+}
+
+func (postgresDialect) AdaptIndex(idx models.IndexInfo) (models.IndexInfo, bool) {
+	return adaptPlainIndex(idx)
+}
+
+func (t postgresDialect) CreateIndexStatement(tableName string, idx models.IndexInfo) string {
+	return createIndexStatement(t, tableName, idx)
+}
+
+func (postgresDialect) IndexName(tableName, indexName string) string {
+	return scopedIndexName(tableName, indexName)
+}
+
+func (t postgresDialect) DropIndexStatement(tableName string, idx models.IndexInfo) string {
+	return fmt.Sprintf("DROP INDEX %s", t.QuoteIdentifier(idx.IndexName))
+}
+
+func (t postgresDialect) AddForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", t.QuoteIdentifier(tableName), foreignKeyClause(t, fk)), true
+}
+
+func (t postgresDialect) DropForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", t.QuoteIdentifier(tableName), t.QuoteIdentifier(fk.ConstraintName)), true
+}
+
+// StripForeignKeys tidak perlu apa-apa, DDL PostgreSQL selalu dibuat tanpa FK
+func (postgresDialect) StripForeignKeys(createStmt string) string {
+	return createStmt
+}
+
+// ObjectDefinition memakai DDL asli; owner object mengikuti user koneksi backup
+func (postgresDialect) ObjectDefinition(obj models.SchemaObject, definer string) string {
+	return obj.Definition
+}
+
+func (t postgresDialect) DropObjectStatement(obj models.SchemaObject) string {
+	switch obj.Type {
+	case models.ObjectTypeTrigger:
+		return fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", t.QuoteIdentifier(obj.Name), t.QuoteIdentifier(obj.TableName))
+	case models.ObjectTypeFunction, models.ObjectTypeProcedure:
+		return fmt.Sprintf("DROP %s IF EXISTS %s(%s)", obj.Type, t.QuoteIdentifier(obj.Name), obj.Arguments)
+	default:
+		return fmt.Sprintf("DROP %s IF EXISTS %s", obj.Type, t.QuoteIdentifier(obj.Name))
+	}
+}
+
+func (t postgresDialect) DisableTriggerStatement(obj models.SchemaObject) (string, bool) {
+	return fmt.Sprintf("ALTER TABLE %s DISABLE TRIGGER %s", t.QuoteIdentifier(obj.TableName), t.QuoteIdentifier(obj.Name)), true
+}
+
+func (t postgresDialect) UpsertStatement(tableName string, columns, pkColumns []string) string {
+	isPK := make(map[string]bool)
+	for _, pk := range pkColumns {
+		isPK[pk] = true
+	}
+
+	var placeholders []string
+	var updates []string
+	for i, col := range columns {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+		if !isPK[col] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", t.QuoteIdentifier(col), t.QuoteIdentifier(col)))
+		}
+	}
+
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		t.QuoteIdentifier(tableName),
+		QuoteIdentifiers(t, columns),
+		strings.Join(placeholders, ", "),
+		QuoteIdentifiers(t, pkColumns),
+		action,
+	)
+}
+
+func (postgresDialect) ChecksumExpression(columns []string) string {
+	// Format teks nilai PostgreSQL berbeda dengan MySQL (boolean, timestamptz),
+	// jadi checksum dihitung di aplikasi dari nilai yang sudah dinormalisasi
+	return ""
+}
+
+func (postgresDialect) SnapshotStatements() []string {
+	return []string{"BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY"}
+}
+
+// ForeignKeyChecksStatements memakai session_replication_role yang juga melewati
+// trigger FK internal; butuh hak superuser
+func (postgresDialect) ForeignKeyChecksStatements() (string, string) {
+	return "SET session_replication_role = replica", "SET session_replication_role = DEFAULT"
+}
+
+// enumCheck membuat CHECK constraint untuk kolom ENUM MySQL
+func (t postgresDialect) enumCheck(col models.ColumnInfo) string {
+	match := enumValuesPattern.FindStringSubmatch(col.ColumnType)
+	if match == nil {
+		return ""
+	}
+	return fmt.Sprintf("CHECK (%s IN (%s))", t.QuoteIdentifier(col.ColumnName), match[1])
+}
+
+// postgresColumnType memetakan tipe MySQL ke nama tipe kanonik PostgreSQL.
+// Kolom yang berasal dari source PostgreSQL sudah memakai nama kanonik.
+func postgresColumnType(col models.ColumnInfo) string {
+	columnType := strings.ToLower(col.ColumnType)
+	if strings.HasSuffix(columnType, " time zone") {
+		return columnType
+	}
+	unsigned := strings.Contains(columnType, "unsigned")
+
+	length := ""
+	if match := typeLengthPattern.FindStringSubmatch(columnType); match != nil {
+		length = match[1]
+	}
+
+	switch strings.ToLower(col.DataType) {
+	case "tinyint":
+		if tinyintBoolPattern.MatchString(columnType) {
+			return "boolean"
+		}
+		return "smallint"
+	case "bool", "boolean":
+		return "boolean"
+	case "smallint":
+		if unsigned {
+			return "integer"
+		}
+		return "smallint"
+	case "mediumint":
+		return "integer"
+	case "int", "integer":
+		if unsigned {
+			return "bigint"
+		}
+		return "integer"
+	case "bigint":
+		if unsigned {
+			return "numeric(20,0)"
+		}
+		return "bigint"
+	case "year":
+		return "smallint"
+	case "decimal", "numeric":
+		if length == "" {
+			return "numeric"
+		}
+		return fmt.Sprintf("numeric(%s)", strings.ReplaceAll(length, " ", ""))
+	case "float":
+		return "real"
+	case "double", "real":
+		return "double precision"
+	case "char":
+		if length == "" {
+			length = "1"
+		}
+		return fmt.Sprintf("character(%s)", length)
+	case "varchar":
+		if length == "" {
+			return "character varying"
+		}
+		return fmt.Sprintf("character varying(%s)", length)
+	case "json":
+		return "jsonb"
+	case "date":
+		return "date"
+	case "datetime":
+		return withPrecision(columnType, "timestamp", "without time zone")
+	case "timestamp":
+		return withPrecision(columnType, "timestamp", "with time zone")
+	case "time":
+		return withPrecision(columnType, "time", "without time zone")
+	case "bit", "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return "bytea"
+	case "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return "text"
+	default:
+		// Tipe native PostgreSQL (int4, bool, uuid, ...) sudah dalam bentuk format_type
+		return columnType
+	}
+}
+
+// withPrecision menambahkan fractional seconds precision, mis. datetime(3) → timestamp(3) without time zone
+func withPrecision(columnType, pgType, zone string) string {
+	if match := datetimePrecision.FindStringSubmatch(columnType); match != nil && match[2] != "0" {
+		return fmt.Sprintf("%s(%s) %s", pgType, match[2], zone)
+	}
+	return fmt.Sprintf("%s %s", pgType, zone)
+}
+
+// postgresDefault mengubah COLUMN_DEFAULT MySQL menjadi default PostgreSQL.
+// Default berupa ekspresi selain CURRENT_TIMESTAMP tidak dibawa.
+func postgresDefault(col models.ColumnInfo) (string, bool) {
+	if col.ColumnDefault == nil {
+		return "", false
+	}
+
+	value := *col.ColumnDefault
+	upper := strings.ToUpper(value)
+
+	switch {
+	case upper == "NULL":
+		return "", false
+	case strings.HasPrefix(upper, "CURRENT_TIMESTAMP"):
+		return "CURRENT_TIMESTAMP", true
+	case col.DefaultExpression:
+		return "", false
+	}
+
+	if postgresColumnType(col) == "boolean" {
+		if value == "0" || value == "'0'" {
+			return "false", true
+		}
+		return "true", true
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value, true
+	}
+
+	// Default dari MariaDB sudah ber-quote
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2 {
+		return value, true
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+}
@@ -0,0 +1,36 @@
+package dialect
+
+import (
+	"testing"
+
+	"db-sync-scheduler/internal/models"
+)
+
+func TestPostgresModifyColumnStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		col  models.ColumnInfo
+		want string
+	}{
+		{
+			"varchar",
+			models.ColumnInfo{ColumnName: "city", DataType: "varchar", ColumnType: "varchar(50)", IsNullable: "NO"},
+			`ALTER TABLE "offices" ALTER COLUMN "city" TYPE character varying(50) USING "city"::character varying(50), ALTER COLUMN "city" SET NOT NULL`,
+		},
+		{
+			"tinyint(1) to boolean",
+			models.ColumnInfo{ColumnName: "active", DataType: "tinyint", ColumnType: "tinyint(1)", IsNullable: "YES"},
+			`ALTER TABLE "offices" ALTER COLUMN "active" TYPE boolean USING "active"::integer <> 0, ALTER COLUMN "active" DROP NOT NULL`,
+		},
+	}
+
+	var d postgresDialect
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := d.ModifyColumnStatement("offices", tt.col)
+			if !ok || got != tt.want {
+				t.Errorf("ModifyColumnStatement() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
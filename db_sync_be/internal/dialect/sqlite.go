@@ -0,0 +1,523 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sqliteDialect menyimpan database dalam satu file SQLite. DDL dibuat dari
+// ColumnInfo kecuali source juga SQLite (DDL asli diambil dari sqlite_master).
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) DriverName() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) DSN(conn ConnectionInfo) string {
+	return conn.Path + "?_pragma=busy_timeout(5000)"
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) ListTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	query := `SELECT name FROM sqlite_master
+	          WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+	          ORDER BY name`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableName)
+	}
+
+	return tables, rows.Err()
+}
+
+func (sqliteDialect) TableExists(ctx context.Context, db *sql.DB, tableName string) (bool, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+
+	var count int
+	if err := db.QueryRowContext(ctx, query, tableName).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (t sqliteDialect) GetColumns(ctx context.Context, db *sql.DB, tableName string) ([]models.ColumnInfo, error) {
+	query := fmt.Sprintf("PRAGMA table_info(%s)", t.QuoteIdentifier(tableName))
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnInfo
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+
+		col := models.ColumnInfo{
+			ColumnName: name,
+			DataType:   strings.ToLower(colType),
+			ColumnType: colType,
+			IsNullable: "YES",
+		}
+		if notNull == 1 {
+			col.IsNullable = "NO"
+		}
+		if pk > 0 {
+			col.ColumnKey = "PRI"
+		}
+		if dfltValue.Valid {
+			col.ColumnDefault = &dfltValue.String
+		}
+		columns = append(columns, col)
+	}
+
+	return columns, rows.Err()
+}
+
+func (sqliteDialect) GetPrimaryKeyColumns(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	query := `SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`
+
+	rows, err := db.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pkColumns []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			return nil, err
+		}
+		pkColumns = append(pkColumns, columnName)
+	}
+
+	return pkColumns, rows.Err()
+}
+
+func (t sqliteDialect) GetForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]models.ForeignKey, error) {
+	query := fmt.Sprintf("PRAGMA foreign_key_list(%s)", t.QuoteIdentifier(tableName))
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []models.ForeignKey
+	for rows.Next() {
+		var (
+			id, seq                         int
+			refTable, from                  string
+			to                              sql.NullString
+			onUpdate, onDelete, matchClause string
+		)
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &matchClause); err != nil {
+			return nil, err
+		}
+
+		// SQLite tidak menyimpan nama constraint
+		fks = append(fks, models.ForeignKey{
+			TableName:            tableName,
+			ColumnName:           from,
+			ReferencedTableName:  refTable,
+			ReferencedColumnName: to.String,
+			ConstraintName:       fmt.Sprintf("fk_%s_%d", tableName, id),
+			UpdateRule:           onUpdate,
+			DeleteRule:           onDelete,
+		})
+	}
+
+	return fks, rows.Err()
+}
+
+func (t sqliteDialect) GetIndexes(ctx context.Context, db *sql.DB, tableName string) ([]models.IndexInfo, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA index_list(%s)", t.QuoteIdentifier(tableName)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Hanya index dari CREATE INDEX (origin c); index untuk PRIMARY KEY dan
+	// UNIQUE constraint ikut DDL tabel dan tidak bisa di-drop
+	type indexEntry struct {
+		name   string
+		unique bool
+	}
+	var entries []indexEntry
+	for rows.Next() {
+		var (
+			seq, unique, partial int
+			name, origin         string
+		)
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if origin == "c" && partial == 0 {
+			entries = append(entries, indexEntry{name: name, unique: unique == 1})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Pool SQLite hanya satu koneksi, query kolom dijalankan setelah rows di atas ditutup
+	builder := newIndexBuilder()
+	for _, entry := range entries {
+		kind := ""
+		if entry.unique {
+			kind = models.IndexKindUnique
+		}
+
+		colRows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA index_xinfo(%s)", t.QuoteIdentifier(entry.name)))
+		if err != nil {
+			return nil, err
+		}
+
+		for colRows.Next() {
+			var (
+				seqno, cid, desc, key int
+				name, coll            sql.NullString
+			)
+			if err := colRows.Scan(&seqno, &cid, &name, &desc, &coll, &key); err != nil {
+				colRows.Close()
+				return nil, err
+			}
+			if key == 0 {
+				continue
+			}
+			// cid -2 berarti kolom ekspresi
+			if !name.Valid {
+				builder.skip(entry.name)
+				continue
+			}
+			builder.add(entry.name, kind, models.IndexColumn{ColumnName: name.String, Descending: desc == 1})
+		}
+		colRows.Close()
+		if err := colRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.result(), nil
+}
+
+func (sqliteDialect) ShowCreateTable(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	query := `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`
+
+	var createStmt string
+	if err := db.QueryRowContext(ctx, query, tableName).Scan(&createStmt); err != nil {
+		return "", err
+	}
+
+	return createStmt, nil
+}
+
+func (sqliteDialect) GetSchemaObjects(ctx context.Context, db *sql.DB) ([]models.SchemaObject, error) {
+	query := `SELECT UPPER(type), name, CASE WHEN type = 'trigger' THEN tbl_name ELSE '' END, sql
+	          FROM sqlite_master
+	          WHERE type IN ('view', 'trigger') AND sql IS NOT NULL
+	          ORDER BY type, name`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []models.SchemaObject
+	for rows.Next() {
+		var obj models.SchemaObject
+		if err := rows.Scan(&obj.Type, &obj.Name, &obj.TableName, &obj.Definition); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, rows.Err()
+}
+
+func (t sqliteDialect) CreateTableStatement(tableName string, columns []models.ColumnInfo, sourceCreate string) string {
+	if sourceCreate != "" {
+		return sourceCreate
+	}
+
+	var defs []string
+	for _, col := range columns {
+		def := fmt.Sprintf("%s %s", t.QuoteIdentifier(col.ColumnName), sqliteColumnType(col))
+		if col.IsNullable == "NO" {
+			def += " NOT NULL"
+		}
+		if value, ok := sqliteDefault(col); ok {
+			def += " DEFAULT " + value
+		}
+		defs = append(defs, def)
+	}
+
+	if pk := PrimaryKeyColumns(columns); len(pk) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", QuoteIdentifiers(t, pk)))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", t.QuoteIdentifier(tableName), strings.Join(defs, ",\n  "))
+}
+
+func (t sqliteDialect) AddColumnStatement(tableName string, col models.ColumnInfo, after string) string {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+		t.QuoteIdentifier(tableName), t.QuoteIdentifier(col.ColumnName), sqliteColumnType(col))
+
+	// SQLite hanya mengizinkan NOT NULL pada ADD COLUMN jika ada default
+	if value, ok := sqliteDefault(col); ok {
+		if col.IsNullable == "NO" {
+			stmt += " NOT NULL"
+		}
+		stmt += " DEFAULT " + value
+	}
+
+	return stmt
+}
+
+func (sqliteDialect) ColumnsDifferent(masterCol, backupCol models.ColumnInfo) bool {
+	return sqliteColumnType(masterCol) != sqliteColumnType(backupCol)
+}
+
+func (sqliteDialect) ModifyColumnStatement(tableName string, col models.ColumnInfo) (string, bool) {
+	// SQLite tidak mendukung ALTER TABLE ... MODIFY COLUMN
+	return "", false
+}
+
+func (t sqliteDialect) RenameColumnStatement(tableName, oldName string, col models.ColumnInfo) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", t.QuoteIdentifier(tableName),
+		t.QuoteIdentifier(oldName), t.QuoteIdentifier(col.ColumnName))
+}
+
+// DropColumnStatement butuh SQLite 3.35+, kolom yang dipakai index atau PK tidak bisa di-drop
+func (t sqliteDialect) DropColumnStatement(tableName, columnName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.QuoteIdentifier(tableName), t.QuoteIdentifier(columnName))
+}
+
+func (sqliteDialect) DropNotNullStatement(tableName string, col models.ColumnInfo) (string, bool) {
+	return "", false
+}
+
+func (sqliteDialect) MoveColumnStatement(tableName string, col models.ColumnInfo, after string) (string, bool) {
+	return "", false
+}
+
+// AlterClause selalu false, SQLite hanya menerima satu aksi per ALTER TABLE
+func (sqliteDialect) AlterClause(tableName, stmt string) (string, bool) {
+	return "", false
+}
+
+func (t sqliteDialect) AlterTableStatement(tableName string, clauses []string) string {
+	return fmt.Sprintf("ALTER TABLE %s %s", t.QuoteIdentifier(tableName), strings.Join(clauses, ", "))
+}
+
+// GetTableOptions nil, engine, charset tabel dan partisi tidak disinkronkan
+func (sqliteDialect) GetTableOptions(ctx context.Context, db *sql.DB, tableName string) (*models.TableOptions, error) {
+	return nil, nil
+}
+
+func (sqliteDialect) TableOptionStatement(tableName, option string, opts models.TableOptions) (string, bool) {
+	return "", false
+}
+
+func (sqliteDialect) PartitionStatement(tableName string, change models.PartitionChange) (string, bool) {
+	return "", false
+}
+
+func (sqliteDialect) OnlineAlterOptions() []string {
+	return nil
+}
+
+func (sqliteDialect) UnsupportedAlter(err error) bool {
+	return false
+}
+
+func (t sqliteDialect) RenameTableStatement(oldName, newName string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", t.QuoteIdentifier(oldName), t.QuoteIdentifier(newName))
+}
+
+func (sqliteDialect) ShadowTableStatements(tableName, shadowName, oldName string) (string, string, bool) {
+	return "", "", false
+}
+
+func (sqliteDialect) AdaptIndex(idx models.IndexInfo) (models.IndexInfo, bool) {
+	return adaptPlainIndex(idx)
+}
+
+func (t sqliteDialect) CreateIndexStatement(tableName string, idx models.IndexInfo) string {
+	return createIndexStatement(t, tableName, idx)
+}
+
+func (sqliteDialect) IndexName(tableName, indexName string) string {
+	return scopedIndexName(tableName, indexName)
+}
+
+func (t sqliteDialect) DropIndexStatement(tableName string, idx models.IndexInfo) string {
+	return fmt.Sprintf("DROP INDEX %s", t.QuoteIdentifier(idx.IndexName))
+}
+
+func (sqliteDialect) AddForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool) {
+	// SQLite tidak mendukung ALTER TABLE ... ADD CONSTRAINT
+	return "", false
+}
+
+func (sqliteDialect) DropForeignKeyStatement(tableName string, fk models.ForeignKeyConstraint) (string, bool) {
+	return "", false
+}
+
+// StripForeignKeys membiarkan REFERENCES inline karena FK tidak bisa ditambahkan belakangan
+func (sqliteDialect) StripForeignKeys(createStmt string) string {
+	return createStmt
+}
+
+// ObjectDefinition memakai DDL asli, SQLite tidak punya DEFINER maupun event
+func (sqliteDialect) ObjectDefinition(obj models.SchemaObject, definer string) string {
+	return obj.Definition
+}
+
+func (t sqliteDialect) DropObjectStatement(obj models.SchemaObject) string {
+	return fmt.Sprintf("DROP %s IF EXISTS %s", obj.Type, t.QuoteIdentifier(obj.Name))
+}
+
+func (sqliteDialect) DisableTriggerStatement(obj models.SchemaObject) (string, bool) {
+	return "", false
+}
+
+func (t sqliteDialect) UpsertStatement(tableName string, columns, pkColumns []string) string {
+	isPK := make(map[string]bool)
+	for _, pk := range pkColumns {
+		isPK[pk] = true
+	}
+
+	var placeholders []string
+	var updates []string
+	for _, col := range columns {
+		placeholders = append(placeholders, "?")
+		if !isPK[col] {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", t.QuoteIdentifier(col), t.QuoteIdentifier(col)))
+		}
+	}
+
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		t.QuoteIdentifier(tableName),
+		QuoteIdentifiers(t, columns),
+		strings.Join(placeholders, ", "),
+		QuoteIdentifiers(t, pkColumns),
+		action,
+	)
+}
+
+func (sqliteDialect) ChecksumExpression(columns []string) string {
+	// SQLite tidak punya MD5, checksum dihitung di aplikasi
+	return ""
+}
+
+func (sqliteDialect) SnapshotStatements() []string {
+	// Pool SQLite hanya punya satu koneksi, koneksi yang di-pin akan memblokir
+	// query metadata lain. Satu file juga sudah konsisten selama tidak ada penulis lain.
+	return nil
+}
+
+func (sqliteDialect) ForeignKeyChecksStatements() (string, string) {
+	// Koneksi SQLite tidak mengaktifkan PRAGMA foreign_keys, FK memang tidak dicek
+	return "", ""
+}
+
+// sqliteColumnType memetakan tipe MySQL ke tipe SQLite dengan affinity yang sesuai
+func sqliteColumnType(col models.ColumnInfo) string {
+	switch strings.ToLower(col.DataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "bit", "year", "bool", "boolean":
+		return "INTEGER"
+	case "float", "double", "real":
+		return "REAL"
+	case "decimal", "numeric":
+		return "NUMERIC"
+	case "date":
+		return "DATE"
+	case "datetime":
+		return "DATETIME"
+	case "timestamp":
+		return "TIMESTAMP"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return "BLOB"
+	default:
+		// char, varchar, text, enum, set, json, time
+		return "TEXT"
+	}
+}
+
+// sqliteDefault mengubah COLUMN_DEFAULT MySQL menjadi literal SQLite
+func sqliteDefault(col models.ColumnInfo) (string, bool) {
+	if col.ColumnDefault == nil {
+		return "", false
+	}
+
+	value := *col.ColumnDefault
+	upper := strings.ToUpper(value)
+	switch {
+	case upper == "NULL":
+		return "NULL", true
+	case strings.HasPrefix(upper, "CURRENT_TIMESTAMP"):
+		return "CURRENT_TIMESTAMP", true
+	case col.DefaultExpression:
+		// Ekspresi MySQL lain tidak bisa dibawa ke SQLite
+		return "", false
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value, true
+	}
+
+	// Default dari SQLite dan MariaDB lama sudah ber-quote
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2 {
+		return value, true
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"db-sync-scheduler/internal/services"
@@ -33,6 +34,39 @@ type ConfigRequest struct {
 	AutoSchemaSync *bool  `json:"autoSchemaSync,omitempty"`
 }
 
+type ConflictResolveRequest struct {
+	TableName string `json:"tableName"`
+	PKValue   string `json:"pkValue"`
+	Winner    string `json:"winner"`
+}
+
+type SchemaApproveRequest struct {
+	TableName string `json:"tableName"`
+	Statement string `json:"statement,omitempty"`
+}
+
+type RenameResolveRequest struct {
+	TableName string `json:"tableName"`
+	OldName   string `json:"oldName"`
+	NewName   string `json:"newName"`
+	Accept    bool   `json:"accept"`
+}
+
+type TableRenameResolveRequest struct {
+	OldName string `json:"oldName"`
+	NewName string `json:"newName"`
+	Accept  bool   `json:"accept"`
+}
+
+type SnapshotRestoreRequest struct {
+	ID string `json:"id"`
+}
+
+type QuarantineRequest struct {
+	TableName string `json:"tableName"`
+	PKValue   string `json:"pkValue,omitempty"`
+}
+
 func (h *Handler) StartSyncHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -110,18 +144,317 @@ func (h *Handler) SchemaSyncHandler(w http.ResponseWriter, r *http.Request) {
 	sendSuccessResponse(w, "Schema synchronization completed", nil)
 }
 
+func (h *Handler) SchemaDiffHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	plans, err := h.syncService.SchemaDiff(r.URL.Query().Get("table"))
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, "", plans)
+}
+
+func (h *Handler) SchemaHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			sendErrorResponse(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.syncService.SchemaHistory(r.URL.Query().Get("table"), limit)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, "", entries)
+}
+
+func (h *Handler) DependencyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != services.GraphFormatDOT && format != services.GraphFormatMermaid {
+		sendErrorResponse(w, "Invalid format, use json, dot or mermaid", http.StatusBadRequest)
+		return
+	}
+
+	graph, err := h.syncService.DependencyGraph()
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "" || format == "json" {
+		sendSuccessResponse(w, "", graph)
+		return
+	}
+
+	rendered, err := services.RenderDependencyGraph(graph, format)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if format == services.GraphFormatDOT {
+		contentType = "text/vnd.graphviz; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(rendered))
+}
+
+func (h *Handler) SchemaApproveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SchemaApproveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	approved, err := h.syncService.ApproveSchemaChanges(req.TableName, req.Statement)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, "Schema changes approved", map[string]interface{}{"approved": approved})
+}
+
+func (h *Handler) RenameListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sendSuccessResponse(w, "", h.syncService.PendingRenames())
+}
+
+func (h *Handler) RenameResolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RenameResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.syncService.ResolveRename(req.TableName, req.OldName, req.NewName, req.Accept); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, "Column rename resolved", nil)
+}
+
+func (h *Handler) TableRenameListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sendSuccessResponse(w, "", h.syncService.PendingTableRenames())
+}
+
+func (h *Handler) TableRenameResolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TableRenameResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.syncService.ResolveTableRename(req.OldName, req.NewName, req.Accept); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, "Table rename resolved", nil)
+}
+
+func (h *Handler) SnapshotListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	snapshots, err := h.syncService.SchemaSnapshots(r.URL.Query().Get("table"))
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, "", snapshots)
+}
+
+func (h *Handler) SnapshotRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SnapshotRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := h.syncService.RestoreSchemaSnapshot(req.ID)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, "Snapshot restored", snapshot)
+}
+
+func (h *Handler) QuarantineListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rows, err := h.syncService.ListQuarantined(r.URL.Query().Get("table"))
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, "", rows)
+}
+
+func (h *Handler) QuarantineRetryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req QuarantineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	recovered, err := h.syncService.RetryQuarantined(req.TableName, req.PKValue)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, "Quarantined rows retried", map[string]interface{}{"recovered": recovered})
+}
+
+func (h *Handler) QuarantineDiscardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req QuarantineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.syncService.DiscardQuarantined(req.TableName, req.PKValue); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, "Quarantined row discarded", nil)
+}
+
+func (h *Handler) ConflictListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conflicts, err := h.syncService.ListConflicts(r.URL.Query().Get("table"))
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendSuccessResponse(w, "", conflicts)
+}
+
+func (h *Handler) ConflictResolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ConflictResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.syncService.ResolveConflict(req.TableName, req.PKValue, req.Winner); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendSuccessResponse(w, "Conflict resolved", nil)
+}
+
 func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	sendSuccessResponse(w, "Service is running", nil)
 }
 
 func (h *Handler) RootHandler(w http.ResponseWriter, r *http.Request) {
 	endpoints := map[string]string{
-		"health":       "GET /health",
-		"startSync":    "POST /api/sync/start",
-		"stopSync":     "POST /api/sync/stop",
-		"status":       "GET /api/sync/status",
-		"updateConfig": "PUT /api/sync/config",
-		"schemaSync":   "POST /api/schema/sync",
+		"health":        "GET /health",
+		"startSync":     "POST /api/sync/start",
+		"stopSync":      "POST /api/sync/stop",
+		"status":        "GET /api/sync/status",
+		"updateConfig":  "PUT /api/sync/config",
+		"schemaSync":    "POST /api/schema/sync",
+		"schemaDiff":    "GET /api/schema/diff",
+		"schemaApprove": "POST /api/schema/approve",
+		"schemaHistory": "GET /api/schema/history",
+		"dependencies":  "GET /api/schema/dependencies",
+		"renames":       "GET /api/schema/renames",
+		"renameResolve": "POST /api/schema/renames/resolve",
+		"tableRenames":  "GET /api/schema/table-renames",
+		"tableResolve":  "POST /api/schema/table-renames/resolve",
+		"snapshots":     "GET /api/schema/snapshots",
+		"restore":       "POST /api/schema/snapshots/restore",
+		"conflicts":     "GET /api/sync/conflicts",
+		"resolve":       "POST /api/sync/conflicts/resolve",
+		"quarantine":    "GET /api/quarantine",
+		"retry":         "POST /api/quarantine/retry",
+		"discard":       "POST /api/quarantine/discard",
 	}
 
 	response := Response{
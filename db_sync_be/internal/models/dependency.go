@@ -1,16 +1,42 @@
 package models
 
+// ForeignKey adalah satu kolom dari constraint FK, FK komposit terdiri dari beberapa baris
 type ForeignKey struct {
 	TableName            string `json:"table_name"`
 	ColumnName           string `json:"column_name"`
 	ReferencedTableName  string `json:"referenced_table_name"`
 	ReferencedColumnName string `json:"referenced_column_name"`
 	ConstraintName       string `json:"constraint_name"`
+	UpdateRule           string `json:"update_rule,omitempty"`
+	DeleteRule           string `json:"delete_rule,omitempty"`
+}
+
+// ForeignKeyConstraint adalah satu constraint FK utuh dengan kolom sesuai urutan
+type ForeignKeyConstraint struct {
+	ConstraintName      string   `json:"constraint_name"`
+	TableName           string   `json:"table_name"`
+	Columns             []string `json:"columns"`
+	ReferencedTableName string   `json:"referenced_table_name"`
+	ReferencedColumns   []string `json:"referenced_columns"`
+	UpdateRule          string   `json:"update_rule,omitempty"`
+	DeleteRule          string   `json:"delete_rule,omitempty"`
 }
 
 type TableDependency struct {
-	TableName   string   `json:"table_name"`
-	DependsOn   []string `json:"depends_on"`   // Tables that must be synced first
-	Level       int      `json:"level"`        // Depth level in dependency tree
-	HasCircular bool     `json:"has_circular"` // Has circular dependency
+	TableName       string   `json:"table_name"`
+	DependsOn       []string `json:"depends_on"`       // Tables that must be synced first
+	Level           int      `json:"level"`            // Depth level in dependency tree
+	HasCircular     bool     `json:"has_circular"`     // Has circular dependency
+	SelfReferencing bool     `json:"self_referencing"` // Has FK to itself, rows loaded parent-first
+	Cycle           []string `json:"cycle,omitempty"`  // Tables in the same FK cycle, sorted by name
+}
+
+// DependencyGraph adalah urutan sync tabel berdasarkan FK: Nodes sesuai urutan
+// sync, Edges satu per constraint FK (dari tabel anak ke tabel referensi),
+// Levels tabel per level dependency dan Cycles tabel yang saling bergantung
+type DependencyGraph struct {
+	Nodes  []TableDependency      `json:"nodes"`
+	Edges  []ForeignKeyConstraint `json:"edges"`
+	Levels [][]string             `json:"levels"`
+	Cycles [][]string             `json:"cycles"`
 }
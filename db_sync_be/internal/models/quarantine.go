@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type QuarantinedRow struct {
+	TableName     string    `json:"table_name"`
+	PKValue       string    `json:"pk_value"` // Semua kolom PK, format rowKey
+	RowData       string    `json:"row_data"`
+	ErrorMessage  string    `json:"error_message"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+}
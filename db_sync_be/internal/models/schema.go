@@ -0,0 +1,182 @@
+package models
+
+import "time"
+
+// Jenis index selain index biasa
+const (
+	IndexKindUnique   = "UNIQUE"
+	IndexKindFulltext = "FULLTEXT"
+	IndexKindSpatial  = "SPATIAL"
+)
+
+type IndexInfo struct {
+	IndexName string        `json:"index_name"`
+	Kind      string        `json:"kind"` // "", UNIQUE, FULLTEXT atau SPATIAL
+	Columns   []IndexColumn `json:"columns"`
+}
+
+type IndexColumn struct {
+	ColumnName string `json:"column_name"`
+	SubPart    int    `json:"sub_part,omitempty"` // Panjang prefix, 0 berarti seluruh kolom
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// SchemaChange adalah satu statement DDL hasil perbandingan schema master dan backup
+type SchemaChange struct {
+	TableName   string `json:"table_name"`
+	Kind        string `json:"kind"`               // add_column, modify_column, add_index, drop_index, ...
+	Object      string `json:"object"`             // Nama kolom, index atau constraint
+	NewName     string `json:"new_name,omitempty"` // Nama kolom setelah rename_column
+	Statement   string `json:"statement"`
+	Destructive bool   `json:"destructive"`
+	Narrowing   bool   `json:"narrowing"` // Tipe kolom menyempit, data backup bisa terpotong
+	// TypeChange: widening, narrowing atau incompatible, hanya untuk modify_column
+	TypeChange string     `json:"type_change,omitempty"`
+	DataCheck  *DataCheck `json:"data_check,omitempty"`
+	// RequiresApproval: perubahan belum disetujui dan tidak boleh dijalankan otomatis
+	RequiresApproval bool `json:"requires_approval"`
+}
+
+// DataCheck adalah hasil scan data backup sebelum kolom dipersempit
+type DataCheck struct {
+	ViolatingRows int64  `json:"violating_rows"`
+	Verified      bool   `json:"verified"` // false jika ada aturan yang tidak bisa dicek
+	Report        string `json:"report"`
+}
+
+// TablePlan adalah DDL yang akan dijalankan di backup untuk satu tabel
+type TablePlan struct {
+	TableName string         `json:"table_name"`
+	Action    string         `json:"action"` // create atau alter
+	Changes   []SchemaChange `json:"changes"`
+	Blocked   bool           `json:"blocked"` // Ada perubahan yang menunggu approval
+}
+
+// TableOptions adalah opsi tabel MySQL yang ikut disinkronkan. RowFormat hanya
+// berisi ROW_FORMAT yang ditulis eksplisit saat tabel dibuat.
+type TableOptions struct {
+	Engine       string        `json:"engine"`
+	Charset      string        `json:"charset"`
+	Collation    string        `json:"collation"`
+	RowFormat    string        `json:"row_format"`
+	Comment      string        `json:"comment"`
+	Partitioning *Partitioning `json:"partitioning,omitempty"`
+}
+
+// Partitioning adalah skema partisi tabel; subpartisi tidak disinkronkan
+type Partitioning struct {
+	Method     string      `json:"method"` // RANGE, LIST, RANGE COLUMNS, HASH, KEY, ...
+	Expression string      `json:"expression"`
+	Partitions []Partition `json:"partitions"`
+}
+
+type Partition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"` // Batas RANGE atau daftar nilai LIST
+}
+
+// PartitionChange adalah satu operasi partisi di backup. Reorganize berisi nama
+// partisi yang dipecah menjadi Partitions (mis. partisi MAXVALUE).
+type PartitionChange struct {
+	Action       string       `json:"action"`
+	Partitioning Partitioning `json:"partitioning"`
+	Reorganize   string       `json:"reorganize,omitempty"`
+}
+
+// SchemaProgress adalah DDL yang sedang berjalan di backup untuk satu tabel
+type SchemaProgress struct {
+	TableName  string    `json:"table_name"`
+	Method     string    `json:"method"` // Opsi online ALTER, shadow copy atau blocking ALTER
+	CopiedRows int64     `json:"copied_rows"`
+	TotalRows  int64     `json:"total_rows"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// TableDefinition adalah kolom dan index tabel backup pada satu waktu
+type TableDefinition struct {
+	Columns []ColumnInfo `json:"columns"`
+	Indexes []IndexInfo  `json:"indexes"`
+}
+
+// Jenis entri schema history selain DDL object (view, trigger, ...)
+const (
+	SchemaHistoryDDL      = "ddl"
+	SchemaHistoryDrift    = "drift"    // Schema backup diubah di luar db_sync
+	SchemaHistoryBaseline = "baseline" // Definisi pertama kali tercatat
+	SchemaHistoryRestore  = "restore"  // Tabel dikembalikan dari snapshot
+)
+
+// Hasil entri schema history
+const (
+	SchemaOutcomeSuccess  = "success"
+	SchemaOutcomeFailed   = "failed"
+	SchemaOutcomeDetected = "detected"
+)
+
+// SchemaHistoryEntry adalah satu DDL yang dijalankan di backup, atau perubahan
+// schema backup yang terdeteksi. Entri dalam satu sinkronisasi schema memakai
+// RunID yang sama dan diurutkan Seq.
+type SchemaHistoryEntry struct {
+	RunID        string           `json:"run_id"`
+	Seq          int              `json:"seq"`
+	TableName    string           `json:"table_name"`
+	Kind         string           `json:"kind"`
+	Statement    string           `json:"statement,omitempty"`
+	Before       *TableDefinition `json:"before,omitempty"`
+	After        *TableDefinition `json:"after,omitempty"`
+	ExecutedAt   time.Time        `json:"executed_at"`
+	DurationMs   int64            `json:"duration_ms"`
+	Outcome      string           `json:"outcome"`
+	ErrorMessage string           `json:"error_message,omitempty"`
+}
+
+// ColumnRename adalah kolom backup yang terdeteksi di-rename di master
+type ColumnRename struct {
+	TableName  string    `json:"table_name"`
+	OldName    string    `json:"old_name"`
+	NewName    string    `json:"new_name"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// TableRename adalah tabel backup yang terdeteksi di-rename di master
+type TableRename struct {
+	OldName    string    `json:"old_name"`
+	NewName    string    `json:"new_name"`
+	MasterRows int64     `json:"master_rows"`
+	BackupRows int64     `json:"backup_rows"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Jenis object schema selain tabel
+const (
+	ObjectTypeView      = "VIEW"
+	ObjectTypeTrigger   = "TRIGGER"
+	ObjectTypeProcedure = "PROCEDURE"
+	ObjectTypeFunction  = "FUNCTION"
+	ObjectTypeEvent     = "EVENT"
+)
+
+// SchemaObject adalah view, trigger, routine atau event beserta DDL aslinya
+type SchemaObject struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	TableName  string `json:"table_name,omitempty"` // Tabel milik trigger
+	Arguments  string `json:"arguments,omitempty"`  // Argumen routine PostgreSQL, bagian dari identitasnya
+	Definition string `json:"definition"`
+}
+
+// TableSnapshot adalah salinan tabel backup yang diambil sebelum DDL yang bisa
+// menghapus data (modify/drop kolom, drop tabel atau partisi)
+type TableSnapshot struct {
+	ID        string `json:"id"`
+	TableName string `json:"table_name"`
+	Mode      string `json:"mode"`     // table atau file
+	Location  string `json:"location"` // Nama tabel salinan atau path file
+	// Columns adalah kolom yang disalin (tanpa generated column), Definition DDL
+	// tabel saat snapshot diambil, dipakai untuk restore
+	Columns    []string  `json:"columns"`
+	Definition []string  `json:"definition"`
+	RowCount   int64     `json:"row_count"`
+	Reason     string    `json:"reason"` // Statement DDL yang memicu snapshot
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -15,6 +15,14 @@ type ColumnInfo struct {
 	ColumnKey     string  `json:"column_key"`
 	ColumnDefault *string `json:"column_default"`
 	Extra         string  `json:"extra"`
+
+	// DefaultExpression: ColumnDefault berisi ekspresi (CURRENT_TIMESTAMP, uuid()), bukan nilai literal
+	DefaultExpression    bool    `json:"default_expression,omitempty"`
+	GenerationExpression string  `json:"generation_expression,omitempty"`
+	CharacterSetName     *string `json:"character_set_name,omitempty"`
+	CollationName        *string `json:"collation_name,omitempty"`
+	ColumnComment        string  `json:"column_comment,omitempty"`
+	SrsID                *string `json:"srs_id,omitempty"`
 }
 
 type SyncStatus struct {
@@ -25,3 +33,12 @@ type SyncStatus struct {
 	Status       string    `json:"status"`
 	ErrorMessage string    `json:"error_message,omitempty"`
 }
+
+type SyncConflict struct {
+	TableName  string    `json:"table_name"`
+	PKValue    string    `json:"pk_value"`
+	MasterData string    `json:"master_data,omitempty"`
+	BackupData string    `json:"backup_data,omitempty"`
+	Reason     string    `json:"reason"`
+	DetectedAt time.Time `json:"detected_at"`
+}
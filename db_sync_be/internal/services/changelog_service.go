@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	changelogTable = "_db_sync_changelog"
+
+	changelogInsert = "I"
+	changelogUpdate = "U"
+	changelogDelete = "D"
+)
+
+// changelogEntry adalah satu baris di changelog master. PKValues berisi nilai
+// primary key sesuai urutan kolom PK tabel.
+type changelogEntry struct {
+	ID        int64
+	TableName string
+	PKValues  []interface{}
+	Operation string
+}
+
+// ChangelogService mengelola trigger AFTER INSERT/UPDATE/DELETE di master yang
+// mencatat PK baris yang berubah ke tabel changelog. Tabel aplikasi tidak pernah
+// diubah selain penambahan trigger.
+type ChangelogService struct {
+	masterDB *sql.DB
+	source   dialect.Dialect
+	mutex    sync.Mutex
+	ready    bool
+}
+
+func NewChangelogService(masterDB *sql.DB, source dialect.Dialect) *ChangelogService {
+	return &ChangelogService{
+		masterDB: masterDB,
+		source:   source,
+	}
+}
+
+// ensureTable membuat tabel changelog di master jika belum ada
+func (c *ChangelogService) ensureTable() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.ready {
+		return nil
+	}
+
+	if c.source.Name() != "mysql" {
+		return fmt.Errorf("trigger-based capture requires a mysql master, got %s", c.source.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	            id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	            table_name VARCHAR(64) NOT NULL,
+	            pk_value JSON NOT NULL,
+	            operation CHAR(1) NOT NULL,
+	            changed_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+	            PRIMARY KEY (id)
+	          )`, changelogTable)
+
+	if _, err := c.masterDB.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create changelog table: %v", err)
+	}
+
+	c.ready = true
+	return nil
+}
+
+// Install membuat trigger changelog untuk tabel yang diberikan. Trigger lama
+// milik db_sync di tabel yang sama diganti supaya mengikuti PK terbaru.
+func (c *ChangelogService) Install(tables []string) error {
+	if err := c.ensureTable(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if strings.HasPrefix(table, internalTablePrefix) {
+			continue
+		}
+
+		if err := c.installTable(table); err != nil {
+			return err
+		}
+		log.Printf("Changelog triggers installed on %s", table)
+	}
+
+	return nil
+}
+
+func (c *ChangelogService) installTable(table string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pkColumns, err := c.source.GetPrimaryKeyColumns(ctx, c.masterDB, table)
+	if err != nil {
+		return fmt.Errorf("failed to get primary key for %s: %v", table, err)
+	}
+	if len(pkColumns) == 0 {
+		return fmt.Errorf("table %s has no primary key, cannot capture changes", table)
+	}
+
+	statements := append(c.dropTriggerStatements(table), c.createTriggerStatements(table, pkColumns)...)
+	for _, stmt := range statements {
+		if _, err := c.masterDB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to install changelog trigger on %s: %v", table, err)
+		}
+	}
+
+	return nil
+}
+
+// Uninstall menghapus trigger changelog. Tanpa tabel, semua trigger dan tabel
+// changelog dihapus.
+func (c *ChangelogService) Uninstall(tables []string) error {
+	if c.source.Name() != "mysql" {
+		return fmt.Errorf("trigger-based capture requires a mysql master, got %s", c.source.Name())
+	}
+
+	removeAll := len(tables) == 0
+	if removeAll {
+		installed, err := c.InstalledTables()
+		if err != nil {
+			return err
+		}
+		tables = installed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, table := range tables {
+		for _, stmt := range c.dropTriggerStatements(table) {
+			if _, err := c.masterDB.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to remove changelog trigger on %s: %v", table, err)
+			}
+		}
+		log.Printf("Changelog triggers removed from %s", table)
+	}
+
+	if removeAll {
+		if _, err := c.masterDB.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", changelogTable)); err != nil {
+			return fmt.Errorf("failed to drop changelog table: %v", err)
+		}
+
+		c.mutex.Lock()
+		c.ready = false
+		c.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// InstalledTables mengembalikan tabel master yang punya trigger changelog
+func (c *ChangelogService) InstalledTables() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT DISTINCT EVENT_OBJECT_TABLE
+	          FROM information_schema.TRIGGERS
+	          WHERE TRIGGER_SCHEMA = DATABASE()
+	          AND TRIGGER_NAME LIKE '\_db\_sync\_%'
+	          ORDER BY EVENT_OBJECT_TABLE`
+
+	rows, err := c.masterDB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changelog triggers: %v", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// Fetch mengambil entry changelog tertua dengan id setelah afterID. Entry yang
+// belum bisa di-ack dilewati lewat afterID supaya tidak dibaca ulang terus.
+func (c *ChangelogService) Fetch(afterID int64, limit int) ([]changelogEntry, error) {
+	if err := c.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT id, table_name, pk_value, operation FROM %s WHERE id > ? ORDER BY id LIMIT ?", changelogTable)
+
+	rows, err := c.masterDB.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changelog: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []changelogEntry
+	for rows.Next() {
+		var entry changelogEntry
+		var pkValue string
+		if err := rows.Scan(&entry.ID, &entry.TableName, &pkValue, &entry.Operation); err != nil {
+			return nil, err
+		}
+
+		// UseNumber supaya PK bigint tidak kehilangan presisi
+		decoder := json.NewDecoder(strings.NewReader(pkValue))
+		decoder.UseNumber()
+		if err := decoder.Decode(&entry.PKValues); err != nil {
+			return nil, fmt.Errorf("invalid changelog pk value %q: %v", pkValue, err)
+		}
+		for i, val := range entry.PKValues {
+			if n, ok := val.(json.Number); ok {
+				entry.PKValues[i] = n.String()
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Ack menghapus entry changelog yang sudah diproses. Dihapus per id, bukan
+// per rentang, karena id dari transaksi yang commit belakangan bisa lebih kecil.
+func (c *ChangelogService) Ack(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", changelogTable, strings.Join(placeholders, ", "))
+	if _, err := c.masterDB.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to acknowledge changelog entries: %v", err)
+	}
+
+	return nil
+}
+
+// Pending mengembalikan jumlah entry yang belum diproses
+func (c *ChangelogService) Pending() (int, error) {
+	if err := c.ensureTable(); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var count int
+	err := c.masterDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", changelogTable)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count changelog entries: %v", err)
+	}
+
+	return count, nil
+}
+
+// createTriggerStatements membuat trigger AFTER INSERT/UPDATE/DELETE untuk satu tabel.
+// UPDATE yang mengubah PK juga mencatat delete untuk PK lama.
+func (c *ChangelogService) createTriggerStatements(table string, pkColumns []string) []string {
+	q := c.source.QuoteIdentifier
+	tableLiteral := "'" + strings.ReplaceAll(table, "'", "''") + "'"
+
+	pkArray := func(alias string) string {
+		values := make([]string, len(pkColumns))
+		for i, pk := range pkColumns {
+			values[i] = alias + "." + q(pk)
+		}
+		return "JSON_ARRAY(" + strings.Join(values, ", ") + ")"
+	}
+
+	insertLog := func(alias, operation string) string {
+		return fmt.Sprintf("INSERT INTO %s (table_name, pk_value, operation) VALUES (%s, %s, '%s')",
+			q(changelogTable), tableLiteral, pkArray(alias), operation)
+	}
+
+	var pkUnchanged []string
+	for _, pk := range pkColumns {
+		pkUnchanged = append(pkUnchanged, fmt.Sprintf("OLD.%s <=> NEW.%s", q(pk), q(pk)))
+	}
+
+	return []string{
+		fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT ON %s FOR EACH ROW %s",
+			q(changelogTriggerName(table, "ai")), q(table), insertLog("NEW", changelogInsert)),
+		fmt.Sprintf(`CREATE TRIGGER %s AFTER UPDATE ON %s FOR EACH ROW
+	          BEGIN
+	            IF NOT (%s) THEN
+	              %s;
+	            END IF;
+	            %s;
+	          END`,
+			q(changelogTriggerName(table, "au")), q(table),
+			strings.Join(pkUnchanged, " AND "), insertLog("OLD", changelogDelete), insertLog("NEW", changelogUpdate)),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER DELETE ON %s FOR EACH ROW %s",
+			q(changelogTriggerName(table, "ad")), q(table), insertLog("OLD", changelogDelete)),
+	}
+}
+
+func (c *ChangelogService) dropTriggerStatements(table string) []string {
+	var statements []string
+	for _, suffix := range []string{"ai", "au", "ad"} {
+		statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s",
+			c.source.QuoteIdentifier(changelogTriggerName(table, suffix))))
+	}
+	return statements
+}
+
+// changelogTriggerName membuat nama trigger, di-hash jika melebihi batas 64 karakter MySQL
+func changelogTriggerName(table, suffix string) string {
+	name := internalTablePrefix + table + "_" + suffix
+	if len(name) <= 64 {
+		return name
+	}
+
+	sum := md5.Sum([]byte(table))
+	return internalTablePrefix + hex.EncodeToString(sum[:]) + "_" + suffix
+}
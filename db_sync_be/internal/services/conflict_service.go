@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	rowStateTable = "_db_sync_row_state"
+	conflictTable = "_db_sync_conflicts"
+	seededTable   = "_db_sync_seeded_tables"
+)
+
+// rowState adalah checksum baris terakhir yang diketahui sama di kedua sisi.
+// Origin mencatat sisi mana yang menjadi sumber perubahan terakhir.
+type rowState struct {
+	Checksum string
+	Origin   string
+}
+
+// ConflictService menyimpan checkpoint per baris untuk bidirectional sync dan
+// konflik yang menunggu keputusan manual
+type ConflictService struct {
+	backupDB *sql.DB
+	mutex    sync.Mutex
+	ready    bool
+}
+
+func NewConflictService(backupDB *sql.DB) *ConflictService {
+	return &ConflictService{
+		backupDB: backupDB,
+	}
+}
+
+func (c *ConflictService) ensureTables() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.ready {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// pk_value menyimpan key lengkap, PK-nya memakai pk_hash (rowKeyHash) karena
+	// key komposit yang di-escape bisa melebihi panjang index VARCHAR
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	            table_name VARCHAR(64) NOT NULL,
+	            pk_hash CHAR(32) NOT NULL,
+	            pk_value TEXT NOT NULL,
+	            checksum CHAR(32) NOT NULL,
+	            origin VARCHAR(16) NOT NULL,
+	            synced_at DATETIME NOT NULL,
+	            PRIMARY KEY (table_name, pk_hash)
+	          )`, rowStateTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	            table_name VARCHAR(64) NOT NULL,
+	            pk_hash CHAR(32) NOT NULL,
+	            pk_value TEXT NOT NULL,
+	            master_data LONGTEXT,
+	            backup_data LONGTEXT,
+	            reason VARCHAR(255) NOT NULL,
+	            detected_at DATETIME NOT NULL,
+	            PRIMARY KEY (table_name, pk_hash)
+	          )`, conflictTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	            table_name VARCHAR(64) NOT NULL,
+	            seeded_at DATETIME NOT NULL,
+	            PRIMARY KEY (table_name)
+	          )`, seededTable),
+	}
+
+	for _, stmt := range statements {
+		if _, err := c.backupDB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create bidirectional sync tables: %v", err)
+		}
+	}
+
+	for _, table := range []string{rowStateTable, conflictTable} {
+		if err := c.upgradeTable(ctx, table); err != nil {
+			return err
+		}
+	}
+
+	c.ready = true
+	return nil
+}
+
+// upgradeTable menambahkan pk_hash ke tabel versi lama yang PK-nya
+// (table_name, pk_value VARCHAR(191)), lalu memindahkan PK ke pk_hash
+func (c *ConflictService) upgradeTable(ctx context.Context, table string) error {
+	var count int
+	err := c.backupDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.COLUMNS
+	          WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = 'pk_hash'`, table).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %v", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	log.Printf("Upgrading %s to store primary keys by hash", table)
+
+	// Tabel checkpoint berisi satu baris per baris yang di-sync, beri waktu lebih lama
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	statements := []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN pk_hash CHAR(32) NULL AFTER table_name", table),
+		fmt.Sprintf("UPDATE %s SET pk_hash = MD5(pk_value)", table),
+		fmt.Sprintf(`ALTER TABLE %s
+		            MODIFY pk_hash CHAR(32) NOT NULL,
+		            MODIFY pk_value TEXT NOT NULL,
+		            DROP PRIMARY KEY,
+		            ADD PRIMARY KEY (table_name, pk_hash)`, table),
+	}
+	for _, stmt := range statements {
+		if _, err := c.backupDB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to upgrade %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// LoadStates mengembalikan checkpoint semua baris untuk satu tabel
+func (c *ConflictService) LoadStates(tableName string) (map[string]rowState, error) {
+	if err := c.ensureTables(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT pk_value, checksum, origin FROM %s WHERE table_name = ?", rowStateTable)
+
+	rows, err := c.backupDB.QueryContext(ctx, query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load row state: %v", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]rowState)
+	for rows.Next() {
+		var pkValue string
+		var state rowState
+		if err := rows.Scan(&pkValue, &state.Checksum, &state.Origin); err != nil {
+			return nil, err
+		}
+		states[pkValue] = state
+	}
+
+	return states, rows.Err()
+}
+
+// SaveState menyimpan checkpoint baris setelah kedua sisi sama
+func (c *ConflictService) SaveState(tableName, pkValue string, state rowState) error {
+	if err := c.ensureTables(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (table_name, pk_hash, pk_value, checksum, origin, synced_at)
+	          VALUES (?, ?, ?, ?, ?, ?)
+	          ON DUPLICATE KEY UPDATE
+	            checksum = VALUES(checksum),
+	            origin = VALUES(origin),
+	            synced_at = VALUES(synced_at)`, rowStateTable)
+
+	_, err := c.backupDB.ExecContext(ctx, query, tableName, rowKeyHash(pkValue), pkValue, state.Checksum, state.Origin, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save row state: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteState menghapus checkpoint baris yang sudah tidak ada di kedua sisi
+func (c *ConflictService) DeleteState(tableName, pkValue string) error {
+	if err := c.ensureTables(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE table_name = ? AND pk_hash = ?", rowStateTable)
+	if _, err := c.backupDB.ExecContext(ctx, query, tableName, rowKeyHash(pkValue)); err != nil {
+		return fmt.Errorf("failed to delete row state: %v", err)
+	}
+
+	return nil
+}
+
+// Seeded mengecek apakah tabel sudah pernah selesai satu putaran bidirectional
+// sync, yaitu checkpoint-nya sudah di-seed dari kondisi awal kedua sisi
+func (c *ConflictService) Seeded(tableName string) (bool, error) {
+	if err := c.ensureTables(); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE table_name = ?", seededTable)
+	var count int
+	if err := c.backupDB.QueryRowContext(ctx, query, tableName).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check seeded state: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+// MarkSeeded menandai tabel sudah selesai putaran bidirectional pertamanya
+func (c *ConflictService) MarkSeeded(tableName string) error {
+	if err := c.ensureTables(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (table_name, seeded_at)
+	          VALUES (?, ?)
+	          ON DUPLICATE KEY UPDATE seeded_at = seeded_at`, seededTable)
+
+	if _, err := c.backupDB.ExecContext(ctx, query, tableName, time.Now()); err != nil {
+		return fmt.Errorf("failed to mark table as seeded: %v", err)
+	}
+
+	return nil
+}
+
+// ResetTable menghapus checkpoint dan tanda seeded satu tabel, mis. setelah
+// tabel backup di-restore, sehingga run berikutnya diperlakukan sebagai run pertama
+func (c *ConflictService) ResetTable(tableName string) error {
+	if err := c.ensureTables(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	for _, table := range []string{rowStateTable, seededTable} {
+		query := fmt.Sprintf("DELETE FROM %s WHERE table_name = ?", table)
+		if _, err := c.backupDB.ExecContext(ctx, query, tableName); err != nil {
+			return fmt.Errorf("failed to reset bidirectional state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Record mencatat konflik untuk direview manual
+func (c *ConflictService) Record(tableName, pkValue string, masterRow, backupRow map[string]interface{}, reason string) error {
+	if err := c.ensureTables(); err != nil {
+		return err
+	}
+
+	masterData, err := encodeConflictRow(masterRow)
+	if err != nil {
+		return err
+	}
+	backupData, err := encodeConflictRow(backupRow)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (table_name, pk_hash, pk_value, master_data, backup_data, reason, detected_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)
+	          ON DUPLICATE KEY UPDATE
+	            master_data = VALUES(master_data),
+	            backup_data = VALUES(backup_data),
+	            reason = VALUES(reason)`, conflictTable)
+
+	_, err = c.backupDB.ExecContext(ctx, query, tableName, rowKeyHash(pkValue), pkValue, masterData, backupData, reason, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record conflict: %v", err)
+	}
+
+	return nil
+}
+
+// Remove menghapus konflik yang sudah di-resolve
+func (c *ConflictService) Remove(tableName, pkValue string) error {
+	if err := c.ensureTables(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE table_name = ? AND pk_hash = ?", conflictTable)
+	if _, err := c.backupDB.ExecContext(ctx, query, tableName, rowKeyHash(pkValue)); err != nil {
+		return fmt.Errorf("failed to remove conflict: %v", err)
+	}
+
+	return nil
+}
+
+// Pending mengembalikan set PK yang sedang menunggu resolusi manual
+func (c *ConflictService) Pending(tableName string) (map[string]bool, error) {
+	conflicts, err := c.List(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for _, conflict := range conflicts {
+		keys[conflict.PKValue] = true
+	}
+
+	return keys, nil
+}
+
+// List mengembalikan konflik yang belum di-resolve, opsional difilter per tabel
+func (c *ConflictService) List(tableName string) ([]models.SyncConflict, error) {
+	if err := c.ensureTables(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT table_name, pk_value, master_data, backup_data, reason, detected_at
+	          FROM %s`, conflictTable)
+	var args []interface{}
+	if tableName != "" {
+		query += " WHERE table_name = ?"
+		args = append(args, tableName)
+	}
+	query += " ORDER BY table_name, detected_at"
+
+	rows, err := c.backupDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicts: %v", err)
+	}
+	defer rows.Close()
+
+	result := []models.SyncConflict{}
+	for rows.Next() {
+		var conflict models.SyncConflict
+		var masterData, backupData sql.NullString
+		err := rows.Scan(
+			&conflict.TableName,
+			&conflict.PKValue,
+			&masterData,
+			&backupData,
+			&conflict.Reason,
+			&conflict.DetectedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		conflict.MasterData = masterData.String
+		conflict.BackupData = backupData.String
+		result = append(result, conflict)
+	}
+
+	return result, rows.Err()
+}
+
+func encodeConflictRow(row map[string]interface{}) (interface{}, error) {
+	if row == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode conflict row: %v", err)
+	}
+
+	return string(data), nil
+}
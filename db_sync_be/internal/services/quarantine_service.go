@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const quarantineTable = "_db_sync_quarantine"
+
+// QuarantineService menyimpan baris yang gagal di-apply ke backup supaya
+// tidak memblokir baris lain dalam tabel yang sama
+type QuarantineService struct {
+	backupDB *sql.DB
+	target   dialect.Dialect
+	mutex    sync.Mutex
+	ready    bool
+}
+
+func NewQuarantineService(backupDB *sql.DB, target dialect.Dialect) *QuarantineService {
+	return &QuarantineService{
+		backupDB: backupDB,
+		target:   target,
+	}
+}
+
+// quarantineColumns adalah struktur tabel quarantine, dirender oleh target backup.
+// pk_value menyimpan key lengkap, unique key-nya memakai pk_hash (rowKeyHash).
+var quarantineColumns = []models.ColumnInfo{
+	dialect.InternalColumn("table_name", "varchar", "varchar(64)", true, true),
+	dialect.InternalColumn("pk_hash", "char", "char(32)", true, true),
+	dialect.InternalColumn("pk_value", "text", "text", true, false),
+	dialect.InternalColumn("row_data", "longtext", "longtext", false, false),
+	dialect.InternalColumn("error_message", "text", "text", false, false),
+	dialect.InternalColumn("attempts", "int", "int", true, false),
+	dialect.InternalColumn("first_failed_at", "datetime", "datetime", true, false),
+	dialect.InternalColumn("last_failed_at", "datetime", "datetime", true, false),
+}
+
+// ensureTable membuat tabel quarantine di backup database jika belum ada
+func (q *QuarantineService) ensureTable() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.ready {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := q.target.TableExists(ctx, q.backupDB, quarantineTable)
+	if err != nil {
+		return fmt.Errorf("failed to check quarantine table: %v", err)
+	}
+
+	if exists {
+		if err := q.upgradeTable(ctx); err != nil {
+			return err
+		}
+	} else {
+		query := q.target.CreateTableStatement(quarantineTable, quarantineColumns, "")
+		if _, err := q.backupDB.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create quarantine table: %v", err)
+		}
+	}
+
+	q.ready = true
+	return nil
+}
+
+// upgradeTable memindahkan tabel quarantine versi lama (unique key di pk_value
+// varchar(191)) ke struktur dengan pk_hash. Tabel lama di-rename, isinya disalin
+// dengan hash yang dihitung di sini, lalu di-drop.
+func (q *QuarantineService) upgradeTable(ctx context.Context) error {
+	columns, err := q.target.GetColumns(ctx, q.backupDB, quarantineTable)
+	if err != nil {
+		return fmt.Errorf("failed to check quarantine table: %v", err)
+	}
+	for _, col := range columns {
+		if col.ColumnName == "pk_hash" {
+			return nil
+		}
+	}
+
+	log.Printf("Upgrading %s to store primary keys by hash", quarantineTable)
+
+	oldTable := quarantineTable + "_old"
+	statements := []string{
+		q.target.RenameTableStatement(quarantineTable, oldTable),
+		q.target.CreateTableStatement(quarantineTable, quarantineColumns, ""),
+	}
+	for _, stmt := range statements {
+		if _, err := q.backupDB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to upgrade quarantine table: %v", err)
+		}
+	}
+
+	selectQuery := fmt.Sprintf(`SELECT table_name, pk_value, row_data, error_message, attempts, first_failed_at, last_failed_at
+	          FROM %s`, q.target.QuoteIdentifier(oldTable))
+	rows, err := q.backupDB.QueryContext(ctx, selectQuery)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade quarantine table: %v", err)
+	}
+
+	var copied [][]interface{}
+	for rows.Next() {
+		var tableName, pkValue string
+		var rowData, errorMessage sql.NullString
+		var attempts int
+		var firstFailed, lastFailed time.Time
+		if err := rows.Scan(&tableName, &pkValue, &rowData, &errorMessage, &attempts, &firstFailed, &lastFailed); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to upgrade quarantine table: %v", err)
+		}
+		copied = append(copied, []interface{}{tableName, rowKeyHash(pkValue), pkValue, rowData, errorMessage, attempts, firstFailed, lastFailed})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to upgrade quarantine table: %v", err)
+	}
+
+	insertQuery := fmt.Sprintf(`INSERT INTO %s
+	            (table_name, pk_hash, pk_value, row_data, error_message, attempts, first_failed_at, last_failed_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, quarantineTable)
+	for _, values := range copied {
+		if _, err := q.backupDB.ExecContext(ctx, q.target.Rebind(insertQuery), values...); err != nil {
+			return fmt.Errorf("failed to upgrade quarantine table: %v", err)
+		}
+	}
+
+	if _, err := q.backupDB.ExecContext(ctx, "DROP TABLE "+q.target.QuoteIdentifier(oldTable)); err != nil {
+		return fmt.Errorf("failed to upgrade quarantine table: %v", err)
+	}
+	return nil
+}
+
+// Add mencatat baris yang gagal beserta error-nya. Baris yang sudah ada di
+// quarantine akan di-update dan jumlah attempts-nya bertambah.
+func (q *QuarantineService) Add(tableName, pkValue string, row map[string]interface{}, cause error) error {
+	if err := q.ensureTable(); err != nil {
+		return err
+	}
+
+	rowData, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("failed to encode row data: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	// Update dulu supaya attempts bertambah, insert jika belum pernah di-quarantine
+	updateQuery := fmt.Sprintf(`UPDATE %s
+	          SET row_data = ?, error_message = ?, attempts = attempts + 1, last_failed_at = ?
+	          WHERE table_name = ? AND pk_hash = ?`, quarantineTable)
+
+	pkHash := rowKeyHash(pkValue)
+	result, err := q.backupDB.ExecContext(ctx, q.target.Rebind(updateQuery), string(rowData), cause.Error(), now, tableName, pkHash)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine row %s.%s: %v", tableName, pkValue, err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		insertQuery := fmt.Sprintf(`INSERT INTO %s
+	            (table_name, pk_hash, pk_value, row_data, error_message, attempts, first_failed_at, last_failed_at)
+	          VALUES (?, ?, ?, ?, ?, 1, ?, ?)`, quarantineTable)
+
+		_, err = q.backupDB.ExecContext(ctx, q.target.Rebind(insertQuery), tableName, pkHash, pkValue, string(rowData), cause.Error(), now, now)
+		if err != nil {
+			return fmt.Errorf("failed to quarantine row %s.%s: %v", tableName, pkValue, err)
+		}
+	}
+
+	log.Printf("  Row %s in %s quarantined: %v", pkValue, tableName, cause)
+	return nil
+}
+
+// Remove menghapus baris dari quarantine (setelah berhasil di-retry atau di-discard)
+func (q *QuarantineService) Remove(tableName, pkValue string) error {
+	if err := q.ensureTable(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE table_name = ? AND pk_hash = ?", quarantineTable)
+
+	result, err := q.backupDB.ExecContext(ctx, q.target.Rebind(query), tableName, rowKeyHash(pkValue))
+	if err != nil {
+		return fmt.Errorf("failed to remove quarantined row: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err == nil && affected == 0 {
+		return fmt.Errorf("row %s is not quarantined for table %s", pkValue, tableName)
+	}
+
+	return nil
+}
+
+// List mengembalikan semua baris di quarantine, opsional difilter per tabel
+func (q *QuarantineService) List(tableName string) ([]models.QuarantinedRow, error) {
+	if err := q.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT table_name, pk_value, row_data, error_message, attempts, first_failed_at, last_failed_at
+	          FROM %s`, quarantineTable)
+	var args []interface{}
+	if tableName != "" {
+		query += " WHERE table_name = ?"
+		args = append(args, tableName)
+	}
+	query += " ORDER BY table_name, last_failed_at"
+
+	rows, err := q.backupDB.QueryContext(ctx, q.target.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined rows: %v", err)
+	}
+	defer rows.Close()
+
+	result := []models.QuarantinedRow{}
+	for rows.Next() {
+		var row models.QuarantinedRow
+		var rowData, errMsg sql.NullString
+		err := rows.Scan(
+			&row.TableName,
+			&row.PKValue,
+			&rowData,
+			&errMsg,
+			&row.Attempts,
+			&row.FirstFailedAt,
+			&row.LastFailedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		row.RowData = rowData.String
+		row.ErrorMessage = errMsg.String
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// PendingKeys mengembalikan set PK yang sedang di-quarantine untuk satu tabel
+func (q *QuarantineService) PendingKeys(tableName string) (map[string]bool, error) {
+	if err := q.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT pk_value FROM %s WHERE table_name = ?", quarantineTable)
+
+	rows, err := q.backupDB.QueryContext(ctx, q.target.Rebind(query), tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var pkValue string
+		if err := rows.Scan(&pkValue); err != nil {
+			return nil, err
+		}
+		keys[pkValue] = true
+	}
+
+	return keys, rows.Err()
+}
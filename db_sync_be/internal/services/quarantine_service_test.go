@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQuarantineLongKeys(t *testing.T) {
+	s := newSQLiteSyncService(t, nil, nil, nil)
+	q := s.quarantine
+
+	// Dua key yang sama di 191 karakter pertama tetap tercatat terpisah
+	prefix := strings.Repeat("x", 200)
+	keys := []string{prefix + "|1", prefix + "|2"}
+	for _, key := range keys {
+		if err := q.Add("documents", key, map[string]interface{}{"id": key}, errors.New("constraint failed")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Add("documents", keys[0], nil, errors.New("constraint failed again")); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := q.List("documents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("quarantined %d rows, want 2", len(rows))
+	}
+	for _, row := range rows {
+		if row.PKValue == keys[0] && row.Attempts != 2 {
+			t.Errorf("attempts for %s = %d, want 2", row.PKValue[len(prefix):], row.Attempts)
+		}
+	}
+
+	if err := q.Remove("documents", keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := q.PendingKeys("documents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || !pending[keys[0]] {
+		t.Errorf("pending keys after remove = %d, want only the first key", len(pending))
+	}
+}
+
+func TestQuarantineUpgradesLegacyTable(t *testing.T) {
+	legacy := []string{
+		`CREATE TABLE _db_sync_quarantine (
+			table_name TEXT NOT NULL,
+			pk_value TEXT NOT NULL,
+			row_data TEXT,
+			error_message TEXT,
+			attempts INTEGER NOT NULL,
+			first_failed_at DATETIME NOT NULL,
+			last_failed_at DATETIME NOT NULL,
+			PRIMARY KEY (table_name, pk_value))`,
+		`INSERT INTO _db_sync_quarantine VALUES ('orders', '10100', '{"orderNumber":10100}', 'constraint failed', 3, '2026-01-02 03:04:05', '2026-01-03 03:04:05')`,
+	}
+	s := newSQLiteSyncService(t, nil, nil, legacy)
+
+	rows, err := s.quarantine.List("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].PKValue != "10100" || rows[0].Attempts != 3 {
+		t.Fatalf("rows after upgrade = %+v, want the legacy row", rows)
+	}
+
+	if err := s.quarantine.Remove("orders", "10100"); err != nil {
+		t.Fatalf("remove by hash after upgrade: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Aksi schema plan untuk satu tabel
+const (
+	PlanActionCreate  = "create"
+	PlanActionAlter   = "alter"
+	PlanActionRename  = "rename"  // Tabel backup lama di-rename ke nama di master
+	PlanActionArchive = "archive" // Tabel yang hilang di master di-rename ke _archived_<nama>
+	PlanActionDrop    = "drop"    // Tabel yang hilang di master, sesuai DroppedTablePolicy
+)
+
+// PlanSchema menghasilkan DDL yang akan dijalankan untuk satu tabel tanpa
+// menjalankannya. Perubahan yang perlu approval ditandai RequiresApproval.
+func (s *SchemaService) PlanSchema(tableName string) (models.TablePlan, error) {
+	plan := models.TablePlan{TableName: tableName}
+
+	onMaster, err := s.masterTableExists(tableName)
+	if err != nil {
+		return plan, err
+	}
+	if !onMaster {
+		return s.planDroppedTable(tableName)
+	}
+
+	exists, err := s.TableExists(tableName)
+	if err != nil {
+		return plan, err
+	}
+
+	var changes []models.SchemaChange
+	if exists {
+		plan.Action = PlanActionAlter
+		changes, err = s.CompareSchemas(tableName)
+	} else {
+		changes, err = s.planNewTable(&plan)
+	}
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Changes = s.markApprovals(changes)
+	plan.Blocked = changesBlocked(plan.Changes)
+	return plan, nil
+}
+
+// planNewTable membuat tabel yang belum ada di backup, atau me-rename tabel
+// backup lama jika tabel master terdeteksi hasil rename. Tabel yang rename-nya
+// menunggu konfirmasi tidak dibuat dulu.
+func (s *SchemaService) planNewTable(plan *models.TablePlan) ([]models.SchemaChange, error) {
+	renames, held, err := s.detectTableRenames()
+	if err != nil {
+		return nil, err
+	}
+
+	if held[plan.TableName] {
+		plan.Action = PlanActionRename
+		return nil, nil
+	}
+
+	if oldName, ok := renames[plan.TableName]; ok {
+		plan.Action = PlanActionRename
+		return []models.SchemaChange{{
+			TableName: plan.TableName,
+			Kind:      "rename_table",
+			Object:    oldName,
+			Statement: s.target.RenameTableStatement(oldName, plan.TableName),
+		}}, nil
+	}
+
+	plan.Action = PlanActionCreate
+	return s.CreateTablePlan(plan.TableName)
+}
+
+// PlanAllSchemas menghasilkan schema plan untuk semua tabel yang berbeda, sesuai
+// urutan dependency FK. FK ke tabel yang belum dibuat baru muncul setelah tabel
+// referensinya ada di backup.
+func (s *SchemaService) PlanAllSchemas() ([]models.TablePlan, error) {
+	tableDeps, err := s.GetAllTablesWithDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []models.TablePlan{}
+	for _, dep := range tableDeps {
+		plan, err := s.PlanSchema(dep.TableName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan schema for table %s: %v", dep.TableName, err)
+		}
+		if len(plan.Changes) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+
+	_, orphans, err := s.tableSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range orphans {
+		plan, err := s.planDroppedTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan schema for table %s: %v", table, err)
+		}
+		if len(plan.Changes) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+
+	return plans, nil
+}
+
+// ApproveChanges menyetujui perubahan yang menunggu approval untuk satu tabel;
+// statement kosong berarti semua perubahan tabel tersebut. Approval berlaku
+// untuk statement yang persis sama, jika master berubah lagi perlu approval baru.
+func (s *SchemaService) ApproveChanges(tableName, statement string) (int, error) {
+	plan, err := s.PlanSchema(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	s.approvalMutex.Lock()
+	defer s.approvalMutex.Unlock()
+
+	approved := 0
+	for _, change := range plan.Changes {
+		if !change.RequiresApproval || (statement != "" && change.Statement != statement) {
+			continue
+		}
+		s.approvals[approvalKey(tableName, change.Statement)] = true
+		approved++
+	}
+
+	if approved == 0 {
+		return 0, fmt.Errorf("no schema change waiting for approval for table %s", tableName)
+	}
+
+	log.Printf("Approved %d schema changes for table %s", approved, tableName)
+	return approved, nil
+}
+
+// SchemaBlocked mengembalikan alasan data tabel belum boleh di-sync, kosong jika
+// schema backup sudah siap
+func (s *SchemaService) SchemaBlocked(tableName string) string {
+	if s.HasPendingRenames(tableName) {
+		return "column rename waiting for confirmation"
+	}
+	if s.HasPendingTableRename(tableName) {
+		return "table rename waiting for confirmation"
+	}
+	if s.shadowCopyRunning(tableName) {
+		return "shadow table copy in progress"
+	}
+
+	s.approvalMutex.Lock()
+	defer s.approvalMutex.Unlock()
+
+	return s.heldTables[tableName]
+}
+
+// markApprovals menandai perubahan yang perlu approval dan belum disetujui.
+// Perubahan destruktif dan narrowing (termasuk tipe yang incompatible) selalu
+// perlu approval, juga jika data check menunjukkan semua data backup muat.
+func (s *SchemaService) markApprovals(changes []models.SchemaChange) []models.SchemaChange {
+	s.approvalMutex.Lock()
+	defer s.approvalMutex.Unlock()
+
+	for i, change := range changes {
+		needsApproval := s.config.Sync.RequireSchemaApproval || change.Destructive || change.Narrowing
+		changes[i].RequiresApproval = needsApproval && !s.approvals[approvalKey(change.TableName, change.Statement)]
+	}
+	return changes
+}
+
+// holdTable mencatat tabel yang DDL-nya tertahan beserta alasannya, sync datanya
+// ikut ditahan karena schema backup belum sesuai master. Alasan kosong melepas tabel.
+func (s *SchemaService) holdTable(tableName, reason string) {
+	s.approvalMutex.Lock()
+	defer s.approvalMutex.Unlock()
+
+	if reason != "" {
+		s.heldTables[tableName] = reason
+	} else {
+		delete(s.heldTables, tableName)
+	}
+}
+
+// heldReason menjelaskan kenapa DDL tabel tertahan; laporan data check ikut
+// disertakan supaya terlihat data mana yang tidak muat
+func heldReason(changes []models.SchemaChange) string {
+	for _, change := range changes {
+		if change.RequiresApproval && change.Narrowing {
+			if change.DataCheck != nil {
+				return "narrowing change waiting for approval: " + change.DataCheck.Report
+			}
+			return fmt.Sprintf("%s change of column %s waiting for approval", change.TypeChange, change.Object)
+		}
+	}
+	return "schema changes waiting for approval"
+}
+
+// clearApprovals menghapus approval tabel setelah DDL-nya berhasil dijalankan
+func (s *SchemaService) clearApprovals(tableName string) {
+	s.approvalMutex.Lock()
+	defer s.approvalMutex.Unlock()
+
+	prefix := approvalKey(tableName, "")
+	for key := range s.approvals {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.approvals, key)
+		}
+	}
+}
+
+func changesBlocked(changes []models.SchemaChange) bool {
+	for _, change := range changes {
+		if change.RequiresApproval {
+			return true
+		}
+	}
+	return false
+}
+
+func approvalKey(tableName, statement string) string {
+	return tableName + "|" + statement
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"db-sync-scheduler/internal/models"
+)
+
+func TestMarkApprovals(t *testing.T) {
+	fits := &models.DataCheck{Verified: true, Report: "customers.city: all rows fit varchar(50)"}
+
+	tests := []struct {
+		name   string
+		change models.SchemaChange
+		want   bool
+	}{
+		{"widening", models.SchemaChange{Kind: "modify_column", TypeChange: TypeChangeWidening}, false},
+		{"add column", models.SchemaChange{Kind: "add_column"}, false},
+		{"narrowing with fitting data", models.SchemaChange{Kind: "modify_column", TypeChange: TypeChangeNarrowing, Narrowing: true, DataCheck: fits}, true},
+		{"narrowing without data check", models.SchemaChange{Kind: "modify_column", TypeChange: TypeChangeNarrowing, Narrowing: true}, true},
+		{"incompatible", models.SchemaChange{Kind: "modify_column", TypeChange: TypeChangeIncompatible, Narrowing: true}, true},
+		{"destructive", models.SchemaChange{Kind: "drop_column", Destructive: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMySQLSchemaService(t, nil)
+			tt.change.TableName = "customers"
+			tt.change.Statement = "ALTER TABLE `customers` " + tt.name
+
+			got := s.markApprovals([]models.SchemaChange{tt.change})[0].RequiresApproval
+			if got != tt.want {
+				t.Errorf("RequiresApproval = %v, want %v", got, tt.want)
+			}
+
+			// Setelah disetujui, statement yang sama boleh dijalankan
+			s.approvals[approvalKey(tt.change.TableName, tt.change.Statement)] = true
+			if s.markApprovals([]models.SchemaChange{tt.change})[0].RequiresApproval {
+				t.Error("approved change still requires approval")
+			}
+		})
+	}
+}
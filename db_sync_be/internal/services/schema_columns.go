@@ -0,0 +1,474 @@
+package services
+
+import (
+	"crypto/md5"
+	"db-sync-scheduler/internal/models"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kebijakan untuk kolom backup yang sudah tidak ada di master
+const (
+	DroppedColumnKeep      = "keep"
+	DroppedColumnDrop      = "drop"
+	DroppedColumnDeprecate = "deprecate"
+)
+
+// Mode deteksi rename kolom
+const (
+	RenameDetectionOff     = "off"
+	RenameDetectionAuto    = "auto"
+	RenameDetectionConfirm = "confirm"
+)
+
+// deprecatedColumnPrefix dipakai kebijakan deprecate, kolom dengan prefix ini
+// tidak pernah dianggap kandidat rename atau di-deprecate ulang
+const deprecatedColumnPrefix = "_deprecated_"
+
+// deprecatedColumnName memberi prefix deprecate pada nama kolom. Jika hasilnya
+// melebihi batas 64 karakter MySQL, nama asli dipotong dan diberi hash pendek
+// supaya kolom panjang yang awalnya sama tidak bertabrakan
+func deprecatedColumnName(name string) string {
+	deprecated := deprecatedColumnPrefix + name
+	if len(deprecated) <= 64 {
+		return deprecated
+	}
+
+	sum := md5.Sum([]byte(name))
+	suffix := "_" + hex.EncodeToString(sum[:])[:8]
+	keep := []rune(name)
+	for len(deprecatedColumnPrefix)+len(string(keep))+len(suffix) > 64 {
+		keep = keep[:len(keep)-1]
+	}
+	return deprecatedColumnPrefix + string(keep) + suffix
+}
+
+// compareColumns menghasilkan DDL kolom dengan urutan: rename, kolom baru,
+// kolom berubah, kolom yang sudah hilang di master, lalu urutan kolom.
+// Pasangan rename yang masih menunggu konfirmasi tidak diubah sama sekali.
+func (s *SchemaService) compareColumns(tableName string, masterColumns, backupColumns []models.ColumnInfo) []models.SchemaChange {
+	masterColMap := make(map[string]models.ColumnInfo)
+	for _, col := range masterColumns {
+		masterColMap[col.ColumnName] = col
+	}
+
+	backupColMap := make(map[string]models.ColumnInfo)
+	for _, col := range backupColumns {
+		backupColMap[col.ColumnName] = col
+	}
+
+	renames, held := s.detectRenames(tableName, masterColumns, backupColumns)
+
+	var changes []models.SchemaChange
+
+	// order mensimulasikan urutan kolom backup setelah setiap DDL
+	order := make([]string, len(backupColumns))
+	for i, col := range backupColumns {
+		order[i] = col.ColumnName
+	}
+
+	for _, masterCol := range masterColumns {
+		oldName, ok := renames[masterCol.ColumnName]
+		if !ok {
+			continue
+		}
+		changes = append(changes, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "rename_column",
+			Object:    oldName,
+			NewName:   masterCol.ColumnName,
+			Statement: s.target.RenameColumnStatement(tableName, oldName, masterCol),
+		})
+		order[slices.Index(order, oldName)] = masterCol.ColumnName
+		backupColMap[masterCol.ColumnName] = backupColMap[oldName]
+		delete(backupColMap, oldName)
+	}
+
+	for _, masterCol := range masterColumns {
+		if held[masterCol.ColumnName] {
+			continue
+		}
+
+		backupCol, exists := backupColMap[masterCol.ColumnName]
+		if !exists {
+			// Kolom baru ditaruh setelah kolom master sebelumnya yang sudah ada di backup
+			after := previousColumn(masterColumns, masterCol.ColumnName, order)
+			changes = append(changes, models.SchemaChange{
+				TableName: tableName,
+				Kind:      "add_column",
+				Object:    masterCol.ColumnName,
+				Statement: s.target.AddColumnStatement(tableName, masterCol, after),
+			})
+			order = insertAfter(order, after, masterCol.ColumnName)
+			continue
+		}
+
+		if s.target.ColumnsDifferent(masterCol, backupCol) {
+			// Kolom sudah ada tapi berbeda, perlu dimodifikasi
+			alterStmt, ok := s.target.ModifyColumnStatement(tableName, masterCol)
+			if !ok {
+				log.Printf("Warning: column %s.%s differs but %s backup cannot modify columns",
+					tableName, masterCol.ColumnName, s.target.Name())
+				continue
+			}
+			change := models.SchemaChange{
+				TableName: tableName,
+				Kind:      "modify_column",
+				Object:    masterCol.ColumnName,
+				Statement: alterStmt,
+			}
+			typeChange, checks := s.classifyColumnChange(masterCol, backupCol)
+			change.TypeChange = typeChange
+			change.Narrowing = typeChange != TypeChangeWidening
+			if typeChange == TypeChangeNarrowing {
+				change.DataCheck = s.scanNarrowing(tableName, backupCol.ColumnName, checks)
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	for _, backupCol := range backupColumns {
+		if _, exists := masterColMap[backupCol.ColumnName]; exists || held[backupCol.ColumnName] {
+			continue
+		}
+		if _, stillExists := backupColMap[backupCol.ColumnName]; !stillExists {
+			// Sudah di-rename ke kolom master
+			continue
+		}
+
+		dropped, removed := s.droppedColumnChanges(tableName, backupCol)
+		changes = append(changes, dropped...)
+		if removed {
+			order = slices.DeleteFunc(order, func(name string) bool { return name == backupCol.ColumnName })
+		}
+	}
+
+	if s.config.Sync.KeepColumnOrder {
+		changes = append(changes, s.columnOrderChanges(tableName, masterColumns, order)...)
+	}
+
+	return changes
+}
+
+// droppedColumnChanges menerapkan DroppedColumnPolicy untuk kolom backup yang
+// sudah tidak ada di master. removed bernilai true jika kolom di-drop.
+func (s *SchemaService) droppedColumnChanges(tableName string, col models.ColumnInfo) (changes []models.SchemaChange, removed bool) {
+	if strings.HasPrefix(col.ColumnName, deprecatedColumnPrefix) {
+		return nil, false
+	}
+
+	switch s.config.Sync.DroppedColumnPolicy {
+	case DroppedColumnDrop:
+		log.Printf("Column %s.%s no longer exists on master, dropping it from backup", tableName, col.ColumnName)
+		return []models.SchemaChange{{
+			TableName:   tableName,
+			Kind:        "drop_column",
+			Object:      col.ColumnName,
+			Statement:   s.target.DropColumnStatement(tableName, col.ColumnName),
+			Destructive: true,
+		}}, true
+
+	case DroppedColumnDeprecate:
+		oldName := col.ColumnName
+		col.ColumnName = deprecatedColumnName(oldName)
+		log.Printf("Column %s.%s no longer exists on master, renaming it to %s", tableName, oldName, col.ColumnName)
+		changes = append(changes, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "rename_column",
+			Object:    oldName,
+			NewName:   col.ColumnName,
+			Statement: s.target.RenameColumnStatement(tableName, oldName, col),
+		})
+	}
+
+	// Insert dari master tidak mengisi kolom ini, NOT NULL tanpa default harus dilepas
+	if col.IsNullable == "NO" && col.ColumnDefault == nil && col.ColumnKey != "PRI" {
+		stmt, ok := s.target.DropNotNullStatement(tableName, col)
+		if !ok {
+			log.Printf("Warning: column %s.%s is NOT NULL without default and %s backup cannot relax it, inserts may fail",
+				tableName, col.ColumnName, s.target.Name())
+			return changes, false
+		}
+		changes = append(changes, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "modify_column",
+			Object:    col.ColumnName,
+			Statement: stmt,
+		})
+	}
+
+	return changes, false
+}
+
+// columnOrderChanges memindahkan kolom supaya urutannya sama dengan master.
+// Kolom yang sudah berurutan (longest increasing subsequence dari posisi master)
+// tidak disentuh, sisanya dipindah satu per satu mengikuti urutan master.
+func (s *SchemaService) columnOrderChanges(tableName string, masterColumns []models.ColumnInfo, order []string) []models.SchemaChange {
+	position := make(map[string]int)
+	for i, col := range masterColumns {
+		position[col.ColumnName] = i
+	}
+
+	// Posisi master dari kolom backup, kolom yang tidak ada di master diabaikan
+	var names []string
+	var positions []int
+	for _, name := range order {
+		if pos, ok := position[name]; ok {
+			names = append(names, name)
+			positions = append(positions, pos)
+		}
+	}
+
+	inPlace := make(map[string]bool)
+	for _, i := range longestIncreasing(positions) {
+		inPlace[names[i]] = true
+	}
+
+	var changes []models.SchemaChange
+	for _, col := range masterColumns {
+		if inPlace[col.ColumnName] || !slices.Contains(order, col.ColumnName) {
+			continue
+		}
+
+		after := previousColumn(masterColumns, col.ColumnName, order)
+		stmt, ok := s.target.MoveColumnStatement(tableName, col, after)
+		if !ok {
+			return nil
+		}
+
+		changes = append(changes, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "move_column",
+			Object:    col.ColumnName,
+			Statement: stmt,
+		})
+	}
+
+	return changes
+}
+
+// detectRenames memasangkan kolom master yang belum ada di backup dengan kolom
+// backup yang sudah hilang di master. Pasangan harus bertipe sama, lalu berada di
+// posisi yang sama atau menjadi satu-satunya kandidat bagi keduanya.
+// renames berisi nama baru -> nama lama yang boleh dijalankan; held berisi kolom
+// yang pasangannya masih menunggu konfirmasi.
+func (s *SchemaService) detectRenames(tableName string, masterColumns, backupColumns []models.ColumnInfo) (renames map[string]string, held map[string]bool) {
+	renames = make(map[string]string)
+	held = make(map[string]bool)
+
+	mode := s.config.Sync.RenameDetection
+	if mode != RenameDetectionAuto && mode != RenameDetectionConfirm {
+		return renames, held
+	}
+
+	inMaster := make(map[string]bool)
+	for _, col := range masterColumns {
+		inMaster[col.ColumnName] = true
+	}
+	inBackup := make(map[string]bool)
+	for _, col := range backupColumns {
+		inBackup[col.ColumnName] = true
+	}
+
+	type candidate struct {
+		master, backup int
+	}
+
+	var candidates []candidate
+	masterCount := make(map[int]int)
+	backupCount := make(map[int]int)
+	for i, masterCol := range masterColumns {
+		if inBackup[masterCol.ColumnName] {
+			continue
+		}
+		for j, backupCol := range backupColumns {
+			if inMaster[backupCol.ColumnName] || strings.HasPrefix(backupCol.ColumnName, deprecatedColumnPrefix) {
+				continue
+			}
+			if s.target.ColumnsDifferent(masterCol, backupCol) {
+				continue
+			}
+			candidates = append(candidates, candidate{i, j})
+			masterCount[i]++
+			backupCount[j]++
+		}
+	}
+
+	// Posisi yang sama didahulukan, baru pasangan yang tidak punya alternatif
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].master == candidates[a].backup && candidates[b].master != candidates[b].backup
+	})
+
+	pairedMaster := make(map[int]bool)
+	pairedBackup := make(map[int]bool)
+	var pairs []models.ColumnRename
+	for _, c := range candidates {
+		if pairedMaster[c.master] || pairedBackup[c.backup] {
+			continue
+		}
+		if c.master != c.backup && (masterCount[c.master] > 1 || backupCount[c.backup] > 1) {
+			continue
+		}
+		pairedMaster[c.master] = true
+		pairedBackup[c.backup] = true
+		pairs = append(pairs, models.ColumnRename{
+			TableName: tableName,
+			OldName:   backupColumns[c.backup].ColumnName,
+			NewName:   masterColumns[c.master].ColumnName,
+		})
+	}
+
+	if mode == RenameDetectionAuto {
+		for _, pair := range pairs {
+			log.Printf("Column %s.%s looks renamed to %s, renaming it in backup", tableName, pair.OldName, pair.NewName)
+			renames[pair.NewName] = pair.OldName
+		}
+		return renames, held
+	}
+
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	// Pasangan yang sudah tidak terdeteksi lagi tidak perlu ditunggu
+	previous := make(map[string]models.ColumnRename)
+	for key, pending := range s.pendingRenames {
+		if pending.TableName == tableName {
+			previous[key] = pending
+			delete(s.pendingRenames, key)
+		}
+	}
+
+	for _, pair := range pairs {
+		key := renameKey(pair.TableName, pair.OldName, pair.NewName)
+
+		accept, decided := s.renameDecisions[key]
+		if !decided {
+			if pending, ok := previous[key]; ok {
+				pair.DetectedAt = pending.DetectedAt
+			} else {
+				pair.DetectedAt = time.Now()
+				log.Printf("Column %s.%s looks renamed to %s, waiting for confirmation", tableName, pair.OldName, pair.NewName)
+			}
+			s.pendingRenames[key] = pair
+			held[pair.OldName] = true
+			held[pair.NewName] = true
+			continue
+		}
+
+		delete(s.renameDecisions, key)
+		if accept {
+			renames[pair.NewName] = pair.OldName
+		}
+	}
+
+	return renames, held
+}
+
+// PendingRenames mengembalikan rename kolom yang menunggu konfirmasi
+func (s *SchemaService) PendingRenames() []models.ColumnRename {
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	renames := make([]models.ColumnRename, 0, len(s.pendingRenames))
+	for _, pending := range s.pendingRenames {
+		renames = append(renames, pending)
+	}
+
+	sort.Slice(renames, func(i, j int) bool {
+		if renames[i].TableName != renames[j].TableName {
+			return renames[i].TableName < renames[j].TableName
+		}
+		return renames[i].OldName < renames[j].OldName
+	})
+
+	return renames
+}
+
+// HasPendingRenames mengembalikan true jika tabel punya rename yang belum dikonfirmasi
+func (s *SchemaService) HasPendingRenames(tableName string) bool {
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	for _, pending := range s.pendingRenames {
+		if pending.TableName == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveRename mencatat keputusan untuk rename yang menunggu konfirmasi.
+// accept menjalankan rename; reject memperlakukan kolom baru sebagai kolom
+// tambahan dan kolom lama sesuai DroppedColumnPolicy.
+func (s *SchemaService) ResolveRename(tableName, oldName, newName string, accept bool) error {
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	key := renameKey(tableName, oldName, newName)
+	if _, ok := s.pendingRenames[key]; !ok {
+		return fmt.Errorf("no pending rename %s.%s -> %s", tableName, oldName, newName)
+	}
+
+	delete(s.pendingRenames, key)
+	s.renameDecisions[key] = accept
+	return nil
+}
+
+func renameKey(tableName, oldName, newName string) string {
+	return tableName + "|" + oldName + "|" + newName
+}
+
+// previousColumn mencari kolom master sebelum column yang sudah ada di order,
+// kosong jika column harus menjadi kolom pertama
+func previousColumn(masterColumns []models.ColumnInfo, column string, order []string) string {
+	after := ""
+	for _, col := range masterColumns {
+		if col.ColumnName == column {
+			break
+		}
+		if slices.Contains(order, col.ColumnName) {
+			after = col.ColumnName
+		}
+	}
+	return after
+}
+
+// insertAfter menyisipkan name setelah after, atau di depan jika after kosong
+func insertAfter(order []string, after, name string) []string {
+	return slices.Insert(order, slices.Index(order, after)+1, name)
+}
+
+// longestIncreasing mengembalikan index elemen yang membentuk longest
+// increasing subsequence dari values
+func longestIncreasing(values []int) []int {
+	var tails []int // index elemen terakhir untuk setiap panjang subsequence
+	prev := make([]int, len(values))
+
+	for i, v := range values {
+		n := sort.Search(len(tails), func(k int) bool { return values[tails[k]] >= v })
+		prev[i] = -1
+		if n > 0 {
+			prev[i] = tails[n-1]
+		}
+		if n == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[n] = i
+		}
+	}
+
+	if len(tails) == 0 {
+		return nil
+	}
+
+	result := make([]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		result[i] = k
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/models"
+)
+
+func TestLongestIncreasing(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   []int
+	}{
+		{"empty", nil, nil},
+		{"already ordered", []int{0, 1, 2, 3}, []int{0, 1, 2, 3}},
+		{"one column moved to the front", []int{3, 0, 1, 2}, []int{1, 2, 3}},
+		{"one column moved to the end", []int{1, 2, 3, 0}, []int{0, 1, 2}},
+		{"reversed keeps the last element", []int{3, 2, 1, 0}, []int{3}},
+		{"two swapped", []int{0, 2, 1, 3}, []int{0, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longestIncreasing(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("longestIncreasing(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeprecatedColumnName(t *testing.T) {
+	long := strings.Repeat("a", 60)
+	tests := []struct {
+		name   string
+		column string
+	}{
+		{"short name", "state"},
+		{"exactly at the limit", strings.Repeat("b", 64-len(deprecatedColumnPrefix))},
+		{"long name", long + "_one"},
+		{"long name with same start", long + "_two"},
+		{"multibyte name", strings.Repeat("é", 40)},
+	}
+
+	seen := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := deprecatedColumnName(tt.column)
+			if len(got) > 64 {
+				t.Errorf("deprecatedColumnName(%q) = %q, %d bytes exceeds 64", tt.column, got, len(got))
+			}
+			if !strings.HasPrefix(got, deprecatedColumnPrefix) {
+				t.Errorf("deprecatedColumnName(%q) = %q, missing prefix", tt.column, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("deprecatedColumnName(%q) = %q, not valid UTF-8", tt.column, got)
+			}
+			if len(deprecatedColumnPrefix+tt.column) <= 64 && got != deprecatedColumnPrefix+tt.column {
+				t.Errorf("deprecatedColumnName(%q) = %q, want plain prefix", tt.column, got)
+			}
+			if other, ok := seen[got]; ok {
+				t.Errorf("%q and %q both deprecate to %q", other, tt.column, got)
+			}
+			seen[got] = tt.column
+		})
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	column := func(name, columnType string) models.ColumnInfo {
+		return models.ColumnInfo{ColumnName: name, DataType: columnType, ColumnType: columnType, IsNullable: "YES"}
+	}
+
+	tests := []struct {
+		name   string
+		master []models.ColumnInfo
+		backup []models.ColumnInfo
+		want   map[string]string
+	}{
+		{
+			name:   "same position and type",
+			master: []models.ColumnInfo{column("id", "int"), column("email_address", "varchar(100)")},
+			backup: []models.ColumnInfo{column("id", "int"), column("email", "varchar(100)")},
+			want:   map[string]string{"email_address": "email"},
+		},
+		{
+			name:   "type changed is not a rename",
+			master: []models.ColumnInfo{column("id", "int"), column("email_address", "varchar(255)")},
+			backup: []models.ColumnInfo{column("id", "int"), column("email", "varchar(100)")},
+			want:   map[string]string{},
+		},
+		{
+			name:   "only candidate at a different position",
+			master: []models.ColumnInfo{column("id", "int"), column("note", "text"), column("phone_number", "varchar(20)")},
+			backup: []models.ColumnInfo{column("id", "int"), column("phone", "varchar(20)")},
+			want:   map[string]string{"phone_number": "phone"},
+		},
+		{
+			name: "ambiguous candidates at different positions",
+			master: []models.ColumnInfo{
+				column("id", "int"), column("x", "int"), column("y", "int"),
+				column("first", "varchar(50)"), column("second", "varchar(50)"),
+			},
+			backup: []models.ColumnInfo{column("id", "int"), column("a", "varchar(50)"), column("b", "varchar(50)")},
+			want:   map[string]string{},
+		},
+		{
+			name: "same position wins over other candidates",
+			master: []models.ColumnInfo{
+				column("id", "int"), column("first_name", "varchar(50)"), column("last_name", "varchar(50)"),
+			},
+			backup: []models.ColumnInfo{column("id", "int"), column("fname", "varchar(50)"), column("lname", "varchar(50)")},
+			want:   map[string]string{"first_name": "fname", "last_name": "lname"},
+		},
+		{
+			name:   "deprecated backup column is never renamed",
+			master: []models.ColumnInfo{column("id", "int"), column("status", "int")},
+			backup: []models.ColumnInfo{column("id", "int"), column(deprecatedColumnPrefix+"state", "int")},
+			want:   map[string]string{},
+		},
+	}
+
+	cfg := &config.AppConfig{}
+	cfg.Sync.RenameDetection = RenameDetectionAuto
+	s := newMySQLSchemaService(t, cfg)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renames, held := s.detectRenames("customers", tt.master, tt.backup)
+			if !reflect.DeepEqual(renames, tt.want) {
+				t.Errorf("renames = %v, want %v", renames, tt.want)
+			}
+			if len(held) != 0 {
+				t.Errorf("auto mode held %v", held)
+			}
+		})
+	}
+}
+
+func TestDetectRenamesConfirm(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Sync.RenameDetection = RenameDetectionConfirm
+	s := newMySQLSchemaService(t, cfg)
+
+	master := []models.ColumnInfo{{ColumnName: "id", ColumnType: "int"}, {ColumnName: "email_address", ColumnType: "varchar(100)"}}
+	backup := []models.ColumnInfo{{ColumnName: "id", ColumnType: "int"}, {ColumnName: "email", ColumnType: "varchar(100)"}}
+
+	renames, held := s.detectRenames("customers", master, backup)
+	if len(renames) != 0 || !held["email"] || !held["email_address"] {
+		t.Fatalf("before confirmation: renames %v, held %v", renames, held)
+	}
+
+	s.renameDecisions[renameKey("customers", "email", "email_address")] = true
+	renames, held = s.detectRenames("customers", master, backup)
+	if renames["email_address"] != "email" || len(held) != 0 {
+		t.Fatalf("after confirmation: renames %v, held %v", renames, held)
+	}
+}
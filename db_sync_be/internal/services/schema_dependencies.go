@@ -0,0 +1,187 @@
+package services
+
+import (
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Format keluaran dependency graph selain JSON
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+)
+
+// DependencyGraph mengembalikan tabel master beserta level, FK dan siklusnya
+// sesuai urutan yang dipakai sync
+func (s *SchemaService) DependencyGraph() (models.DependencyGraph, error) {
+	graph := models.DependencyGraph{
+		Edges:  []models.ForeignKeyConstraint{},
+		Levels: [][]string{},
+		Cycles: [][]string{},
+	}
+
+	nodes, err := s.GetAllTablesWithDependencies()
+	if err != nil {
+		return graph, err
+	}
+	graph.Nodes = nodes
+
+	for _, node := range nodes {
+		for len(graph.Levels) <= node.Level {
+			graph.Levels = append(graph.Levels, []string{})
+		}
+		graph.Levels[node.Level] = append(graph.Levels[node.Level], node.TableName)
+
+		// Anggota siklus berurutan di Nodes, siklus cukup dicatat dari anggota pertamanya
+		if len(node.Cycle) > 0 && node.Cycle[0] == node.TableName {
+			graph.Cycles = append(graph.Cycles, node.Cycle)
+		}
+
+		fks, err := s.GetForeignKeys(node.TableName)
+		if err != nil {
+			log.Printf("Warning: failed to get foreign keys for table %s: %v", node.TableName, err)
+			continue
+		}
+		graph.Edges = append(graph.Edges, dialect.GroupForeignKeys(fks)...)
+	}
+
+	return graph, nil
+}
+
+// RenderDependencyGraph menulis graph dalam format Graphviz DOT atau Mermaid.
+// Tabel dikelompokkan per level; FK di dalam siklus diberi warna merah dan
+// self-reference digambar putus-putus.
+func RenderDependencyGraph(graph models.DependencyGraph, format string) (string, error) {
+	switch format {
+	case GraphFormatDOT:
+		return renderDOT(graph), nil
+	case GraphFormatMermaid:
+		return renderMermaid(graph), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format: %s", format)
+	}
+}
+
+func renderDOT(graph models.DependencyGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=RL;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for level, tables := range graph.Levels {
+		fmt.Fprintf(&b, "  subgraph cluster_level_%d {\n", level)
+		fmt.Fprintf(&b, "    label=\"Level %d\";\n", level)
+		for _, table := range tables {
+			fmt.Fprintf(&b, "    %s;\n", dotQuote(table))
+		}
+		b.WriteString("  }\n")
+	}
+
+	cycleOf := cycleMembership(graph)
+	for _, fk := range graph.Edges {
+		attrs := []string{"label=" + dotQuote(edgeLabel(fk))}
+		switch {
+		case fk.TableName == fk.ReferencedTableName:
+			attrs = append(attrs, "style=dashed")
+		case inSameCycle(cycleOf, fk):
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(fk.TableName), dotQuote(fk.ReferencedTableName), strings.Join(attrs, ", "))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderMermaid(graph models.DependencyGraph) string {
+	var b strings.Builder
+	b.WriteString("flowchart RL\n")
+
+	// Nama tabel bisa berisi karakter yang tidak valid sebagai id Mermaid
+	ids := make(map[string]string)
+	for i, node := range graph.Nodes {
+		ids[node.TableName] = fmt.Sprintf("t%d", i)
+	}
+
+	for level, tables := range graph.Levels {
+		fmt.Fprintf(&b, "  subgraph level_%d [\"Level %d\"]\n", level, level)
+		for _, table := range tables {
+			fmt.Fprintf(&b, "    %s[%s]\n", ids[table], mermaidQuote(table))
+		}
+		b.WriteString("  end\n")
+	}
+
+	cycleOf := cycleMembership(graph)
+	var cycleLinks, selfLinks []string
+	link := 0
+	for _, fk := range graph.Edges {
+		from, fromOK := ids[fk.TableName]
+		to, toOK := ids[fk.ReferencedTableName]
+		if !fromOK || !toOK {
+			continue
+		}
+
+		arrow := "-->"
+		if fk.TableName == fk.ReferencedTableName {
+			arrow = "-.->"
+			selfLinks = append(selfLinks, fmt.Sprint(link))
+		} else if inSameCycle(cycleOf, fk) {
+			cycleLinks = append(cycleLinks, fmt.Sprint(link))
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", from, arrow, mermaidQuote(edgeLabel(fk)), to)
+		link++
+	}
+
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#d33,color:#d33\n", strings.Join(cycleLinks, ","))
+	}
+	if len(selfLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#888\n", strings.Join(selfLinks, ","))
+	}
+
+	var cycleNodes []string
+	for _, node := range graph.Nodes {
+		if len(node.Cycle) > 0 {
+			cycleNodes = append(cycleNodes, ids[node.TableName])
+		}
+	}
+	if len(cycleNodes) > 0 {
+		b.WriteString("  classDef cycle stroke:#d33,stroke-width:2px\n")
+		fmt.Fprintf(&b, "  class %s cycle\n", strings.Join(cycleNodes, ","))
+	}
+
+	return b.String()
+}
+
+// edgeLabel berisi nama constraint dan pasangan kolomnya, mis. fk_order (customer_id -> id)
+func edgeLabel(fk models.ForeignKeyConstraint) string {
+	return fmt.Sprintf("%s (%s -> %s)", fk.ConstraintName,
+		strings.Join(fk.Columns, ", "), strings.Join(fk.ReferencedColumns, ", "))
+}
+
+func cycleMembership(graph models.DependencyGraph) map[string]int {
+	cycleOf := make(map[string]int)
+	for i, cycle := range graph.Cycles {
+		for _, table := range cycle {
+			cycleOf[table] = i
+		}
+	}
+	return cycleOf
+}
+
+func inSameCycle(cycleOf map[string]int, fk models.ForeignKeyConstraint) bool {
+	i, ok := cycleOf[fk.TableName]
+	j, refOK := cycleOf[fk.ReferencedTableName]
+	return ok && refOK && i == j
+}
+
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func mermaidQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "#quot;") + `"`
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// desiredMarkerTable menandai database scratch milik db_sync; database yang
+// berisi tabel lain tanpa penanda ini tidak pernah dikosongkan
+const desiredMarkerTable = internalTablePrefix + "desired"
+
+// NewDesiredSchemaService membuat SchemaService yang menyamakan backup dengan
+// desired schema di scratchDB, bukan dengan master. scratchDB memakai dialect
+// backup dan diisi LoadDesiredSchema. View, routine, trigger dan event backup
+// tidak disentuh, FK langsung dibuat bersama tabelnya.
+func NewDesiredSchemaService(scratchDB, backupDB *sql.DB, target dialect.Dialect, history *SchemaHistoryService, cfg *config.AppConfig) *SchemaService {
+	s := NewSchemaService(scratchDB, backupDB, target, target, history, cfg)
+	s.desired = true
+	s.fkDeferred = false
+	return s
+}
+
+// LoadDesiredSchema mengosongkan database scratch lalu menjalankan semua file
+// .sql di dir (urut nama file). Statement yang gagal, mis. FK ke tabel di file
+// berikutnya, dicoba lagi setelah statement lain selesai.
+func (s *SchemaService) LoadDesiredSchema(dir string) error {
+	if !s.desired {
+		return fmt.Errorf("schema service does not use a desired schema")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list desired schema files: %v", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no .sql files found in %s", dir)
+	}
+	slices.Sort(files)
+
+	var stmts []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		stmts = append(stmts, splitStatements(string(data))...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaStatementTimeout)
+	defer cancel()
+
+	tables, err := s.source.ListTables(ctx, s.masterDB)
+	if err != nil {
+		return fmt.Errorf("failed to list desired schema tables: %v", err)
+	}
+	if len(tables) > 0 && !slices.Contains(tables, desiredMarkerTable) {
+		return fmt.Errorf("desired schema database is not empty and was not created by db_sync")
+	}
+
+	var drops []string
+	for _, table := range tables {
+		drops = append(drops, "DROP TABLE "+s.source.QuoteIdentifier(table))
+	}
+
+	marker := s.source.CreateTableStatement(desiredMarkerTable, []models.ColumnInfo{
+		dialect.InternalColumn("loaded_at", "datetime", "datetime", true, false),
+	}, "")
+
+	// Pengecekan FK berlaku per sesi, semua statement dijalankan di satu koneksi
+	conn, err := s.masterDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to desired schema database: %v", err)
+	}
+	defer conn.Close()
+
+	disable, enable := s.source.ForeignKeyChecksStatements()
+	if disable != "" {
+		if _, err := conn.ExecContext(ctx, disable); err != nil {
+			log.Printf("Warning: failed to disable foreign key checks on desired schema database: %v", err)
+		} else {
+			defer conn.ExecContext(context.Background(), enable)
+		}
+	}
+
+	if err := execUntilSettled(ctx, conn, drops); err != nil {
+		return fmt.Errorf("failed to clear desired schema database: %v", err)
+	}
+	if err := execUntilSettled(ctx, conn, append(stmts, marker)); err != nil {
+		return fmt.Errorf("failed to load desired schema: %v", err)
+	}
+
+	log.Printf("Loaded desired schema from %d files in %s", len(files), dir)
+	return nil
+}
+
+// execUntilSettled menjalankan statement berulang kali; yang gagal dicoba lagi
+// selama putaran sebelumnya masih ada statement yang berhasil
+func execUntilSettled(ctx context.Context, conn *sql.Conn, stmts []string) error {
+	pending := stmts
+	for len(pending) > 0 {
+		var failed []string
+		var firstErr error
+		for _, stmt := range pending {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				failed = append(failed, stmt)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %v", stmt, err)
+				}
+			}
+		}
+
+		if len(failed) == len(pending) {
+			return firstErr
+		}
+		pending = failed
+	}
+	return nil
+}
+
+// splitStatements memecah isi file SQL per titik koma, kecuali titik koma di
+// dalam string, identifier ber-quote dan komentar. Komentar dibuang supaya file
+// yang hanya berisi komentar tidak menjadi statement kosong.
+func splitStatements(content string) []string {
+	var stmts []string
+	var current strings.Builder
+	var quote byte
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(content) {
+				i++
+				current.WriteByte(content[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(content[i:], "--"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				end = len(content) - i - 2
+			}
+			// Komentar versi MySQL (/*!50100 PARTITION BY ... */) adalah bagian dari DDL
+			if strings.HasPrefix(content[i:], "/*!") {
+				current.WriteString(content[i:min(i+end+4, len(content))])
+			}
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return stmts
+}
+
+// DumpSchema menulis definisi setiap tabel master ke dir, satu file <tabel>.sql
+// dalam dialect backup, format yang dibaca LoadDesiredSchema
+func (s *SchemaService) DumpSchema(dir string) (int, error) {
+	tables, err := s.GetAllTables()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	for _, table := range tables {
+		stmts, err := s.tableDefinition(table)
+		if err != nil {
+			return 0, fmt.Errorf("failed to dump table %s: %v", table, err)
+		}
+
+		content := strings.Join(stmts, ";\n\n") + ";\n"
+		if err := os.WriteFile(filepath.Join(dir, table+".sql"), []byte(content), 0o644); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %v", table, err)
+		}
+	}
+
+	return len(tables), nil
+}
+
+// tableDefinition membuat DDL lengkap satu tabel master untuk backup
+func (s *SchemaService) tableDefinition(tableName string) ([]string, error) {
+	return s.definitionStatements(s.masterDB, s.source, tableName)
+}
+
+// definitionStatements membuat DDL lengkap satu tabel di db (dialect from) untuk
+// backup. DDL asli dipakai jika dialect sama (tanpa AUTO_INCREMENT berjalan) dan
+// sudah berisi FK; index yang tidak ada di DDL (mis. SQLite) ditambahkan sebagai
+// CREATE INDEX.
+func (s *SchemaService) definitionStatements(db *sql.DB, from dialect.Dialect, tableName string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var createStmt string
+	if from.Name() == s.target.Name() {
+		sourceCreate, err := from.ShowCreateTable(ctx, db, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get create statement: %v", err)
+		}
+		createStmt = autoIncrementPattern.ReplaceAllString(sourceCreate, "")
+	}
+
+	original := createStmt != ""
+	if !original {
+		columns, err := from.GetColumns(ctx, db, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get table schema: %v", err)
+		}
+		createStmt = s.target.CreateTableStatement(tableName, columns, "")
+	}
+	stmts := []string{createStmt}
+
+	indexes, err := from.GetIndexes(ctx, db, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indexes: %v", err)
+	}
+	for _, sourceIdx := range indexes {
+		idx, ok := s.target.AdaptIndex(sourceIdx)
+		if !ok {
+			log.Printf("Warning: index %s.%s (%s) is not supported by %s backup, skipping",
+				tableName, sourceIdx.IndexName, sourceIdx.Kind, s.target.Name())
+			continue
+		}
+		if !s.definedInCreate(createStmt, "KEY", idx.IndexName) {
+			stmts = append(stmts, s.target.CreateIndexStatement(tableName, idx))
+		}
+	}
+
+	if original {
+		return stmts, nil
+	}
+
+	fks, err := from.GetForeignKeys(ctx, db, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %v", err)
+	}
+	for _, fk := range dialect.GroupForeignKeys(fks) {
+		stmt, ok := s.target.AddForeignKeyStatement(tableName, fk)
+		if !ok {
+			log.Printf("Warning: foreign key %s.%s cannot be added by %s backup, skipping",
+				tableName, fk.ConstraintName, s.target.Name())
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "two statements",
+			content: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:    []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:    "missing trailing semicolon",
+			content: "CREATE TABLE a (id INT)",
+			want:    []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name:    "comments only",
+			content: "-- nothing here;\n/* still; nothing */\n",
+		},
+		{
+			name:    "line comment with semicolon is dropped",
+			content: "CREATE TABLE a ( -- first; column\n  id INT\n);",
+			want:    []string{"CREATE TABLE a ( \n  id INT\n)"},
+		},
+		{
+			name:    "block comment is dropped",
+			content: "CREATE TABLE /* legacy; name */ a (id INT);",
+			want:    []string{"CREATE TABLE  a (id INT)"},
+		},
+		{
+			name:    "semicolon inside string default",
+			content: "CREATE TABLE a (note VARCHAR(10) DEFAULT 'a;b');",
+			want:    []string{"CREATE TABLE a (note VARCHAR(10) DEFAULT 'a;b')"},
+		},
+		{
+			name:    "escaped and doubled quotes",
+			content: `CREATE TABLE a (x VARCHAR(9) DEFAULT 'it\'s;', y VARCHAR(9) DEFAULT 'it''s;');`,
+			want:    []string{`CREATE TABLE a (x VARCHAR(9) DEFAULT 'it\'s;', y VARCHAR(9) DEFAULT 'it''s;')`},
+		},
+		{
+			name:    "comment markers inside quoted identifier",
+			content: "CREATE TABLE `a--b;c` (`/*x*/` INT);",
+			want:    []string{"CREATE TABLE `a--b;c` (`/*x*/` INT)"},
+		},
+		{
+			name:    "mysql versioned comment is kept",
+			content: "CREATE TABLE logs (id INT, created DATE)\n/*!50100 PARTITION BY RANGE (YEAR(created)) (PARTITION p0 VALUES LESS THAN (2024)) */;",
+			want:    []string{"CREATE TABLE logs (id INT, created DATE)\n/*!50100 PARTITION BY RANGE (YEAR(created)) (PARTITION p0 VALUES LESS THAN (2024)) */"},
+		},
+		{
+			name:    "unterminated block comment",
+			content: "CREATE TABLE a (id INT); /* trailing",
+			want:    []string{"CREATE TABLE a (id INT)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements()\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ForeignKeysDeferred mengembalikan true selama pembuatan FK di backup ditunda
+// sampai initial load selesai
+func (s *SchemaService) ForeignKeysDeferred() bool {
+	s.fkMutex.Lock()
+	defer s.fkMutex.Unlock()
+	return s.fkDeferred
+}
+
+// canAddForeignKeys mengecek apakah dialect bisa menambah FK ke tabel yang sudah
+// ada. SQLite hanya bisa membuat FK bersama CREATE TABLE, sehingga FK yang ditunda
+// tidak akan pernah dibuat.
+func canAddForeignKeys(d dialect.Dialect) bool {
+	_, ok := d.AddForeignKeyStatement("", models.ForeignKeyConstraint{})
+	return ok
+}
+
+// CreateDeferredForeignKeys membuat FK yang ditunda setelah data selesai dimuat.
+// Setiap FK divalidasi dulu; FK dengan baris yatim dilewati dan dilaporkan.
+func (s *SchemaService) CreateDeferredForeignKeys() error {
+	s.fkMutex.Lock()
+	s.fkDeferred = false
+	s.fkMutex.Unlock()
+
+	log.Println("Initial load finished, creating deferred foreign keys...")
+
+	s.beginRun()
+	defer s.endRun()
+
+	tableDeps, err := s.GetAllTablesWithDependencies()
+	if err != nil {
+		return err
+	}
+
+	created := 0
+	for _, dep := range tableDeps {
+		exists, err := s.TableExists(dep.TableName)
+		if err != nil || !exists {
+			continue
+		}
+
+		drops, adds, err := s.compareForeignKeys(dep.TableName, true)
+		if err != nil {
+			log.Printf("Error comparing foreign keys for %s: %v", dep.TableName, err)
+			continue
+		}
+
+		// Dengan approval, FK menunggu disetujui lewat schema diff seperti DDL lainnya
+		changes := s.markApprovals(append(drops, adds...))
+		if changesBlocked(changes) {
+			log.Printf("Foreign keys for %s are waiting for approval", dep.TableName)
+			continue
+		}
+
+		if err := s.applyChanges(changes); err != nil {
+			log.Printf("Error creating foreign keys for %s: %v", dep.TableName, err)
+			continue
+		}
+		created += len(adds)
+	}
+
+	log.Printf("Deferred foreign keys created: %d", created)
+	return nil
+}
+
+// compareForeignKeys membandingkan FK master dan backup berdasarkan definisinya.
+// FK baru hanya dibuat jika tabel referensinya sudah ada di backup dan tidak ada
+// baris yatim, supaya ALTER TABLE tidak gagal di tengah schema sync. tableExists
+// false berarti tabel backup baru akan dibuat, jadi tidak ada baris yang dicek.
+func (s *SchemaService) compareForeignKeys(tableName string, tableExists bool) (drops, adds []models.SchemaChange, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	masterFKs, err := s.source.GetForeignKeys(ctx, s.masterDB, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get master foreign keys: %v", err)
+	}
+
+	backupFKs, err := s.target.GetForeignKeys(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get backup foreign keys: %v", err)
+	}
+
+	unmatched := make(map[string][]models.ForeignKeyConstraint)
+	for _, fk := range dialect.GroupForeignKeys(backupFKs) {
+		sig := foreignKeySignature(fk)
+		unmatched[sig] = append(unmatched[sig], fk)
+	}
+
+	deferred := s.ForeignKeysDeferred()
+
+	for _, fk := range dialect.GroupForeignKeys(masterFKs) {
+		sig := foreignKeySignature(fk)
+		if matches := unmatched[sig]; len(matches) > 0 {
+			unmatched[sig] = matches[1:]
+			continue
+		}
+
+		if deferred {
+			continue
+		}
+
+		// SQLite tidak bisa menambah FK setelah tabel dibuat
+		stmt, ok := s.target.AddForeignKeyStatement(tableName, fk)
+		if !ok {
+			continue
+		}
+
+		// Referensi ke tabel yang belum dibuat (mis. siklus FK) ditambahkan di run berikutnya
+		if fk.ReferencedTableName != tableName {
+			exists, err := s.target.TableExists(ctx, s.backupDB, fk.ReferencedTableName)
+			if err != nil || !exists {
+				continue
+			}
+		}
+
+		if tableExists {
+			orphans, err := countOrphanRows(ctx, s.backupDB, s.target, fk)
+			if err != nil {
+				log.Printf("Warning: failed to validate foreign key %s.%s: %v", tableName, fk.ConstraintName, err)
+				continue
+			}
+			if orphans > 0 {
+				log.Printf("Warning: skipping foreign key %s.%s, %d backup rows have no matching %s row",
+					tableName, fk.ConstraintName, orphans, fk.ReferencedTableName)
+				continue
+			}
+		}
+
+		adds = append(adds, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "add_foreign_key",
+			Object:    fk.ConstraintName,
+			Statement: stmt,
+		})
+	}
+
+	for _, fk := range dialect.GroupForeignKeys(backupFKs) {
+		if !containsForeignKey(unmatched[foreignKeySignature(fk)], fk.ConstraintName) {
+			continue
+		}
+
+		stmt, ok := s.target.DropForeignKeyStatement(tableName, fk)
+		if !ok {
+			continue
+		}
+
+		drops = append(drops, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "drop_foreign_key",
+			Object:    fk.ConstraintName,
+			Statement: stmt,
+		})
+	}
+
+	return drops, adds, nil
+}
+
+// foreignKeySignature menyatakan definisi FK sebagai string untuk dibandingkan.
+// RESTRICT dan NO ACTION dianggap sama karena keduanya default dan berperilaku sama.
+func foreignKeySignature(fk models.ForeignKeyConstraint) string {
+	rule := func(r string) string {
+		r = strings.ToUpper(r)
+		if r == "" || r == "RESTRICT" {
+			return "NO ACTION"
+		}
+		return r
+	}
+
+	return fmt.Sprintf("%s->%s(%s):%s:%s",
+		strings.ToLower(strings.Join(fk.Columns, ",")),
+		fk.ReferencedTableName,
+		strings.ToLower(strings.Join(fk.ReferencedColumns, ",")),
+		rule(fk.UpdateRule), rule(fk.DeleteRule))
+}
+
+func containsForeignKey(fks []models.ForeignKeyConstraint, name string) bool {
+	for _, fk := range fks {
+		if fk.ConstraintName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// countOrphanRows menghitung baris yang nilai FK-nya tidak punya pasangan di tabel referensi
+func countOrphanRows(ctx context.Context, db *sql.DB, d dialect.Dialect, fk models.ForeignKeyConstraint) (int, error) {
+	q := d.QuoteIdentifier
+
+	var notNull, match []string
+	for i, col := range fk.Columns {
+		notNull = append(notNull, fmt.Sprintf("c.%s IS NOT NULL", q(col)))
+		match = append(match, fmt.Sprintf("p.%s = c.%s", q(fk.ReferencedColumns[i]), q(col)))
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s c WHERE %s AND NOT EXISTS (SELECT 1 FROM %s p WHERE %s)",
+		q(fk.TableName), strings.Join(notNull, " AND "),
+		q(fk.ReferencedTableName), strings.Join(match, " AND "))
+
+	var count int
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
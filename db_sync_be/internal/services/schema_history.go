@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const schemaHistoryTable = "_db_sync_schema_history"
+
+// SchemaHistoryService mencatat setiap DDL yang dijalankan db_sync di backup
+// beserta definisi tabel sebelum dan sesudahnya
+type SchemaHistoryService struct {
+	backupDB *sql.DB
+	target   dialect.Dialect
+	mutex    sync.Mutex
+	ready    bool
+}
+
+func NewSchemaHistoryService(backupDB *sql.DB, target dialect.Dialect) *SchemaHistoryService {
+	return &SchemaHistoryService{
+		backupDB: backupDB,
+		target:   target,
+	}
+}
+
+// schemaHistoryColumns adalah struktur tabel schema history, dirender oleh target backup
+var schemaHistoryColumns = []models.ColumnInfo{
+	dialect.InternalColumn("run_id", "varchar", "varchar(64)", true, true),
+	dialect.InternalColumn("seq", "int", "int", true, true),
+	dialect.InternalColumn("table_name", "varchar", "varchar(64)", true, false),
+	dialect.InternalColumn("kind", "varchar", "varchar(32)", true, false),
+	dialect.InternalColumn("statement", "longtext", "longtext", false, false),
+	dialect.InternalColumn("before_definition", "longtext", "longtext", false, false),
+	dialect.InternalColumn("after_definition", "longtext", "longtext", false, false),
+	dialect.InternalColumn("executed_at", "datetime", "datetime", true, false),
+	dialect.InternalColumn("duration_ms", "bigint", "bigint", true, false),
+	dialect.InternalColumn("outcome", "varchar", "varchar(16)", true, false),
+	dialect.InternalColumn("error_message", "text", "text", false, false),
+}
+
+// ensureTable membuat tabel schema history di backup database jika belum ada
+func (h *SchemaHistoryService) ensureTable() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.ready {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := h.target.TableExists(ctx, h.backupDB, schemaHistoryTable)
+	if err != nil {
+		return fmt.Errorf("failed to check schema history table: %v", err)
+	}
+
+	if !exists {
+		query := h.target.CreateTableStatement(schemaHistoryTable, schemaHistoryColumns, "")
+		if _, err := h.backupDB.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create schema history table: %v", err)
+		}
+	}
+
+	h.ready = true
+	return nil
+}
+
+// Record menyimpan satu entri schema history
+func (h *SchemaHistoryService) Record(entry models.SchemaHistoryEntry) error {
+	if err := h.ensureTable(); err != nil {
+		return err
+	}
+
+	before, err := encodeDefinition(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encodeDefinition(entry.After)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s
+	            (run_id, seq, table_name, kind, statement, before_definition, after_definition,
+	             executed_at, duration_ms, outcome, error_message)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, schemaHistoryTable)
+
+	_, err = h.backupDB.ExecContext(ctx, h.target.Rebind(query),
+		entry.RunID, entry.Seq, entry.TableName, entry.Kind, nullString(entry.Statement), before, after,
+		entry.ExecutedAt, entry.DurationMs, entry.Outcome, nullString(entry.ErrorMessage))
+	if err != nil {
+		return fmt.Errorf("failed to record schema history: %v", err)
+	}
+	return nil
+}
+
+// List mengembalikan entri terbaru lebih dulu, opsional difilter per tabel
+func (h *SchemaHistoryService) List(tableName string, limit int) ([]models.SchemaHistoryEntry, error) {
+	if err := h.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT run_id, seq, table_name, kind, statement, before_definition, after_definition,
+	                 executed_at, duration_ms, outcome, error_message
+	          FROM %s`, schemaHistoryTable)
+	var args []interface{}
+	if tableName != "" {
+		query += " WHERE table_name = ?"
+		args = append(args, tableName)
+	}
+	query += fmt.Sprintf(" ORDER BY executed_at DESC, run_id DESC, seq DESC LIMIT %d", limit)
+
+	rows, err := h.backupDB.QueryContext(ctx, h.target.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema history: %v", err)
+	}
+	defer rows.Close()
+
+	result := []models.SchemaHistoryEntry{}
+	for rows.Next() {
+		var entry models.SchemaHistoryEntry
+		var stmt, before, after, errMsg sql.NullString
+		err := rows.Scan(
+			&entry.RunID,
+			&entry.Seq,
+			&entry.TableName,
+			&entry.Kind,
+			&stmt,
+			&before,
+			&after,
+			&entry.ExecutedAt,
+			&entry.DurationMs,
+			&entry.Outcome,
+			&errMsg,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Statement = stmt.String
+		entry.ErrorMessage = errMsg.String
+		if entry.Before, err = decodeDefinition(before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = decodeDefinition(after); err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+
+	return result, rows.Err()
+}
+
+// LatestDefinitions mengembalikan definisi terakhir yang tercatat per tabel
+// (JSON apa adanya), dipakai untuk mendeteksi drift setelah restart
+func (h *SchemaHistoryService) LatestDefinitions() (map[string]string, error) {
+	if err := h.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT table_name, after_definition
+	          FROM %s
+	          WHERE after_definition IS NOT NULL
+	          ORDER BY executed_at, run_id, seq`, schemaHistoryTable)
+
+	rows, err := h.backupDB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema history definitions: %v", err)
+	}
+	defer rows.Close()
+
+	definitions := make(map[string]string)
+	for rows.Next() {
+		var tableName, definition string
+		if err := rows.Scan(&tableName, &definition); err != nil {
+			return nil, err
+		}
+		definitions[tableName] = definition
+	}
+
+	return definitions, rows.Err()
+}
+
+func encodeDefinition(def *models.TableDefinition) (interface{}, error) {
+	if def == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode table definition: %v", err)
+	}
+	return string(data), nil
+}
+
+func decodeDefinition(data sql.NullString) (*models.TableDefinition, error) {
+	if !data.Valid || data.String == "" {
+		return nil, nil
+	}
+	var def models.TableDefinition
+	if err := json.Unmarshal([]byte(data.String), &def); err != nil {
+		return nil, fmt.Errorf("failed to decode table definition: %v", err)
+	}
+	return &def, nil
+}
+
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// beginRun memulai satu sinkronisasi schema. DDL di backup dijalankan satu run
+// pada satu waktu, semua entri history dalam run memakai run ID yang sama.
+func (s *SchemaService) beginRun() {
+	s.runMutex.Lock()
+	s.runID = time.Now().UTC().Format("20060102T150405.000000Z")
+	s.runSeq = 0
+}
+
+func (s *SchemaService) endRun() {
+	s.runID = ""
+	s.runMutex.Unlock()
+}
+
+// backupDefinition membaca kolom dan index tabel backup, nil jika tabel belum
+// ada atau tidak bisa dibaca
+func (s *SchemaService) backupDefinition(tableName string) *models.TableDefinition {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	exists, err := s.target.TableExists(ctx, s.backupDB, tableName)
+	if err != nil || !exists {
+		return nil
+	}
+
+	columns, err := s.target.GetColumns(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil
+	}
+	indexes, err := s.target.GetIndexes(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil
+	}
+
+	return &models.TableDefinition{Columns: columns, Indexes: indexes}
+}
+
+// recordHistory menulis entri ke schema history dan memperbarui definisi
+// terakhir yang diketahui. Gagal mencatat history tidak menggagalkan DDL.
+func (s *SchemaService) recordHistory(entry models.SchemaHistoryEntry) {
+	if entry.After != nil {
+		if data, err := json.Marshal(entry.After); err == nil {
+			s.knownDefinitions[entry.TableName] = string(data)
+		}
+	} else if entry.Kind == models.SchemaHistoryDDL {
+		delete(s.knownDefinitions, entry.TableName)
+	}
+
+	if s.history == nil {
+		return
+	}
+
+	s.runSeq++
+	entry.RunID = s.runID
+	entry.Seq = s.runSeq
+	if err := s.history.Record(entry); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// checkDrift membandingkan definisi tabel backup dengan definisi terakhir yang
+// diketahui db_sync. Perbedaan berarti tabel diubah langsung di backup dan
+// dicatat sebagai drift; tabel yang belum pernah tercatat dicatat sebagai baseline.
+func (s *SchemaService) checkDrift(tableName string) {
+	if !s.knownLoaded && s.history != nil {
+		definitions, err := s.history.LatestDefinitions()
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			return
+		}
+		s.knownDefinitions = definitions
+		s.knownLoaded = true
+	}
+
+	current := s.backupDefinition(tableName)
+	if current == nil {
+		return
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		return
+	}
+
+	known, exists := s.knownDefinitions[tableName]
+	if exists && known == string(data) {
+		return
+	}
+
+	entry := models.SchemaHistoryEntry{
+		TableName:  tableName,
+		Kind:       models.SchemaHistoryBaseline,
+		After:      current,
+		ExecutedAt: time.Now(),
+		Outcome:    models.SchemaOutcomeDetected,
+	}
+	if exists {
+		log.Printf("Warning: schema of backup table %s was changed outside db_sync", tableName)
+		entry.Kind = models.SchemaHistoryDrift
+		entry.Before, _ = decodeDefinition(sql.NullString{String: known, Valid: true})
+	}
+	s.recordHistory(entry)
+}
+
+// execHistoryDDL menjalankan DDL dan mencatatnya di schema history. Definisi
+// sebelum dan sesudah hanya dibaca untuk DDL tabel, bukan view atau routine.
+func (s *SchemaService) execHistoryDDL(tableName, kind, stmt string) error {
+	var before *models.TableDefinition
+	if kind == models.SchemaHistoryDDL {
+		before = s.backupDefinition(tableName)
+	}
+	started := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaStatementTimeout)
+	defer cancel()
+
+	log.Printf("  Executing: %s", stmt)
+	_, err := s.backupDB.ExecContext(ctx, stmt)
+
+	entry := models.SchemaHistoryEntry{
+		TableName:  tableName,
+		Kind:       kind,
+		Statement:  stmt,
+		Before:     before,
+		ExecutedAt: started,
+		DurationMs: time.Since(started).Milliseconds(),
+		Outcome:    models.SchemaOutcomeSuccess,
+	}
+	if kind == models.SchemaHistoryDDL {
+		entry.After = s.backupDefinition(tableName)
+	}
+	if err != nil {
+		entry.Outcome = models.SchemaOutcomeFailed
+		entry.ErrorMessage = err.Error()
+	}
+	s.recordHistory(entry)
+
+	return err
+}
+
+// History mengembalikan schema history terbaru lebih dulu
+func (s *SchemaService) History(tableName string, limit int) ([]models.SchemaHistoryEntry, error) {
+	if s.history == nil {
+		return []models.SchemaHistoryEntry{}, nil
+	}
+	return s.history.List(tableName, limit)
+}
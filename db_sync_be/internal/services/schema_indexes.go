@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// compareIndexes membandingkan index master dan backup berdasarkan definisinya
+// (jenis, kolom, urutan, prefix length), bukan nama, karena dialect lain bisa
+// menyimpan index dengan nama berbeda. Index yang berubah di-drop lalu dibuat ulang.
+func (s *SchemaService) compareIndexes(tableName string) (drops, adds []models.SchemaChange, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	masterIndexes, err := s.source.GetIndexes(ctx, s.masterDB, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get master indexes: %v", err)
+	}
+
+	backupIndexes, err := s.target.GetIndexes(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get backup indexes: %v", err)
+	}
+
+	unmatched := make(map[string][]models.IndexInfo)
+	for _, idx := range backupIndexes {
+		sig := indexSignature(idx)
+		unmatched[sig] = append(unmatched[sig], idx)
+	}
+
+	for _, masterIdx := range masterIndexes {
+		idx, ok := s.target.AdaptIndex(masterIdx)
+		if !ok {
+			log.Printf("Warning: index %s.%s (%s) is not supported by %s backup, skipping",
+				tableName, masterIdx.IndexName, masterIdx.Kind, s.target.Name())
+			continue
+		}
+
+		sig := indexSignature(idx)
+		if matches := unmatched[sig]; len(matches) > 0 {
+			unmatched[sig] = matches[1:]
+			continue
+		}
+
+		adds = append(adds, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "add_index",
+			Object:    idx.IndexName,
+			Statement: s.target.CreateIndexStatement(tableName, idx),
+		})
+	}
+
+	// Drop dijalankan lebih dulu supaya index yang berubah bisa dibuat ulang dengan nama yang sama
+	for _, idx := range backupIndexes {
+		sig := indexSignature(idx)
+		if !containsIndex(unmatched[sig], idx.IndexName) {
+			continue
+		}
+		drops = append(drops, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "drop_index",
+			Object:    idx.IndexName,
+			Statement: s.target.DropIndexStatement(tableName, idx),
+		})
+	}
+
+	return drops, adds, nil
+}
+
+// indexSignature menyatakan definisi index sebagai string untuk dibandingkan
+func indexSignature(idx models.IndexInfo) string {
+	cols := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		cols[i] = strings.ToLower(col.ColumnName)
+		if col.SubPart > 0 {
+			cols[i] += fmt.Sprintf("(%d)", col.SubPart)
+		}
+		if col.Descending {
+			cols[i] += " desc"
+		}
+	}
+	return idx.Kind + ":" + strings.Join(cols, ",")
+}
+
+func containsIndex(indexes []models.IndexInfo, name string) bool {
+	for _, idx := range indexes {
+		if idx.IndexName == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"db-sync-scheduler/internal/models"
+)
+
+func TestIndexSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		idx  models.IndexInfo
+		want string
+	}{
+		{
+			name: "single column",
+			idx:  models.IndexInfo{IndexName: "idx_status", Columns: []models.IndexColumn{{ColumnName: "status"}}},
+			want: ":status",
+		},
+		{
+			name: "unique composite keeps column order",
+			idx: models.IndexInfo{IndexName: "uq_order_line", Kind: "UNIQUE", Columns: []models.IndexColumn{
+				{ColumnName: "orderNumber"}, {ColumnName: "orderLineNumber"},
+			}},
+			want: "UNIQUE:ordernumber,orderlinenumber",
+		},
+		{
+			name: "prefix length and descending",
+			idx: models.IndexInfo{IndexName: "idx_name", Columns: []models.IndexColumn{
+				{ColumnName: "lastName", SubPart: 10}, {ColumnName: "createdAt", Descending: true},
+			}},
+			want: ":lastname(10),createdat desc",
+		},
+		{
+			name: "fulltext",
+			idx:  models.IndexInfo{IndexName: "ft_body", Kind: "FULLTEXT", Columns: []models.IndexColumn{{ColumnName: "body"}}},
+			want: "FULLTEXT:body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexSignature(tt.idx); got != tt.want {
+				t.Errorf("indexSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Nama index dan huruf besar kecil kolom tidak membedakan definisi
+	a := models.IndexInfo{IndexName: "idx_a", Columns: []models.IndexColumn{{ColumnName: "Email"}}}
+	b := models.IndexInfo{IndexName: "email_idx", Columns: []models.IndexColumn{{ColumnName: "email"}}}
+	if indexSignature(a) != indexSignature(b) {
+		t.Errorf("renamed index has a different signature: %q vs %q", indexSignature(a), indexSignature(b))
+	}
+	c := models.IndexInfo{IndexName: "idx_a", Kind: "UNIQUE", Columns: []models.IndexColumn{{ColumnName: "email"}}}
+	if indexSignature(a) == indexSignature(c) {
+		t.Error("unique and plain index share a signature")
+	}
+}
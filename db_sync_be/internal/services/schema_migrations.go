@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format nama file migration
+const (
+	MigrationFormatGolangMigrate = "golang-migrate"
+	MigrationFormatFlyway        = "flyway"
+)
+
+// migrationFilePattern mengambil nomor versi dari nama file golang-migrate
+// (000001_x.up.sql) atau Flyway (V1__x.sql, U1__x.sql)
+var migrationFilePattern = regexp.MustCompile(`^(?:(\d+)_.*\.(?:up|down)\.sql|[VU](\d+)__.*\.sql)$`)
+
+var migrationNamePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// writeMigration menulis DDL plan sebagai file migration up/down di
+// SchemaMigrationDir, bukan menjalankannya. Tabel ditahan dari sync data sampai
+// migration dijalankan dan schema backup sama dengan master. Migration yang
+// isinya sama dengan file yang sudah ada tidak ditulis ulang; migration tabel
+// yang sama yang belum dijalankan diganti, bukan ditumpuk dengan versi baru.
+func (s *SchemaService) writeMigration(plan models.TablePlan) error {
+	dir := s.config.Sync.SchemaMigrationDir
+
+	down, err := s.downStatements(plan)
+	if err != nil {
+		return err
+	}
+
+	var up []string
+	for _, change := range plan.Changes {
+		if change.Destructive || change.Narrowing {
+			up = append(up, fmt.Sprintf("-- %s: review before applying, backup data may be lost", change.Kind))
+		}
+		up = append(up, change.Statement)
+	}
+
+	header := fmt.Sprintf("-- db_sync schema migration for table %s (%s)", plan.TableName, plan.Action)
+	upContent := migrationContent(header, up)
+	downContent := migrationContent(header+", generated from the current backup definition", down)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list schema migrations: %v", err)
+	}
+
+	// Header file migration tabel ini, tanpa action yang bisa berbeda antar plan
+	tablePrefix := fmt.Sprintf("-- db_sync schema migration for table %s (", plan.TableName)
+
+	version := 0
+	pendingVersion, pendingUp := 0, ""
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		n, err := strconv.Atoi(match[1] + match[2])
+		if err != nil {
+			continue
+		}
+		version = max(version, n)
+
+		if !isUpMigration(entry.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if string(data) == upContent {
+			s.holdTable(plan.TableName, fmt.Sprintf("schema migration %s waiting to be applied", entry.Name()))
+			log.Printf("Schema migration %s for table %s is waiting to be applied", entry.Name(), plan.TableName)
+			return nil
+		}
+		if strings.HasPrefix(string(data), tablePrefix) && n > pendingVersion {
+			pendingVersion, pendingUp = n, entry.Name()
+		}
+	}
+
+	next := version + 1
+	if pendingUp != "" {
+		applied, err := s.appliedMigrationVersion()
+		if err != nil {
+			return err
+		}
+
+		// Migration terakhir tabel ini belum dijalankan, ganti isinya di versi yang sama
+		if pendingVersion > applied {
+			for _, name := range []string{pendingUp, downMigrationName(pendingUp)} {
+				if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to replace schema migration %s: %v", name, err)
+				}
+			}
+			log.Printf("Replacing unapplied schema migration %s for table %s", pendingUp, plan.TableName)
+			next = pendingVersion
+		}
+	}
+
+	upName, downName := migrationFileNames(s.config.Sync.SchemaMigrationFormat, next, plan.Action+"_"+plan.TableName)
+	if err := os.WriteFile(filepath.Join(dir, upName), []byte(upContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write schema migration: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, downName), []byte(downContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write schema migration: %v", err)
+	}
+
+	s.holdTable(plan.TableName, fmt.Sprintf("schema migration %s waiting to be applied", upName))
+	log.Printf("Wrote schema migration %s (%d statements) for table %s", upName, len(plan.Changes), plan.TableName)
+	return nil
+}
+
+// appliedMigrationVersion membaca versi migration terakhir yang sudah dijalankan
+// dari tabel riwayat golang-migrate (schema_migrations) atau Flyway
+// (flyway_schema_history) di backup. 0 jika tabel riwayat belum ada.
+func (s *SchemaService) appliedMigrationVersion() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	table, query := "schema_migrations", "SELECT version FROM schema_migrations"
+	if s.config.Sync.SchemaMigrationFormat == MigrationFormatFlyway {
+		table, query = "flyway_schema_history", "SELECT version FROM flyway_schema_history WHERE success AND version IS NOT NULL"
+	}
+
+	exists, err := s.target.TableExists(ctx, s.backupDB, table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check %s: %v", table, err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	rows, err := s.backupDB.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := 0
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(version); err == nil {
+			applied = max(applied, n)
+		}
+	}
+
+	return applied, rows.Err()
+}
+
+// isUpMigration mengecek apakah file adalah migration up (000001_x.up.sql atau V1__x.sql)
+func isUpMigration(name string) bool {
+	return strings.HasSuffix(name, ".up.sql") || strings.HasPrefix(name, "V")
+}
+
+// downMigrationName mengembalikan nama file down pasangan migration up
+func downMigrationName(upName string) string {
+	if strings.HasPrefix(upName, "V") {
+		return "U" + upName[1:]
+	}
+	return strings.TrimSuffix(upName, ".up.sql") + ".down.sql"
+}
+
+func migrationFileNames(format string, version int, name string) (up, down string) {
+	name = strings.Trim(migrationNamePattern.ReplaceAllString(name, "_"), "_")
+
+	if format == MigrationFormatFlyway {
+		return fmt.Sprintf("V%d__%s.sql", version, name), fmt.Sprintf("U%d__%s.sql", version, name)
+	}
+	return fmt.Sprintf("%06d_%s.up.sql", version, name), fmt.Sprintf("%06d_%s.down.sql", version, name)
+}
+
+// migrationContent menulis satu statement per blok; baris komentar tidak diberi titik koma
+func migrationContent(header string, stmts []string) string {
+	var b strings.Builder
+	b.WriteString(header + "\n")
+	for _, stmt := range stmts {
+		if strings.HasPrefix(stmt, "--") {
+			b.WriteString("\n" + stmt)
+			continue
+		}
+		b.WriteString("\n" + stmt + ";\n")
+	}
+	return b.String()
+}
+
+// downStatements membuat DDL kebalikan plan dari definisi backup saat ini, dalam
+// urutan terbalik. Perubahan yang tidak bisa dibalik ditulis sebagai komentar.
+func (s *SchemaService) downStatements(plan models.TablePlan) ([]string, error) {
+	tableName := plan.TableName
+
+	switch plan.Action {
+	case PlanActionCreate:
+		return []string{"DROP TABLE " + s.target.QuoteIdentifier(tableName)}, nil
+	case PlanActionRename:
+		return []string{s.target.RenameTableStatement(tableName, plan.Changes[0].Object)}, nil
+	case PlanActionArchive:
+		return []string{s.target.RenameTableStatement(plan.Changes[0].Object, tableName)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	columns, err := s.target.GetColumns(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup schema: %v", err)
+	}
+
+	if plan.Action == PlanActionDrop {
+		create, err := s.target.ShowCreateTable(ctx, s.backupDB, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backup table definition: %v", err)
+		}
+		if create == "" {
+			create = s.target.CreateTableStatement(tableName, columns, "")
+		}
+		return []string{"-- Recreates the table structure only, dropped rows are not restored", create}, nil
+	}
+
+	indexes, err := s.target.GetIndexes(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup indexes: %v", err)
+	}
+	fks, err := s.target.GetForeignKeys(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup foreign keys: %v", err)
+	}
+	options, err := s.target.GetTableOptions(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup table options: %v", err)
+	}
+
+	before := backupDefinitions{columns: columns, renamedFrom: make(map[string]string)}
+	for _, change := range plan.Changes {
+		if change.Kind == "rename_column" {
+			before.renamedFrom[change.NewName] = change.Object
+		}
+	}
+
+	var stmts []string
+	for i := len(plan.Changes) - 1; i >= 0; i-- {
+		change := plan.Changes[i]
+		stmt, ok := s.reverseChange(change, before, indexes, fks, options)
+		if !ok {
+			stmts = append(stmts, "-- "+change.Kind+" cannot be reversed automatically:\n-- "+
+				strings.ReplaceAll(change.Statement, "\n", "\n-- ")+"\n")
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts, nil
+}
+
+// backupDefinitions adalah kolom backup sebelum migration; renamedFrom memetakan
+// nama kolom setelah rename ke nama di backup
+type backupDefinitions struct {
+	columns     []models.ColumnInfo
+	renamedFrom map[string]string
+}
+
+// column mengembalikan definisi backup kolom dengan nama setelah migration
+func (b backupDefinitions) column(name string) (models.ColumnInfo, bool) {
+	backupName := name
+	if oldName, ok := b.renamedFrom[name]; ok {
+		backupName = oldName
+	}
+	for _, col := range b.columns {
+		if col.ColumnName == backupName {
+			col.ColumnName = name
+			return col, true
+		}
+	}
+	return models.ColumnInfo{}, false
+}
+
+// previous mengembalikan kolom sebelum name di backup (nama setelah migration),
+// kosong jika kolom pertama
+func (b backupDefinitions) previous(name string) string {
+	backupName := name
+	if oldName, ok := b.renamedFrom[name]; ok {
+		backupName = oldName
+	}
+	for i, col := range b.columns {
+		if col.ColumnName != backupName || i == 0 {
+			continue
+		}
+		prev := b.columns[i-1].ColumnName
+		for newName, oldName := range b.renamedFrom {
+			if oldName == prev {
+				return newName
+			}
+		}
+		return prev
+	}
+	return ""
+}
+
+func (s *SchemaService) reverseChange(change models.SchemaChange, before backupDefinitions,
+	indexes []models.IndexInfo, fks []models.ForeignKey, options *models.TableOptions) (string, bool) {
+	tableName := change.TableName
+
+	switch change.Kind {
+	case "add_column":
+		return s.target.DropColumnStatement(tableName, change.Object), true
+
+	case "drop_column":
+		col, ok := before.column(change.Object)
+		if !ok {
+			return "", false
+		}
+		return s.target.AddColumnStatement(tableName, col, before.previous(change.Object)), true
+
+	case "modify_column":
+		col, ok := before.column(change.Object)
+		if !ok {
+			return "", false
+		}
+		return s.target.ModifyColumnStatement(tableName, col)
+
+	case "rename_column":
+		col, ok := before.column(change.Object)
+		if !ok {
+			return "", false
+		}
+		return s.target.RenameColumnStatement(tableName, change.NewName, col), true
+
+	case "move_column":
+		col, ok := before.column(change.Object)
+		if !ok {
+			return "", false
+		}
+		return s.target.MoveColumnStatement(tableName, col, before.previous(change.Object))
+
+	case "add_index":
+		name := s.target.IndexName(tableName, change.Object)
+		return s.target.DropIndexStatement(tableName, models.IndexInfo{IndexName: name}), true
+
+	case "drop_index":
+		for _, idx := range indexes {
+			if idx.IndexName == change.Object {
+				return s.target.CreateIndexStatement(tableName, idx), true
+			}
+		}
+
+	case "add_foreign_key":
+		return s.target.DropForeignKeyStatement(tableName, models.ForeignKeyConstraint{ConstraintName: change.Object})
+
+	case "drop_foreign_key":
+		for _, fk := range dialect.GroupForeignKeys(fks) {
+			if fk.ConstraintName == change.Object {
+				return s.target.AddForeignKeyStatement(tableName, fk)
+			}
+		}
+
+	case "table_option":
+		if options != nil {
+			return s.target.TableOptionStatement(tableName, change.Object, *options)
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/models"
+)
+
+func TestWriteMigrationReplacesUnappliedMigration(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Sync.SchemaMigrationDir = t.TempDir()
+	s := newSQLiteSyncService(t, cfg, nil, nil).schemaService
+
+	plan := func(statement string) models.TablePlan {
+		return models.TablePlan{
+			TableName: "offices",
+			Action:    PlanActionCreate,
+			Changes:   []models.SchemaChange{{TableName: "offices", Kind: "create_table", Statement: statement}},
+		}
+	}
+	files := func() []string {
+		entries, err := os.ReadDir(cfg.Sync.SchemaMigrationDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	if err := s.writeMigration(plan("CREATE TABLE offices (id INTEGER PRIMARY KEY)")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.writeMigration(plan("CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT)")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"000001_create_offices.down.sql", "000001_create_offices.up.sql"}
+	if got := files(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files after second plan = %v, want %v", got, want)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.Sync.SchemaMigrationDir, "000001_create_offices.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "city TEXT") {
+		t.Errorf("unapplied migration not replaced:\n%s", data)
+	}
+
+	// Setelah migration dijalankan, perubahan berikutnya ditulis sebagai versi baru
+	if _, err := s.backupDB.Exec("CREATE TABLE schema_migrations (version INTEGER NOT NULL, dirty BOOLEAN NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.backupDB.Exec("INSERT INTO schema_migrations VALUES (1, false)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.writeMigration(plan("CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT, phone TEXT)")); err != nil {
+		t.Fatal(err)
+	}
+
+	want = append(want, "000002_create_offices.down.sql", "000002_create_offices.up.sql")
+	if got := files(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files after applied migration = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,457 @@
+package services
+
+import (
+	"context"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Klasifikasi perubahan definisi kolom pada modify_column
+const (
+	TypeChangeWidening     = "widening"
+	TypeChangeNarrowing    = "narrowing"
+	TypeChangeIncompatible = "incompatible"
+)
+
+// narrowingCheck adalah kondisi SQL yang bernilai true untuk baris backup yang
+// tidak muat di definisi kolom baru. condition kosong berarti tidak bisa dicek.
+type narrowingCheck struct {
+	condition   string
+	description string
+}
+
+// columnType adalah tipe kolom yang sudah dipecah, mis. "decimal(10,2) unsigned"
+type columnType struct {
+	raw      string
+	base     string
+	args     []string
+	unsigned bool
+}
+
+var (
+	columnTypePattern = regexp.MustCompile(`^\s*(\w+)\s*(?:\(([^)]*)\))?\s*(.*)$`)
+	enumValuePattern  = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+	// Nama tipe PostgreSQL dari format_type yang terdiri dari beberapa kata
+	multiWordTypes = strings.NewReplacer(
+		"character varying", "varchar",
+		"bit varying", "varbit",
+		"double precision", "double",
+	)
+)
+
+// Keluarga tipe: integerBits jumlah bit per tipe, textBytes dan blobBytes
+// kapasitas byte per tipe (-1 berarti tanpa batas), floatRank dari yang terkecil
+var (
+	integerBits = map[string]uint{"tinyint": 8, "smallint": 16, "mediumint": 24, "int": 32, "integer": 32, "bigint": 64}
+	textBytes   = map[string]int64{"tinytext": 255, "text": 65535, "mediumtext": 16777215, "longtext": 4294967295}
+	blobBytes   = map[string]int64{"tinyblob": 255, "blob": 65535, "mediumblob": 16777215, "longblob": 4294967295, "bytea": -1}
+	floatRank   = map[string]int{"real": 1, "float": 1, "double": 2}
+)
+
+// maxCharBytes adalah ukuran terbesar satu karakter (utf8mb4)
+const maxCharBytes = 4
+
+func parseColumnType(raw string) columnType {
+	t := columnType{raw: strings.ToLower(strings.TrimSpace(raw))}
+	match := columnTypePattern.FindStringSubmatch(multiWordTypes.Replace(t.raw))
+	if match == nil {
+		t.base = t.raw
+		return t
+	}
+
+	t.base = match[1]
+	if match[2] != "" {
+		for _, arg := range strings.Split(match[2], ",") {
+			t.args = append(t.args, strings.TrimSpace(arg))
+		}
+	}
+	t.unsigned = strings.Contains(match[3], "unsigned")
+	return t
+}
+
+// normalizeColumnType memecah tipe kolom lalu memetakan nama tipe khusus dialect
+// ke nama yang dipakai keluarga tipe di bawah (nama MySQL), supaya tipe master
+// dan backup dari dialect berbeda bisa dibandingkan. raw tetap tipe aslinya
+// untuk laporan.
+func normalizeColumnType(dialectName, raw string) columnType {
+	t := parseColumnType(raw)
+
+	switch t.base {
+	case "integer", "int4":
+		t.base = "int"
+	case "int2":
+		t.base = "smallint"
+	case "int8":
+		t.base = "bigint"
+	case "float4":
+		t.base = "float"
+	case "float8":
+		t.base = "double"
+	case "numeric":
+		t.base = "decimal"
+	case "character", "bpchar":
+		t.base = "char"
+	case "bool":
+		t.base = "boolean"
+	case "jsonb":
+		t.base = "json"
+	}
+
+	switch dialectName {
+	case "postgres":
+		switch t.base {
+		case "text":
+			// text PostgreSQL tanpa batas panjang
+			t.base = "varchar"
+		case "real":
+			t.base = "float"
+		case "timestamp", "timestamptz":
+			t.base = "datetime"
+		case "decimal":
+			if len(t.args) == 0 {
+				t.base = "numeric"
+			}
+		}
+	case "sqlite":
+		// Type affinity SQLite: integer 64-bit, teks dan blob tanpa batas
+		switch t.base {
+		case "int":
+			t.base = "bigint"
+		case "real":
+			t.base = "double"
+		case "text":
+			t.base = "varchar"
+		case "blob":
+			t.base = "bytea"
+		case "decimal":
+			if len(t.args) == 0 {
+				t.base = "numeric"
+			}
+		case "timestamp":
+			t.base = "datetime"
+		}
+	default:
+		if t.base == "real" {
+			t.base = "double"
+		}
+	}
+
+	return t
+}
+
+// sameAs mengecek apakah dua tipe yang sudah dinormalisasi identik
+func (t columnType) sameAs(other columnType) bool {
+	if t.base != other.base || t.unsigned != other.unsigned || len(t.args) != len(other.args) {
+		return false
+	}
+	for i := range t.args {
+		if !strings.EqualFold(t.args[i], other.args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// intArg mengembalikan argumen numerik ke-i, ok false jika tidak ada
+func (t columnType) intArg(i int) (int64, bool) {
+	if i >= len(t.args) {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(t.args[i], 10, 64)
+	return n, err == nil
+}
+
+// classifyColumnChange mengklasifikasikan perubahan kolom backup menjadi definisi
+// master dan menghasilkan pengecekan data untuk perubahan yang menyempit. Tipe
+// dibandingkan setelah dinormalisasi per dialect, sehingga master dan backup
+// beda dialect tetap dicek; tipe yang berbeda keluarga (mis. varchar ke int)
+// dianggap incompatible karena tidak bisa dicek lewat SQL.
+func (s *SchemaService) classifyColumnChange(masterCol, backupCol models.ColumnInfo) (string, []narrowingCheck) {
+	column := s.target.QuoteIdentifier(backupCol.ColumnName)
+
+	var checks []narrowingCheck
+	if masterCol.IsNullable == "NO" && backupCol.IsNullable == "YES" {
+		checks = append(checks, narrowingCheck{column + " IS NULL", "NULL values"})
+	}
+
+	masterType := normalizeColumnType(s.source.Name(), masterCol.ColumnType)
+	backupType := normalizeColumnType(s.target.Name(), backupCol.ColumnType)
+	if !masterType.sameAs(backupType) {
+		typeChecks, compatible := typeNarrowingChecks(column, masterType, backupType)
+		if !compatible {
+			return TypeChangeIncompatible, checks
+		}
+		checks = append(checks, typeChecks...)
+	}
+
+	if len(checks) > 0 {
+		return TypeChangeNarrowing, checks
+	}
+	return TypeChangeWidening, nil
+}
+
+// typeNarrowingChecks membandingkan tipe master dan backup dalam keluarga tipe
+// yang sama. compatible false jika keluarganya berbeda.
+func typeNarrowingChecks(column string, master, backup columnType) (checks []narrowingCheck, compatible bool) {
+	if master.unsigned && !backup.unsigned {
+		if _, isInt := integerBits[master.base]; !isInt {
+			checks = append(checks, narrowingCheck{column + " < 0", "negative values"})
+		}
+	}
+
+	_, masterInt := integerBits[master.base]
+	_, backupInt := integerBits[backup.base]
+	_, masterFloat := floatRank[master.base]
+	_, backupFloat := floatRank[backup.base]
+
+	switch {
+	case masterInt && backupInt:
+		return append(checks, integerChecks(column, master, backup)...), true
+
+	case isDecimal(master) && (isDecimal(backup) || backupInt):
+		return append(checks, decimalChecks(column, master, backup)...), true
+
+	case isString(master) && isString(backup):
+		return append(checks, lengthChecks(column, stringCapacity(master), stringCapacity(backup))...), true
+
+	case isBinary(master) && isBinary(backup):
+		return append(checks, lengthChecks(column, binaryCapacity(master), binaryCapacity(backup))...), true
+
+	case master.base == "enum" && backup.base == "enum", master.base == "set" && backup.base == "set":
+		return append(checks, enumChecks(column, master, backup)...), true
+
+	case masterFloat && backupFloat:
+		if floatRank[master.base] < floatRank[backup.base] {
+			checks = append(checks, narrowingCheck{"", fmt.Sprintf("precision reduced from %s to %s", backup.raw, master.raw)})
+		}
+		return checks, true
+
+	case master.base == backup.base:
+		// Tipe lain (datetime(6), bit(n), ...) hanya dibandingkan argumennya
+		for i := range master.args {
+			m, okM := master.intArg(i)
+			b, okB := backup.intArg(i)
+			if okM && okB && m < b {
+				checks = append(checks, narrowingCheck{"", fmt.Sprintf("precision reduced from %s to %s", backup.raw, master.raw)})
+				break
+			}
+		}
+		return checks, true
+	}
+
+	return nil, false
+}
+
+// integerRange mengembalikan nilai terkecil dan terbesar tipe integer
+func integerRange(t columnType) (*big.Int, *big.Int) {
+	bits := integerBits[t.base]
+	if t.unsigned {
+		max := new(big.Int).Lsh(big.NewInt(1), bits)
+		return big.NewInt(0), max.Sub(max, big.NewInt(1))
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	min := new(big.Int).Neg(max)
+	return min, max.Sub(max, big.NewInt(1))
+}
+
+func integerChecks(column string, master, backup columnType) []narrowingCheck {
+	masterMin, masterMax := integerRange(master)
+	backupMin, backupMax := integerRange(backup)
+
+	var conditions []string
+	if masterMin.Cmp(backupMin) > 0 {
+		conditions = append(conditions, column+" < "+masterMin.String())
+	}
+	if masterMax.Cmp(backupMax) < 0 {
+		conditions = append(conditions, column+" > "+masterMax.String())
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	return []narrowingCheck{{strings.Join(conditions, " OR "), "values outside " + master.raw + " range"}}
+}
+
+func isDecimal(t columnType) bool {
+	return t.base == "decimal" || t.base == "numeric"
+}
+
+// decimalDigits mengembalikan jumlah digit sebelum koma dan jumlah desimal,
+// -1 berarti tanpa batas (numeric PostgreSQL tanpa presisi)
+func decimalDigits(t columnType) (intDigits, scale int64) {
+	if _, isInt := integerBits[t.base]; isInt {
+		_, max := integerRange(t)
+		return int64(len(max.String())), 0
+	}
+
+	precision, ok := t.intArg(0)
+	if !ok {
+		if len(t.args) == 0 && t.base == "numeric" {
+			return -1, -1
+		}
+		// Default MySQL DECIMAL adalah DECIMAL(10,0)
+		precision = 10
+	}
+	scale, _ = t.intArg(1)
+	return precision - scale, scale
+}
+
+func decimalChecks(column string, master, backup columnType) []narrowingCheck {
+	masterInt, masterScale := decimalDigits(master)
+	backupInt, backupScale := decimalDigits(backup)
+
+	var checks []narrowingCheck
+	if masterInt >= 0 && (backupInt < 0 || masterInt < backupInt) {
+		checks = append(checks, narrowingCheck{
+			fmt.Sprintf("ABS(%s) >= 1%s", column, strings.Repeat("0", int(masterInt))),
+			fmt.Sprintf("values with more than %d integer digits", masterInt),
+		})
+	}
+	if masterScale >= 0 && (backupScale < 0 || masterScale < backupScale) {
+		checks = append(checks, narrowingCheck{
+			fmt.Sprintf("%s <> ROUND(%s, %d)", column, column, masterScale),
+			fmt.Sprintf("values with more than %d decimals", masterScale),
+		})
+	}
+	return checks
+}
+
+// capacity adalah panjang maksimum nilai, dalam karakter atau byte; limit -1 berarti tanpa batas
+type capacity struct {
+	limit int64
+	bytes bool
+}
+
+func isString(t columnType) bool {
+	_, isText := textBytes[t.base]
+	return isText || t.base == "char" || t.base == "varchar" || t.base == "character"
+}
+
+func stringCapacity(t columnType) capacity {
+	if limit, isText := textBytes[t.base]; isText {
+		return capacity{limit: limit, bytes: true}
+	}
+	if limit, ok := t.intArg(0); ok {
+		return capacity{limit: limit}
+	}
+	if t.base == "varchar" {
+		// varchar PostgreSQL tanpa panjang
+		return capacity{limit: -1}
+	}
+	return capacity{limit: 1}
+}
+
+func isBinary(t columnType) bool {
+	_, isBlob := blobBytes[t.base]
+	return isBlob || t.base == "binary" || t.base == "varbinary"
+}
+
+func binaryCapacity(t columnType) capacity {
+	if limit, isBlob := blobBytes[t.base]; isBlob {
+		return capacity{limit: limit, bytes: true}
+	}
+	if limit, ok := t.intArg(0); ok {
+		return capacity{limit: limit, bytes: true}
+	}
+	return capacity{limit: 1, bytes: true}
+}
+
+// lengthChecks mengecek panjang nilai jika kapasitas master bisa lebih kecil dari
+// backup. Satu karakter bisa sampai maxCharBytes byte.
+func lengthChecks(column string, master, backup capacity) []narrowingCheck {
+	if master.limit < 0 {
+		return nil
+	}
+	if backup.limit >= 0 {
+		backupLimit := backup.limit
+		if master.bytes && !backup.bytes {
+			backupLimit *= maxCharBytes
+		}
+		if master.limit >= backupLimit {
+			return nil
+		}
+	}
+
+	if master.bytes {
+		return []narrowingCheck{{
+			fmt.Sprintf("OCTET_LENGTH(%s) > %d", column, master.limit),
+			fmt.Sprintf("values longer than %d bytes", master.limit),
+		}}
+	}
+	return []narrowingCheck{{
+		fmt.Sprintf("CHAR_LENGTH(%s) > %d", column, master.limit),
+		fmt.Sprintf("values longer than %d characters", master.limit),
+	}}
+}
+
+// enumChecks mengecek nilai ENUM/SET backup yang dihapus dari definisi master
+func enumChecks(column string, master, backup columnType) []narrowingCheck {
+	masterValues := enumValuePattern.FindAllString(master.raw, -1)
+	allowed := make(map[string]bool)
+	for _, value := range masterValues {
+		allowed[value] = true
+	}
+
+	var removed []string
+	for _, value := range enumValuePattern.FindAllString(backup.raw, -1) {
+		if !allowed[value] {
+			removed = append(removed, value)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	description := "values removed from " + master.base + ": " + strings.Join(removed, ", ")
+	if master.base == "set" {
+		var conditions []string
+		for _, value := range removed {
+			conditions = append(conditions, fmt.Sprintf("FIND_IN_SET(%s, %s) > 0", value, column))
+		}
+		return []narrowingCheck{{strings.Join(conditions, " OR "), description}}
+	}
+	return []narrowingCheck{{fmt.Sprintf("%s IN (%s)", column, strings.Join(removed, ", ")), description}}
+}
+
+// scanNarrowing menghitung baris backup yang tidak muat di definisi kolom baru.
+// Hasilnya Verified false jika ada aturan yang tidak bisa dicek atau query gagal.
+func (s *SchemaService) scanNarrowing(tableName, columnName string, checks []narrowingCheck) *models.DataCheck {
+	result := &models.DataCheck{Verified: true}
+	var findings []string
+
+	for _, check := range checks {
+		if check.condition == "" {
+			result.Verified = false
+			findings = append(findings, "cannot verify "+check.description)
+			continue
+		}
+
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", s.target.QuoteIdentifier(tableName), check.condition)
+		ctx, cancel := context.WithTimeout(context.Background(), schemaStatementTimeout)
+		err := s.backupDB.QueryRowContext(ctx, query).Scan(&count)
+		cancel()
+		if err != nil {
+			result.Verified = false
+			findings = append(findings, fmt.Sprintf("failed to check %s: %v", check.description, err))
+			continue
+		}
+		if count > 0 {
+			result.ViolatingRows += count
+			findings = append(findings, fmt.Sprintf("%d rows with %s", count, check.description))
+		}
+	}
+
+	if len(findings) == 0 {
+		result.Report = fmt.Sprintf("all backup values of %s.%s fit the new definition", tableName, columnName)
+	} else {
+		result.Report = fmt.Sprintf("%s.%s: %s", tableName, columnName, strings.Join(findings, "; "))
+		log.Printf("Warning: narrowing column %s", result.Report)
+	}
+	return result
+}
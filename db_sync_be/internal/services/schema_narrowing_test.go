@@ -0,0 +1,184 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func conditions(checks []narrowingCheck) string {
+	var parts []string
+	for _, check := range checks {
+		if check.condition == "" {
+			parts = append(parts, "<unchecked: "+check.description+">")
+			continue
+		}
+		parts = append(parts, check.condition)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestTypeNarrowingChecks(t *testing.T) {
+	tests := []struct {
+		name           string
+		master         string
+		backup         string
+		wantCompatible bool
+		want           string
+	}{
+		{"int widened", "bigint", "int", true, ""},
+		{"int narrowed", "tinyint", "int", true, "c < -128 OR c > 127"},
+		{"int to unsigned", "int unsigned", "int", true, "c < 0"},
+		{"decimal unsigned", "decimal(10,2) unsigned", "decimal(10,2)", true, "c < 0"},
+		{"varchar narrowed", "varchar(50)", "varchar(255)", true, "CHAR_LENGTH(c) > 50"},
+		{"varchar to text", "text", "varchar(255)", true, ""},
+		{"long varchar to text", "text", "varchar(20000)", true, "OCTET_LENGTH(c) > 65535"},
+		{"varchar widened", "varchar(255)", "varchar(50)", true, ""},
+		{"binary narrowed", "varbinary(16)", "blob", true, "OCTET_LENGTH(c) > 16"},
+		{"enum value removed", "enum('a','b')", "enum('a','b','c')", true, "c IN ('c')"},
+		{"set value removed", "set('r','w')", "set('r','w','x')", true, "FIND_IN_SET('x', c) > 0"},
+		{"double to float", "float", "double", true, "<unchecked: precision reduced from double to float>"},
+		{"datetime precision", "datetime", "datetime(6)", true, ""},
+		{"time precision reduced", "time(3)", "time(6)", true, "<unchecked: precision reduced from time(6) to time(3)>"},
+		{"int to decimal", "decimal(5,0)", "int", true, "ABS(c) >= 100000"},
+		{"varchar to int", "int", "varchar(20)", false, ""},
+		{"date to datetime", "date", "datetime", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, compatible := typeNarrowingChecks("c", parseColumnType(tt.master), parseColumnType(tt.backup))
+			if compatible != tt.wantCompatible {
+				t.Fatalf("compatible = %v, want %v", compatible, tt.wantCompatible)
+			}
+			if got := conditions(checks); got != tt.want {
+				t.Errorf("checks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntegerChecks(t *testing.T) {
+	tests := []struct {
+		master string
+		backup string
+		want   string
+	}{
+		{"int", "int", ""},
+		{"smallint", "mediumint", "c < -32768 OR c > 32767"},
+		{"tinyint unsigned", "tinyint", "c < 0"},
+		{"tinyint", "tinyint unsigned", "c > 127"},
+		{"int unsigned", "bigint", "c < 0 OR c > 4294967295"},
+		{"bigint unsigned", "bigint", "c < 0"},
+		{"bigint", "int unsigned", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backup+" to "+tt.master, func(t *testing.T) {
+			got := conditions(integerChecks("c", parseColumnType(tt.master), parseColumnType(tt.backup)))
+			if got != tt.want {
+				t.Errorf("integerChecks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalChecks(t *testing.T) {
+	tests := []struct {
+		master string
+		backup string
+		want   string
+	}{
+		{"decimal(10,2)", "decimal(10,2)", ""},
+		{"decimal(12,4)", "decimal(10,2)", ""},
+		{"decimal(8,2)", "decimal(10,2)", "ABS(c) >= 1000000"},
+		{"decimal(10,1)", "decimal(10,2)", "c <> ROUND(c, 1)"},
+		{"decimal(6,1)", "decimal(10,2)", "ABS(c) >= 100000; c <> ROUND(c, 1)"},
+		{"decimal", "decimal(10,0)", ""},
+		{"decimal(10,2)", "numeric", "ABS(c) >= 100000000; c <> ROUND(c, 2)"},
+		{"numeric", "decimal(10,2)", ""},
+		{"decimal(3,0)", "smallint", "ABS(c) >= 1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backup+" to "+tt.master, func(t *testing.T) {
+			got := conditions(decimalChecks("c", parseColumnType(tt.master), parseColumnType(tt.backup)))
+			if got != tt.want {
+				t.Errorf("decimalChecks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLengthChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		master capacity
+		backup capacity
+		want   string
+	}{
+		{"same characters", capacity{limit: 50}, capacity{limit: 50}, ""},
+		{"fewer characters", capacity{limit: 20}, capacity{limit: 50}, "CHAR_LENGTH(c) > 20"},
+		{"more characters", capacity{limit: 100}, capacity{limit: 50}, ""},
+		{"unbounded master", capacity{limit: -1}, capacity{limit: 50}, ""},
+		{"unbounded backup", capacity{limit: 50}, capacity{limit: -1}, "CHAR_LENGTH(c) > 50"},
+		{"bytes fit multibyte characters", capacity{limit: 255, bytes: true}, capacity{limit: 50}, ""},
+		{"bytes smaller than multibyte characters", capacity{limit: 255, bytes: true}, capacity{limit: 100}, "OCTET_LENGTH(c) > 255"},
+		{"fewer bytes", capacity{limit: 16, bytes: true}, capacity{limit: 65535, bytes: true}, "OCTET_LENGTH(c) > 16"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := conditions(lengthChecks("c", tt.master, tt.backup))
+			if got != tt.want {
+				t.Errorf("lengthChecks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeColumnTypeAcrossDialects(t *testing.T) {
+	tests := []struct {
+		name           string
+		master         string
+		backupDialect  string
+		backup         string
+		wantSame       bool
+		wantCompatible bool
+		want           string
+	}{
+		{"varchar to postgres", "varchar(50)", "postgres", "character varying(50)", true, true, ""},
+		{"varchar narrowed on postgres", "varchar(50)", "postgres", "character varying(255)", false, true, "CHAR_LENGTH(c) > 50"},
+		{"varchar from postgres text", "varchar(50)", "postgres", "text", false, true, "CHAR_LENGTH(c) > 50"},
+		{"int to postgres integer", "int", "postgres", "integer", true, true, ""},
+		{"smallint from postgres integer", "smallint", "postgres", "integer", false, true, "c < -32768 OR c > 32767"},
+		{"decimal to postgres numeric", "decimal(10,2)", "postgres", "numeric(10,2)", true, true, ""},
+		{"decimal from unbounded numeric", "decimal(10,2)", "postgres", "numeric", false, true, "ABS(c) >= 100000000; c <> ROUND(c, 2)"},
+		{"datetime to postgres timestamp", "datetime(3)", "postgres", "timestamp(3) without time zone", true, true, ""},
+		{"double to postgres", "double", "postgres", "double precision", true, true, ""},
+		{"float from postgres double", "float", "postgres", "double precision", false, true, "<unchecked: precision reduced from double precision to float>"},
+		{"varbinary from postgres bytea", "varbinary(16)", "postgres", "bytea", false, true, "OCTET_LENGTH(c) > 16"},
+		{"varchar to postgres integer", "varchar(20)", "postgres", "integer", false, false, ""},
+		{"int from sqlite integer", "int", "sqlite", "INTEGER", false, true, "c < -2147483648 OR c > 2147483647"},
+		{"varchar from sqlite text", "varchar(20)", "sqlite", "TEXT", false, true, "CHAR_LENGTH(c) > 20"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := normalizeColumnType("mysql", tt.master)
+			backup := normalizeColumnType(tt.backupDialect, tt.backup)
+			if same := master.sameAs(backup); same != tt.wantSame {
+				t.Fatalf("sameAs = %v, want %v (master %+v, backup %+v)", same, tt.wantSame, master, backup)
+			}
+			if tt.wantSame {
+				return
+			}
+			checks, compatible := typeNarrowingChecks("c", master, backup)
+			if compatible != tt.wantCompatible {
+				t.Fatalf("compatible = %v, want %v", compatible, tt.wantCompatible)
+			}
+			if got := conditions(checks); got != tt.want {
+				t.Errorf("checks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
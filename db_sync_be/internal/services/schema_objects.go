@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Mode trigger master di backup
+const (
+	BackupTriggersSkip     = "skip"
+	BackupTriggersDisabled = "disabled"
+)
+
+// objectTypeOrder adalah urutan pembuatan object: routine dulu karena bisa dipakai
+// view dan trigger, view setelah semua tabel, trigger dan event paling akhir.
+// Object di-drop dengan urutan terbalik.
+var objectTypeOrder = []string{
+	models.ObjectTypeFunction,
+	models.ObjectTypeProcedure,
+	models.ObjectTypeView,
+	models.ObjectTypeTrigger,
+	models.ObjectTypeEvent,
+}
+
+// syncSchemaObjects menyalin view, routine, trigger dan event dari master ke
+// backup. DDL object tidak portabel antar database, jadi hanya dijalankan jika
+// master dan backup memakai dialect yang sama. Object yang gagal dibuat dicatat
+// dan dicoba lagi di schema sync berikutnya.
+func (s *SchemaService) syncSchemaObjects() error {
+	if s.source.Name() != s.target.Name() {
+		log.Printf("Skipping views, routines, triggers and events: %s master and %s backup use different DDL",
+			s.source.Name(), s.target.Name())
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	masterObjects, err := s.source.GetSchemaObjects(ctx, s.masterDB)
+	if err != nil {
+		return fmt.Errorf("failed to get master schema objects: %v", err)
+	}
+
+	backupObjects, err := s.target.GetSchemaObjects(ctx, s.backupDB)
+	if err != nil {
+		return fmt.Errorf("failed to get backup schema objects: %v", err)
+	}
+
+	// Trigger hanya disalin jika backup bisa menonaktifkannya
+	copyTriggers := s.config.Sync.BackupTriggers == BackupTriggersDisabled
+	if _, ok := s.target.DisableTriggerStatement(models.SchemaObject{}); copyTriggers && !ok {
+		log.Printf("Warning: %s backup cannot disable triggers, master triggers are not copied", s.target.Name())
+		copyTriggers = false
+	}
+
+	backupByKey := make(map[string]models.SchemaObject)
+	for _, obj := range backupObjects {
+		if s.objectSynced(obj, copyTriggers) {
+			backupByKey[objectKey(obj)] = obj
+		} else if obj.Type == models.ObjectTypeTrigger && !strings.HasPrefix(obj.Name, internalTablePrefix) {
+			log.Printf("Warning: backup trigger %s on %s also fires on replicated rows", obj.Name, obj.TableName)
+		}
+	}
+
+	masterByKey := make(map[string]models.SchemaObject)
+	byType := make(map[string][]models.SchemaObject)
+	for _, obj := range masterObjects {
+		if s.objectSynced(obj, copyTriggers) {
+			masterByKey[objectKey(obj)] = obj
+			byType[obj.Type] = append(byType[obj.Type], obj)
+		}
+	}
+
+	// Object yang sudah tidak ada di master di-drop, urutan terbalik dari pembuatan
+	dropped := 0
+	for i := len(objectTypeOrder) - 1; i >= 0; i-- {
+		for _, obj := range backupObjects {
+			if obj.Type != objectTypeOrder[i] {
+				continue
+			}
+			if _, synced := backupByKey[objectKey(obj)]; !synced {
+				continue
+			}
+			if _, exists := masterByKey[objectKey(obj)]; exists {
+				continue
+			}
+			if s.execObjectStatements(obj, []string{s.target.DropObjectStatement(obj)}) {
+				dropped++
+			}
+		}
+	}
+
+	created, failed := 0, 0
+	for _, objType := range objectTypeOrder {
+		pending := byType[objType]
+
+		// View bisa bergantung pada view lain, yang gagal dicoba lagi selama masih
+		// ada view yang berhasil dibuat di putaran sebelumnya
+		for len(pending) > 0 {
+			var retry []models.SchemaObject
+			for _, obj := range pending {
+				stmts := s.objectStatements(obj, backupByKey)
+				if len(stmts) == 0 {
+					continue
+				}
+				if s.execObjectStatements(obj, stmts) {
+					created++
+				} else {
+					retry = append(retry, obj)
+				}
+			}
+
+			if objType != models.ObjectTypeView || len(retry) == len(pending) {
+				failed += len(retry)
+				break
+			}
+			pending = retry
+		}
+	}
+
+	log.Printf("Schema objects synchronized: %d created or replaced, %d dropped, %d failed", created, dropped, failed)
+	return nil
+}
+
+// objectSynced menentukan apakah object ikut disinkronkan sesuai konfigurasi.
+// Trigger internal db_sync (mis. trigger changelog) tidak pernah disalin.
+func (s *SchemaService) objectSynced(obj models.SchemaObject, copyTriggers bool) bool {
+	if strings.HasPrefix(obj.Name, internalTablePrefix) {
+		return false
+	}
+
+	switch obj.Type {
+	case models.ObjectTypeView:
+		return s.config.Sync.SyncViews
+	case models.ObjectTypeProcedure, models.ObjectTypeFunction:
+		return s.config.Sync.SyncRoutines
+	case models.ObjectTypeEvent:
+		return s.config.Sync.SyncEvents
+	case models.ObjectTypeTrigger:
+		return copyTriggers
+	default:
+		return false
+	}
+}
+
+// objectStatements menghasilkan DDL untuk membuat atau mengganti object di
+// backup, kosong jika definisinya sudah sama
+func (s *SchemaService) objectStatements(obj models.SchemaObject, backupByKey map[string]models.SchemaObject) []string {
+	var stmts []string
+
+	if existing, exists := backupByKey[objectKey(obj)]; exists {
+		// DEFINER dibandingkan tanpa nilai, backup bisa memakai definer lain
+		if objectSignature(s.target.ObjectDefinition(existing, "")) == objectSignature(s.target.ObjectDefinition(obj, "")) {
+			return nil
+		}
+		if !strings.HasPrefix(strings.ToUpper(obj.Definition), "CREATE OR REPLACE") {
+			stmts = append(stmts, s.target.DropObjectStatement(existing))
+		}
+	}
+
+	stmts = append(stmts, s.target.ObjectDefinition(obj, s.config.Sync.ObjectDefiner))
+
+	if obj.Type == models.ObjectTypeTrigger {
+		if disable, ok := s.target.DisableTriggerStatement(obj); ok {
+			stmts = append(stmts, disable)
+		}
+	}
+
+	return stmts
+}
+
+// execObjectStatements menjalankan DDL satu object, error hanya dicatat supaya
+// object lain tetap diproses
+func (s *SchemaService) execObjectStatements(obj models.SchemaObject, stmts []string) bool {
+	for _, stmt := range stmts {
+		if err := s.execHistoryDDL(obj.Name, strings.ToLower(obj.Type), stmt); err != nil {
+			log.Printf("Error syncing %s %s: %v", strings.ToLower(obj.Type), obj.Name, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// objectKey mengidentifikasi object; routine PostgreSQL bisa di-overload
+// sehingga argumennya ikut menjadi bagian dari identitas
+func objectKey(obj models.SchemaObject) string {
+	return obj.Type + ":" + obj.TableName + ":" + obj.Name + "(" + obj.Arguments + ")"
+}
+
+// objectSignature menormalkan whitespace DDL untuk dibandingkan
+func objectSignature(def string) string {
+	return strings.Join(strings.Fields(def), " ")
+}
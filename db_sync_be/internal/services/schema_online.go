@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Method DDL yang tercatat di SchemaProgress selain opsi online ALTER dialect
+const (
+	SchemaMethodShadowCopy = "shadow copy"
+	SchemaMethodBlocking   = "blocking alter"
+)
+
+// shadowCopyChunk adalah jumlah baris per INSERT ... SELECT saat menyalin ke tabel bayangan
+const shadowCopyChunk = 5000
+
+// combinableChanges adalah jenis perubahan yang boleh digabung dalam satu ALTER
+// TABLE. Rename, create table dan FK selalu dijalankan sendiri.
+var combinableChanges = map[string]bool{
+	"add_column":    true,
+	"modify_column": true,
+	"drop_column":   true,
+	"move_column":   true,
+	"add_index":     true,
+	"drop_index":    true,
+	"table_option":  true,
+}
+
+var errShadowCopyUnsupported = errors.New("shadow copy not supported")
+
+// applyChanges menjalankan DDL di backup sesuai urutan. Perubahan kolom dan index
+// yang berurutan untuk tabel yang sama digabung menjadi satu ALTER TABLE supaya
+// tabel hanya ditulis ulang sekali.
+func (s *SchemaService) applyChanges(changes []models.SchemaChange) error {
+	var tableName string
+	var clauses []string
+	touched := make(map[string]bool)
+
+	flush := func() error {
+		if len(clauses) == 0 {
+			return nil
+		}
+		err := s.applyAlter(tableName, clauses)
+		clauses = nil
+		touched = make(map[string]bool)
+		return err
+	}
+
+	for _, change := range changes {
+		clause, ok := s.target.AlterClause(change.TableName, change.Statement)
+		ok = ok && combinableChanges[change.Kind]
+
+		// Object yang sama tidak diubah dua kali dalam satu ALTER, mis. modify lalu move kolom
+		key := changeObjectKey(change)
+		if !ok || change.TableName != tableName || touched[key] {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		if !ok {
+			if err := s.execDDL(change.TableName, change.Statement); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tableName = change.TableName
+		clauses = append(clauses, clause)
+		touched[key] = true
+	}
+
+	return flush()
+}
+
+// applyAlter menjalankan klausa ALTER yang sudah digabung. Opsi online dialect
+// dicoba berurutan; jika semuanya ditolak server, perubahan dijalankan lewat
+// tabel bayangan, dan jika itu juga tidak bisa, sebagai ALTER biasa.
+func (s *SchemaService) applyAlter(tableName string, clauses []string) error {
+	options := s.target.OnlineAlterOptions()
+
+	for _, option := range options {
+		stmt := s.target.AlterTableStatement(tableName, append(clauses[:len(clauses):len(clauses)], option))
+		s.startProgress(tableName, option)
+		err := s.execDDL(tableName, stmt)
+		s.finishProgress(tableName)
+		if err == nil {
+			return nil
+		}
+		if !s.target.UnsupportedAlter(err) {
+			return err
+		}
+		log.Printf("  %s not supported for table %s: %v", option, tableName, err)
+	}
+
+	if len(options) > 0 {
+		err := s.shadowCopy(tableName, clauses)
+		if !errors.Is(err, errShadowCopyUnsupported) {
+			return err
+		}
+		log.Printf("Warning: running blocking ALTER on table %s, writes to the backup table wait until it finishes", tableName)
+	}
+
+	s.startProgress(tableName, SchemaMethodBlocking)
+	defer s.finishProgress(tableName)
+	return s.execDDL(tableName, s.target.AlterTableStatement(tableName, clauses))
+}
+
+// shadowCopy menerapkan perubahan ke tabel bayangan kosong, menyalin data per
+// chunk PK, lalu menukar tabel secara atomik. Data tabel tidak di-sync selama
+// penyalinan supaya tidak ada perubahan yang tertinggal di tabel lama.
+func (s *SchemaService) shadowCopy(tableName string, clauses []string) error {
+	shadowName := internalTablePrefix + "shadow_" + tableName
+	oldName := internalTablePrefix + "old_" + tableName
+
+	createStmt, swapStmt, ok := s.target.ShadowTableStatements(tableName, shadowName, oldName)
+	if !ok {
+		return errShadowCopyUnsupported
+	}
+
+	pkColumns, reason, err := s.shadowCopyCheck(tableName)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		log.Printf("Warning: cannot use shadow copy for table %s: %s", tableName, reason)
+		return errShadowCopyUnsupported
+	}
+
+	// Tunggu syncTable yang sedang menulis ke tabel ini selesai; selama penyalinan
+	// dan penukaran tabel, syncTable berikutnya melewati tabel ini
+	lock := s.tableLock(tableName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	s.startProgress(tableName, SchemaMethodShadowCopy)
+	defer s.finishProgress(tableName)
+
+	// Sisa shadow copy yang gagal sebelumnya dibuang dulu
+	dropShadow := "DROP TABLE IF EXISTS " + s.target.QuoteIdentifier(shadowName)
+	if err := s.execDDL(tableName, dropShadow); err != nil {
+		return err
+	}
+	if err := s.execDDL(tableName, createStmt); err != nil {
+		return err
+	}
+	if err := s.execDDL(tableName, s.target.AlterTableStatement(shadowName, clauses)); err != nil {
+		s.execDDL(tableName, dropShadow)
+		return err
+	}
+
+	if err := s.copyToShadow(tableName, shadowName, pkColumns); err != nil {
+		s.execDDL(tableName, dropShadow)
+		return fmt.Errorf("failed to copy table %s to shadow table: %v", tableName, err)
+	}
+
+	if err := s.execDDL(tableName, swapStmt); err != nil {
+		s.execDDL(tableName, dropShadow)
+		return err
+	}
+
+	if err := s.execDDL(tableName, "DROP TABLE "+s.target.QuoteIdentifier(oldName)); err != nil {
+		log.Printf("Warning: failed to drop old table %s after shadow copy: %v", oldName, err)
+	}
+	return nil
+}
+
+// shadowCopyCheck mengembalikan kolom PK tabel backup, atau alasan tabel tidak
+// bisa ditukar: tanpa PK tidak bisa disalin per chunk, sedangkan FK dan trigger
+// tetap menempel di tabel lama setelah rename.
+func (s *SchemaService) shadowCopyCheck(tableName string) ([]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	pkColumns, err := s.target.GetPrimaryKeyColumns(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get primary key: %v", err)
+	}
+	if len(pkColumns) == 0 {
+		return nil, "table has no primary key", nil
+	}
+
+	fks, err := s.target.GetForeignKeys(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get foreign keys: %v", err)
+	}
+	if len(fks) > 0 {
+		return nil, "table has foreign keys", nil
+	}
+
+	tables, err := s.target.ListTables(ctx, s.backupDB)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list tables: %v", err)
+	}
+	for _, other := range tables {
+		if other == tableName {
+			continue
+		}
+		fks, err := s.target.GetForeignKeys(ctx, s.backupDB, other)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get foreign keys: %v", err)
+		}
+		for _, fk := range fks {
+			if fk.ReferencedTableName == tableName {
+				return nil, "table is referenced by foreign keys from " + other, nil
+			}
+		}
+	}
+
+	objects, err := s.target.GetSchemaObjects(ctx, s.backupDB)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get schema objects: %v", err)
+	}
+	for _, obj := range objects {
+		if obj.Type == models.ObjectTypeTrigger && obj.TableName == tableName {
+			return nil, "table has triggers", nil
+		}
+	}
+
+	return pkColumns, "", nil
+}
+
+// copyToShadow menyalin kolom yang ada di kedua tabel dengan keyset pagination
+// PK, setiap chunk dibatasi nilai PK awal dan akhir
+func (s *SchemaService) copyToShadow(tableName, shadowName string, pkColumns []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sourceColumns, err := s.target.GetColumns(ctx, s.backupDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %v", err)
+	}
+	shadowColumns, err := s.target.GetColumns(ctx, s.backupDB, shadowName)
+	if err != nil {
+		return fmt.Errorf("failed to get shadow columns: %v", err)
+	}
+
+	existing := make(map[string]bool)
+	for _, col := range sourceColumns {
+		existing[col.ColumnName] = true
+	}
+	var columns []string
+	for _, col := range shadowColumns {
+		// Kolom generated dihitung sendiri oleh tabel bayangan
+		if existing[col.ColumnName] && col.GenerationExpression == "" {
+			columns = append(columns, col.ColumnName)
+		}
+	}
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM " + s.target.QuoteIdentifier(tableName)
+	if err := s.backupDB.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+		return fmt.Errorf("failed to count rows: %v", err)
+	}
+	s.updateProgress(tableName, 0, total)
+
+	pkList := "(" + dialect.QuoteIdentifiers(s.target, pkColumns) + ")"
+	pkPlaceholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(pkColumns)), ", ") + ")"
+	columnList := dialect.QuoteIdentifiers(s.target, columns)
+	insertQuery := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+		s.target.QuoteIdentifier(shadowName), columnList, columnList, s.target.QuoteIdentifier(tableName))
+	endQuery := fmt.Sprintf("SELECT %s FROM %s", dialect.QuoteIdentifiers(s.target, pkColumns), s.target.QuoteIdentifier(tableName))
+	orderBy := fmt.Sprintf(" ORDER BY %s LIMIT 1 OFFSET %d", dialect.QuoteIdentifiers(s.target, pkColumns), shadowCopyChunk-1)
+
+	var copied int64
+	var lastKey []interface{}
+	lastLog := time.Now()
+	for {
+		var conditions []string
+		var args []interface{}
+		if lastKey != nil {
+			conditions = append(conditions, pkList+" > "+pkPlaceholders)
+			args = append(args, lastKey...)
+		}
+
+		// PK baris terakhir chunk ini, ErrNoRows berarti sisa baris kurang dari satu chunk
+		endKey := make([]interface{}, len(pkColumns))
+		dest := make([]interface{}, len(pkColumns))
+		for i := range endKey {
+			dest[i] = &endKey[i]
+		}
+		err := s.queryRow(endQuery+whereClause(conditions)+orderBy, args, dest)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to find chunk boundary: %v", err)
+		}
+
+		last := err == sql.ErrNoRows
+		if !last {
+			conditions = append(conditions, pkList+" <= "+pkPlaceholders)
+			args = append(args, endKey...)
+		}
+
+		insertCtx, insertCancel := context.WithTimeout(context.Background(), schemaStatementTimeout)
+		result, err := s.backupDB.ExecContext(insertCtx, s.target.Rebind(insertQuery+whereClause(conditions)), args...)
+		insertCancel()
+		if err != nil {
+			return fmt.Errorf("failed to copy rows: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			copied += affected
+		}
+		s.updateProgress(tableName, copied, total)
+
+		if last {
+			break
+		}
+		lastKey = endKey
+
+		if time.Since(lastLog) >= 10*time.Second {
+			log.Printf("  Shadow copy %s: %d/%d rows", tableName, copied, total)
+			lastLog = time.Now()
+		}
+	}
+
+	log.Printf("  Shadow copy %s: %d/%d rows copied", tableName, copied, total)
+	return nil
+}
+
+func (s *SchemaService) queryRow(query string, args, dest []interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	return s.backupDB.QueryRowContext(ctx, s.target.Rebind(query), args...).Scan(dest...)
+}
+
+// execDDL menjalankan satu DDL di backup dan mencatatnya di schema history.
+// Membuat index atau menulis ulang tabel besar bisa lama, timeout per statement.
+func (s *SchemaService) execDDL(tableName, stmt string) error {
+	if err := s.execHistoryDDL(tableName, models.SchemaHistoryDDL, stmt); err != nil {
+		return fmt.Errorf("failed to execute alter statement on table %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// SchemaProgress mengembalikan DDL yang sedang berjalan di backup
+func (s *SchemaService) SchemaProgress() []models.SchemaProgress {
+	s.progressMutex.Lock()
+	defer s.progressMutex.Unlock()
+
+	progress := make([]models.SchemaProgress, 0, len(s.ddlProgress))
+	for _, p := range s.ddlProgress {
+		progress = append(progress, *p)
+	}
+	return progress
+}
+
+// tableLock mengembalikan lock tabel yang dipegang syncTable dan shadow copy
+func (s *SchemaService) tableLock(tableName string) *sync.Mutex {
+	s.tableLocksMutex.Lock()
+	defer s.tableLocksMutex.Unlock()
+
+	lock, ok := s.tableLocks[tableName]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.tableLocks[tableName] = lock
+	}
+	return lock
+}
+
+// shadowCopyRunning mengecek apakah tabel sedang disalin ke tabel bayangan
+func (s *SchemaService) shadowCopyRunning(tableName string) bool {
+	s.progressMutex.Lock()
+	defer s.progressMutex.Unlock()
+
+	p, ok := s.ddlProgress[tableName]
+	return ok && p.Method == SchemaMethodShadowCopy
+}
+
+func (s *SchemaService) startProgress(tableName, method string) {
+	s.progressMutex.Lock()
+	defer s.progressMutex.Unlock()
+
+	s.ddlProgress[tableName] = &models.SchemaProgress{
+		TableName: tableName,
+		Method:    method,
+		StartedAt: time.Now(),
+	}
+}
+
+func (s *SchemaService) updateProgress(tableName string, copied, total int64) {
+	s.progressMutex.Lock()
+	defer s.progressMutex.Unlock()
+
+	if p, ok := s.ddlProgress[tableName]; ok {
+		p.CopiedRows = copied
+		p.TotalRows = total
+	}
+}
+
+func (s *SchemaService) finishProgress(tableName string) {
+	s.progressMutex.Lock()
+	defer s.progressMutex.Unlock()
+
+	delete(s.ddlProgress, tableName)
+}
+
+// changeObjectKey mengidentifikasi object yang diubah satu SchemaChange. Drop dan
+// add index dengan nama yang sama boleh berada di satu ALTER.
+func changeObjectKey(change models.SchemaChange) string {
+	switch change.Kind {
+	case "add_column", "modify_column", "drop_column", "move_column":
+		return "column:" + change.Object
+	default:
+		return change.Kind + ":" + change.Object
+	}
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
@@ -3,63 +3,113 @@ package services
 import (
 	"context"
 	"database/sql"
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
 	"db-sync-scheduler/internal/models"
 	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+const schemaStatementTimeout = 5 * time.Minute
+
 type SchemaService struct {
 	masterDB *sql.DB
 	backupDB *sql.DB
+	source   dialect.Dialect
+	target   dialect.Dialect
+
+	config *config.AppConfig
+
+	// fkDeferred: tabel dibuat tanpa FK sampai initial load selesai
+	fkDeferred bool
+	fkMutex    sync.Mutex
+
+	// Rename kolom yang menunggu konfirmasi (RenameDetectionConfirm), di-key renameKey
+	pendingRenames  map[string]models.ColumnRename
+	renameDecisions map[string]bool
+	renameMutex     sync.Mutex
+
+	// Rename tabel yang menunggu konfirmasi, di-key renameKey tanpa nama tabel
+	pendingTableRenames  map[string]models.TableRename
+	tableRenameDecisions map[string]bool
+
+	// approvals berisi DDL yang sudah disetujui (approvalKey), heldTables tabel
+	// yang DDL-nya masih menunggu approval beserta alasannya
+	approvals     map[string]bool
+	heldTables    map[string]string
+	approvalMutex sync.Mutex
+
+	// ddlProgress berisi ALTER atau shadow copy yang sedang berjalan per tabel
+	ddlProgress   map[string]*models.SchemaProgress
+	progressMutex sync.Mutex
+
+	// tableLocks membuat sync data dan shadow copy tabel yang sama bergiliran
+	tableLocks      map[string]*sync.Mutex
+	tableLocksMutex sync.Mutex
+
+	// Satu sinkronisasi schema berjalan pada satu waktu (beginRun). knownDefinitions
+	// berisi definisi tabel backup terakhir yang diketahui untuk deteksi drift.
+	// desired: masterDB berisi desired schema dari file (NewDesiredSchemaService)
+	desired bool
+
+	history          *SchemaHistoryService
+	runMutex         sync.Mutex
+	runID            string
+	runSeq           int
+	knownDefinitions map[string]string
+	knownLoaded      bool
+
+	// Daftar snapshot tabel sebelum DDL yang bisa menghapus data (SchemaSnapshotMode)
+	snapshotMutex  sync.Mutex
+	snapshotsReady bool
 }
 
-func NewSchemaService(masterDB, backupDB *sql.DB) *SchemaService {
+func NewSchemaService(masterDB, backupDB *sql.DB, source, target dialect.Dialect, history *SchemaHistoryService, cfg *config.AppConfig) *SchemaService {
 	return &SchemaService{
-		masterDB: masterDB,
-		backupDB: backupDB,
+		masterDB:             masterDB,
+		backupDB:             backupDB,
+		source:               source,
+		target:               target,
+		config:               cfg,
+		fkDeferred:           cfg.Sync.DeferForeignKeys && canAddForeignKeys(target),
+		pendingRenames:       make(map[string]models.ColumnRename),
+		renameDecisions:      make(map[string]bool),
+		pendingTableRenames:  make(map[string]models.TableRename),
+		tableRenameDecisions: make(map[string]bool),
+		approvals:            make(map[string]bool),
+		heldTables:           make(map[string]string),
+		ddlProgress:          make(map[string]*models.SchemaProgress),
+		tableLocks:           make(map[string]*sync.Mutex),
+		history:              history,
+		knownDefinitions:     make(map[string]string),
 	}
 }
 
+// Source mengembalikan dialect database master
+func (s *SchemaService) Source() dialect.Dialect {
+	return s.source
+}
+
+// Target mengembalikan dialect database backup
+func (s *SchemaService) Target() dialect.Dialect {
+	return s.target
+}
+
 func (s *SchemaService) GetForeignKeys(tableName string) ([]models.ForeignKey, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := `SELECT
-	            kcu.TABLE_NAME,
-	            kcu.COLUMN_NAME,
-	            kcu.REFERENCED_TABLE_NAME,
-	            kcu.REFERENCED_COLUMN_NAME,
-	            kcu.CONSTRAINT_NAME
-	          FROM information_schema.KEY_COLUMN_USAGE kcu
-	          WHERE kcu.TABLE_SCHEMA = DATABASE()
-	          AND kcu.TABLE_NAME = ?
-	          AND kcu.REFERENCED_TABLE_NAME IS NOT NULL`
-
-	rows, err := s.masterDB.QueryContext(ctx, query, tableName)
+	fks, err := s.source.GetForeignKeys(ctx, s.masterDB, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get foreign keys: %v", err)
 	}
-	defer rows.Close()
-
-	var fks []models.ForeignKey
-	for rows.Next() {
-		var fk models.ForeignKey
-		err := rows.Scan(
-			&fk.TableName,
-			&fk.ColumnName,
-			&fk.ReferencedTableName,
-			&fk.ReferencedColumnName,
-			&fk.ConstraintName,
-		)
-		if err != nil {
-			return nil, err
-		}
-		fks = append(fks, fk)
-	}
 
-	return fks, rows.Err()
+	return fks, nil
 }
 
 func (s *SchemaService) GetAllTablesWithDependencies() ([]models.TableDependency, error) {
@@ -88,15 +138,16 @@ func (s *SchemaService) GetAllTablesWithDependencies() ([]models.TableDependency
 		}
 
 		for _, fk := range fks {
-			// Skip self-references
+			// Self-reference tidak mempengaruhi urutan tabel, barisnya dimuat
+			// parent dulu saat sync (lihat selfRefLoader)
 			if fk.ReferencedTableName == table {
-				log.Printf("Table %s has self-reference, will handle separately", table)
+				depMap[table].SelfReferencing = true
 				continue
 			}
 
-			// Add dependency
-			if _, exists := depMap[table]; exists {
-				depMap[table].DependsOn = append(depMap[table].DependsOn, fk.ReferencedTableName)
+			// Add dependency, FK komposit atau beberapa FK ke tabel yang sama cukup sekali
+			if dep, exists := depMap[table]; exists && !slices.Contains(dep.DependsOn, fk.ReferencedTableName) {
+				dep.DependsOn = append(dep.DependsOn, fk.ReferencedTableName)
 			}
 		}
 	}
@@ -110,157 +161,164 @@ func (s *SchemaService) GetAllTablesWithDependencies() ([]models.TableDependency
 	return sorted, nil
 }
 
+// topologicalSort mengurutkan tabel berdasarkan FK memakai strongly connected
+// components (Tarjan). Tabel dalam satu siklus FK menjadi satu komponen dengan
+// level yang sama; level komponen = max(level dependency) + 1. Urutan akhir
+// deterministik: level, lalu siklus (anggota berurutan), lalu nama tabel.
 func (s *SchemaService) topologicalSort(depMap map[string]*models.TableDependency) ([]models.TableDependency, error) {
-	visited := make(map[string]bool)
-	recStack := make(map[string]bool)
-	var result []models.TableDependency
-
-	// Helper function to calculate depth (level) for each table
-	var calculateLevel func(string) int
-	calculateLevel = func(table string) int {
-		if recStack[table] {
-			// Circular dependency detected
-			depMap[table].HasCircular = true
-			log.Printf("Circular dependency detected for table: %s", table)
-			return 0
-		}
-
-		// If already calculated, return cached level
-		if visited[table] {
-			return depMap[table].Level
-		}
+	tables := make([]string, 0, len(depMap))
+	for table := range depMap {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
 
-		visited[table] = true
-		recStack[table] = true
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
 
-		dep := depMap[table]
-		maxDepLevel := 0
+	var strongConnect func(string)
+	strongConnect = func(table string) {
+		index[table] = len(index)
+		lowlink[table] = index[table]
+		stack = append(stack, table)
+		onStack[table] = true
 
-		// Calculate level based on dependencies
-		// Level = max(dependency levels) + 1
-		for _, depTable := range dep.DependsOn {
+		for _, depTable := range depMap[table].DependsOn {
 			if _, exists := depMap[depTable]; !exists {
 				// Referenced table doesn't exist in our schema, skip
 				log.Printf("Table %s references non-existent table %s", table, depTable)
 				continue
 			}
 
-			depLevel := calculateLevel(depTable)
-			if depLevel >= maxDepLevel {
-				maxDepLevel = depLevel + 1
+			if _, seen := index[depTable]; !seen {
+				strongConnect(depTable)
+				lowlink[table] = min(lowlink[table], lowlink[depTable])
+			} else if onStack[depTable] {
+				lowlink[table] = min(lowlink[table], index[depTable])
 			}
 		}
 
-		recStack[table] = false
-		dep.Level = maxDepLevel
+		if lowlink[table] != index[table] {
+			return
+		}
 
-		return maxDepLevel
+		var members []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			members = append(members, top)
+			if top == table {
+				break
+			}
+		}
+		sort.Strings(members)
+		components = append(components, members)
 	}
 
-	// Calculate level for all tables
-	for table := range depMap {
-		if !visited[table] {
-			calculateLevel(table)
+	for _, table := range tables {
+		if _, seen := index[table]; !seen {
+			strongConnect(table)
 		}
 	}
 
-	// Convert map to sorted slice by level
-	for _, dep := range depMap {
-		result = append(result, *dep)
-	}
+	// Tarjan menghasilkan komponen setelah semua dependency-nya, jadi level
+	// dependency selalu sudah dihitung
+	componentOf := make(map[string]int)
+	levels := make([]int, len(components))
+	for i, members := range components {
+		for _, table := range members {
+			componentOf[table] = i
+		}
 
-	// Sort by level (tables with no dependencies first, level 0 → 1 → 2...)
-	for i := 0; i < len(result); i++ {
-		for j := i + 1; j < len(result); j++ {
-			if result[i].Level > result[j].Level {
-				result[i], result[j] = result[j], result[i]
+		for _, table := range members {
+			for _, depTable := range depMap[table].DependsOn {
+				j, exists := componentOf[depTable]
+				if !exists || j == i {
+					continue
+				}
+				levels[i] = max(levels[i], levels[j]+1)
+			}
+		}
+
+		if len(members) > 1 {
+			log.Printf("Circular dependency detected between tables: %s", strings.Join(members, ", "))
+		}
+
+		for _, table := range members {
+			depMap[table].Level = levels[i]
+			if len(members) > 1 {
+				depMap[table].HasCircular = true
+				depMap[table].Cycle = members
 			}
 		}
 	}
 
+	result := make([]models.TableDependency, 0, len(depMap))
+	for _, table := range tables {
+		result = append(result, *depMap[table])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Level != result[j].Level {
+			return result[i].Level < result[j].Level
+		}
+		if gi, gj := cycleKey(result[i]), cycleKey(result[j]); gi != gj {
+			return gi < gj
+		}
+		return result[i].TableName < result[j].TableName
+	})
+
 	return result, nil
 }
 
+// cycleKey mengelompokkan anggota satu siklus FK supaya berurutan
+func cycleKey(dep models.TableDependency) string {
+	if len(dep.Cycle) > 0 {
+		return dep.Cycle[0]
+	}
+	return dep.TableName
+}
+
 func (s *SchemaService) GetAllTables() ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := `SELECT TABLE_NAME
-	          FROM information_schema.TABLES
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_TYPE = 'BASE TABLE'
-	          ORDER BY TABLE_NAME`
-
-	rows, err := s.masterDB.QueryContext(ctx, query)
+	allTables, err := s.source.ListTables(ctx, s.masterDB)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tables: %v", err)
 	}
-	defer rows.Close()
 
+	// Tabel internal db_sync (mis. changelog di master) tidak ikut disinkronkan
 	var tables []string
-	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
-			return nil, err
+	for _, table := range allTables {
+		if !strings.HasPrefix(table, internalTablePrefix) {
+			tables = append(tables, table)
 		}
-		tables = append(tables, tableName)
 	}
 
-	return tables, rows.Err()
+	return tables, nil
 }
 
 func (s *SchemaService) GetTableSchema(tableName string) ([]models.ColumnInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := `SELECT
-	            COLUMN_NAME,
-	            DATA_TYPE,
-	            COLUMN_TYPE,
-	            IS_NULLABLE,
-	            COLUMN_KEY,
-	            COLUMN_DEFAULT,
-	            EXTRA
-	          FROM information_schema.COLUMNS
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_NAME = ?
-	          ORDER BY ORDINAL_POSITION`
-
-	rows, err := s.masterDB.QueryContext(ctx, query, tableName)
+	columns, err := s.source.GetColumns(ctx, s.masterDB, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table schema: %v", err)
 	}
-	defer rows.Close()
-
-	var columns []models.ColumnInfo
-	for rows.Next() {
-		var col models.ColumnInfo
-		err := rows.Scan(
-			&col.ColumnName,
-			&col.DataType,
-			&col.ColumnType,
-			&col.IsNullable,
-			&col.ColumnKey,
-			&col.ColumnDefault,
-			&col.Extra,
-		)
-		if err != nil {
-			return nil, err
-		}
-		columns = append(columns, col)
-	}
 
-	return columns, rows.Err()
+	return columns, nil
 }
 
 func (s *SchemaService) GetTableCreateStatement(tableName string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := fmt.Sprintf("SHOW CREATE TABLE `%s`", tableName)
-
-	var table, createStmt string
-	err := s.masterDB.QueryRowContext(ctx, query).Scan(&table, &createStmt)
+	createStmt, err := s.source.ShowCreateTable(ctx, s.masterDB, tableName)
 	if err != nil {
 		return "", fmt.Errorf("failed to get create statement: %v", err)
 	}
@@ -272,43 +330,72 @@ func (s *SchemaService) TableExists(tableName string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := `SELECT COUNT(*)
-	          FROM information_schema.TABLES
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_NAME = ?`
+	return s.target.TableExists(ctx, s.backupDB, tableName)
+}
 
-	var count int
-	err := s.backupDB.QueryRowContext(ctx, query, tableName).Scan(&count)
-	if err != nil {
-		return false, err
+// CreateTablePlan menghasilkan DDL untuk membuat tabel di backup beserta index
+// dan FK yang belum termasuk di CREATE TABLE, tanpa menjalankannya
+func (s *SchemaService) CreateTablePlan(tableName string) ([]models.SchemaChange, error) {
+	// DDL asli master hanya bisa dipakai jika dialect backup sama
+	var sourceCreate string
+	if s.source.Name() == s.target.Name() {
+		createStmt, err := s.GetTableCreateStatement(tableName)
+		if err != nil {
+			return nil, err
+		}
+		sourceCreate = createStmt
+
+		// FK dibuat setelah data dimuat (lihat CreateDeferredForeignKeys)
+		if s.ForeignKeysDeferred() {
+			sourceCreate = s.target.StripForeignKeys(sourceCreate)
+		}
 	}
 
-	return count > 0, nil
-}
+	columns, err := s.GetTableSchema(tableName)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *SchemaService) CreateTable(tableName string) error {
-	log.Printf("Creating table: %s", tableName)
+	createStmt := s.target.CreateTableStatement(tableName, columns, sourceCreate)
+	changes := []models.SchemaChange{{
+		TableName: tableName,
+		Kind:      "create_table",
+		Object:    tableName,
+		Statement: createStmt,
+	}}
 
-	// Dapatkan CREATE TABLE statement dari master
-	createStmt, err := s.GetTableCreateStatement(tableName)
+	// DDL hasil generate (dan DDL tabel SQLite) belum berisi index dan FK,
+	// DDL asli MySQL sudah berisi keduanya
+	_, indexAdds, err := s.compareIndexes(tableName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for _, change := range indexAdds {
+		if !s.definedInCreate(createStmt, "KEY", change.Object) {
+			changes = append(changes, change)
+		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Execute CREATE TABLE di backup database
-	_, err = s.backupDB.ExecContext(ctx, createStmt)
+	_, fkAdds, err := s.compareForeignKeys(tableName, false)
 	if err != nil {
-		return fmt.Errorf("failed to create table %s: %v", tableName, err)
+		return nil, err
+	}
+	for _, change := range fkAdds {
+		if !s.definedInCreate(createStmt, "CONSTRAINT", change.Object) {
+			changes = append(changes, change)
+		}
 	}
 
-	log.Printf("Table created successfully: %s", tableName)
-	return nil
+	return changes, nil
+}
+
+// definedInCreate mengecek apakah index atau constraint sudah didefinisikan di CREATE TABLE
+func (s *SchemaService) definedInCreate(createStmt, keyword, name string) bool {
+	return strings.Contains(createStmt, keyword+" "+s.target.QuoteIdentifier(name))
 }
 
-func (s *SchemaService) CompareSchemas(tableName string) ([]string, error) {
+// CompareSchemas membandingkan schema master dan backup, menghasilkan DDL untuk backup
+func (s *SchemaService) CompareSchemas(tableName string) ([]models.SchemaChange, error) {
 	masterColumns, err := s.GetTableSchema(tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get master schema: %v", err)
@@ -319,158 +406,110 @@ func (s *SchemaService) CompareSchemas(tableName string) ([]string, error) {
 		return nil, fmt.Errorf("failed to get backup schema: %v", err)
 	}
 
-	backupColMap := make(map[string]models.ColumnInfo)
-	for _, col := range backupColumns {
-		backupColMap[col.ColumnName] = col
+	// FK di-drop paling awal dan ditambahkan paling akhir, karena FK bergantung
+	// pada kolom dan index (MySQL menolak drop index yang dipakai FK). Index lama
+	// di-drop sebelum kolomnya di-drop, index baru dibuat setelah kolomnya ada.
+	fkDrops, fkAdds, err := s.compareForeignKeys(tableName, true)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate ALTER statements
-	var alterStatements []string
-
-	for _, masterCol := range masterColumns {
-		backupCol, exists := backupColMap[masterCol.ColumnName]
-
-		if !exists {
-			// Kolom baru, perlu ditambahkan
-			alterStmt := s.generateAddColumnStatement(tableName, masterCol)
-			alterStatements = append(alterStatements, alterStmt)
-		} else if s.columnsDifferent(masterCol, backupCol) {
-			// Kolom sudah ada tapi berbeda, perlu dimodifikasi
-			alterStmt := s.generateModifyColumnStatement(tableName, masterCol)
-			alterStatements = append(alterStatements, alterStmt)
-		}
+	indexDrops, indexAdds, err := s.compareIndexes(tableName)
+	if err != nil {
+		return nil, err
 	}
 
-	return alterStatements, nil
-}
-
-func (s *SchemaService) getBackupTableSchema(tableName string) ([]models.ColumnInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	query := `SELECT
-	            COLUMN_NAME,
-	            DATA_TYPE,
-	            COLUMN_TYPE,
-	            IS_NULLABLE,
-	            COLUMN_KEY,
-	            COLUMN_DEFAULT,
-	            EXTRA
-	          FROM information_schema.COLUMNS
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_NAME = ?
-	          ORDER BY ORDINAL_POSITION`
-
-	rows, err := s.backupDB.QueryContext(ctx, query, tableName)
+	// Partisi diubah paling akhir karena MySQL mensyaratkan setiap unique key
+	// memuat kolom partisi, index baru harus sudah ada
+	tableOptions, partitions, err := s.compareTableOptions(tableName)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var columns []models.ColumnInfo
-	for rows.Next() {
-		var col models.ColumnInfo
-		err := rows.Scan(
-			&col.ColumnName,
-			&col.DataType,
-			&col.ColumnType,
-			&col.IsNullable,
-			&col.ColumnKey,
-			&col.ColumnDefault,
-			&col.Extra,
-		)
-		if err != nil {
-			return nil, err
-		}
-		columns = append(columns, col)
-	}
 
-	return columns, rows.Err()
-}
+	columnChanges := s.compareColumns(tableName, masterColumns, backupColumns)
 
-func (s *SchemaService) columnsDifferent(col1, col2 models.ColumnInfo) bool {
-	return col1.ColumnType != col2.ColumnType ||
-		col1.IsNullable != col2.IsNullable ||
-		col1.Extra != col2.Extra
+	return concatChanges(fkDrops, indexDrops, tableOptions, columnChanges, indexAdds, fkAdds, partitions), nil
 }
 
-func (s *SchemaService) generateAddColumnStatement(tableName string, col models.ColumnInfo) string {
-	var parts []string
-	parts = append(parts, fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s",
-		tableName, col.ColumnName, col.ColumnType))
-
-	if col.IsNullable == "NO" {
-		parts = append(parts, "NOT NULL")
-	}
-
-	if col.ColumnDefault != nil {
-		parts = append(parts, fmt.Sprintf("DEFAULT %s", *col.ColumnDefault))
+// concatChanges menggabungkan beberapa kelompok DDL sesuai urutan eksekusi
+func concatChanges(groups ...[]models.SchemaChange) []models.SchemaChange {
+	var changes []models.SchemaChange
+	for _, group := range groups {
+		changes = append(changes, group...)
 	}
-
-	if col.Extra != "" {
-		parts = append(parts, col.Extra)
-	}
-
-	return strings.Join(parts, " ")
+	return changes
 }
 
-func (s *SchemaService) generateModifyColumnStatement(tableName string, col models.ColumnInfo) string {
-	var parts []string
-	parts = append(parts, fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN `%s` %s",
-		tableName, col.ColumnName, col.ColumnType))
-
-	if col.IsNullable == "NO" {
-		parts = append(parts, "NOT NULL")
-	}
+func (s *SchemaService) getBackupTableSchema(tableName string) ([]models.ColumnInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	if col.ColumnDefault != nil {
-		parts = append(parts, fmt.Sprintf("DEFAULT %s", *col.ColumnDefault))
-	}
+	return s.target.GetColumns(ctx, s.backupDB, tableName)
+}
 
-	if col.Extra != "" {
-		parts = append(parts, col.Extra)
-	}
+func (s *SchemaService) SyncSchema(tableName string) error {
+	s.beginRun()
+	defer s.endRun()
 
-	return strings.Join(parts, " ")
+	return s.syncSchema(tableName)
 }
 
-func (s *SchemaService) SyncSchema(tableName string) error {
+func (s *SchemaService) syncSchema(tableName string) error {
 	log.Printf("Checking schema for table: %s", tableName)
 
-	// Cek apakah tabel sudah ada di backup
-	exists, err := s.TableExists(tableName)
+	// Perubahan langsung di backup dicatat sebelum DDL db_sync dijalankan
+	s.checkDrift(tableName)
+
+	plan, err := s.PlanSchema(tableName)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		// Tabel belum ada, buat baru
-		return s.CreateTable(tableName)
+	if len(plan.Changes) == 0 {
+		s.holdTable(tableName, "")
+		if plan.Action == PlanActionRename {
+			log.Printf("Table %s is waiting for rename confirmation (GET /api/schema/table-renames)", tableName)
+		} else if plan.Action != PlanActionDrop {
+			log.Printf("Schema already in sync for table: %s", tableName)
+		}
+		return nil
 	}
 
-	// Tabel sudah ada, bandingkan schema
-	alterStatements, err := s.CompareSchemas(tableName)
-	if err != nil {
-		return err
+	// DDL ditulis sebagai file migration, approval dilakukan saat review migration
+	if s.config.Sync.SchemaMigrationDir != "" {
+		return s.writeMigration(plan)
 	}
 
-	if len(alterStatements) == 0 {
-		log.Printf("Schema already in sync for table: %s", tableName)
+	if plan.Blocked {
+		reason := heldReason(plan.Changes)
+		s.holdTable(tableName, reason)
+		log.Printf("Schema changes for table %s are waiting for approval (GET /api/schema/diff): %s", tableName, reason)
 		return nil
 	}
 
-	// Execute ALTER statements
-	log.Printf("Found %d schema differences for table: %s", len(alterStatements), tableName)
+	switch plan.Action {
+	case PlanActionCreate:
+		log.Printf("Creating table: %s", tableName)
+	case PlanActionRename:
+		log.Printf("Renaming backup table %s to %s", plan.Changes[0].Object, tableName)
+	case PlanActionAlter:
+		log.Printf("Found %d schema differences for table: %s", len(plan.Changes), tableName)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	if err := s.snapshotBeforeChanges(plan); err != nil {
+		return err
+	}
 
-	for _, stmt := range alterStatements {
-		log.Printf("  Executing: %s", stmt)
-		_, err := s.backupDB.ExecContext(ctx, stmt)
-		if err != nil {
-			return fmt.Errorf("failed to execute alter statement: %v", err)
-		}
+	if err := s.applyChanges(plan.Changes); err != nil {
+		return err
+	}
+
+	s.clearApprovals(tableName)
+	s.holdTable(tableName, "")
+
+	// Tabel hasil rename disamakan lagi dengan master di run yang sama
+	if plan.Action == PlanActionRename {
+		return s.syncSchema(tableName)
 	}
 
 	log.Printf("Schema synchronized for table: %s", tableName)
@@ -481,6 +520,9 @@ func (s *SchemaService) SyncSchema(tableName string) error {
 func (s *SchemaService) SyncAllSchemas() error {
 	log.Println("Starting schema synchronization...")
 
+	s.beginRun()
+	defer s.endRun()
+
 	// Get tables with dependency ordering
 	tableDeps, err := s.GetAllTablesWithDependencies()
 	if err != nil {
@@ -499,13 +541,32 @@ func (s *SchemaService) SyncAllSchemas() error {
 				dep.TableName, dep.Level)
 		}
 
-		if err := s.SyncSchema(dep.TableName); err != nil {
+		if err := s.syncSchema(dep.TableName); err != nil {
 			log.Printf("Error syncing schema for table %s: %v", dep.TableName, err)
 			// Continue dengan tabel lainnya
 			continue
 		}
 	}
 
+	// Tabel yang hilang di master diproses setelah rename tabel dijalankan
+	_, orphans, err := s.tableSets()
+	if err != nil {
+		log.Printf("Error listing backup tables that no longer exist on master: %v", err)
+	}
+	for _, table := range orphans {
+		if err := s.syncSchema(table); err != nil {
+			log.Printf("Error syncing schema for table %s: %v", table, err)
+		}
+	}
+
+	// View, routine, trigger dan event dibuat setelah semua tabel ada. Desired
+	// schema dan file migration hanya berisi tabel, object backup dibiarkan apa adanya.
+	if !s.desired && s.config.Sync.SchemaMigrationDir == "" {
+		if err := s.syncSchemaObjects(); err != nil {
+			log.Printf("Error syncing views, routines, triggers and events: %v", err)
+		}
+	}
+
 	log.Println("Schema synchronization completed")
 	return nil
 }
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+)
+
+// newMySQLSchemaService membuat SchemaService tanpa koneksi database untuk
+// menguji logika perbandingan schema dengan dialect MySQL di kedua sisi
+func newMySQLSchemaService(t *testing.T, cfg *config.AppConfig) *SchemaService {
+	t.Helper()
+
+	d, err := dialect.New("mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil {
+		cfg = &config.AppConfig{}
+	}
+	return NewSchemaService(nil, nil, d, d, nil, cfg)
+}
+
+func TestTopologicalSort(t *testing.T) {
+	type want struct {
+		table string
+		level int
+		cycle []string
+	}
+
+	tests := []struct {
+		name string
+		deps map[string][]string
+		want []want
+	}{
+		{
+			name: "same level sorted by name",
+			deps: map[string][]string{"products": nil, "customers": nil, "offices": nil},
+			want: []want{{"customers", 0, nil}, {"offices", 0, nil}, {"products", 0, nil}},
+		},
+		{
+			name: "classicmodels",
+			deps: map[string][]string{
+				"productlines": nil,
+				"products":     {"productlines"},
+				"offices":      nil,
+				"employees":    {"offices", "employees"},
+				"customers":    {"employees"},
+				"orders":       {"customers"},
+				"orderdetails": {"orders", "products"},
+				"payments":     {"customers"},
+			},
+			want: []want{
+				{"offices", 0, nil}, {"productlines", 0, nil},
+				{"employees", 1, nil}, {"products", 1, nil},
+				{"customers", 2, nil},
+				{"orders", 3, nil}, {"payments", 3, nil},
+				{"orderdetails", 4, nil},
+			},
+		},
+		{
+			name: "cycle members share a level and stay together",
+			deps: map[string][]string{
+				"accounts": nil,
+				"users":    {"teams", "accounts"},
+				"teams":    {"users"},
+				"audit":    {"accounts"},
+				"invoices": {"users"},
+			},
+			want: []want{
+				{"accounts", 0, nil},
+				{"audit", 1, nil},
+				{"teams", 1, []string{"teams", "users"}},
+				{"users", 1, []string{"teams", "users"}},
+				{"invoices", 2, nil},
+			},
+		},
+		{
+			name: "reference to a missing table is ignored",
+			deps: map[string][]string{"orders": {"archived_customers"}},
+			want: []want{{"orders", 0, nil}},
+		},
+	}
+
+	s := newMySQLSchemaService(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depMap := make(map[string]*models.TableDependency)
+			for table, dependsOn := range tt.deps {
+				depMap[table] = &models.TableDependency{TableName: table, DependsOn: dependsOn}
+			}
+
+			result, err := s.topologicalSort(depMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []want
+			for _, dep := range result {
+				got = append(got, want{dep.TableName, dep.Level, dep.Cycle})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("topologicalSort()\n got: %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopologicalSortIsStable(t *testing.T) {
+	s := newMySQLSchemaService(t, nil)
+	deps := map[string][]string{"c": {"a"}, "b": {"a"}, "a": nil, "e": {"d"}, "d": {"e"}}
+
+	var first []string
+	for i := 0; i < 20; i++ {
+		depMap := make(map[string]*models.TableDependency)
+		for table, dependsOn := range deps {
+			depMap[table] = &models.TableDependency{TableName: table, DependsOn: dependsOn}
+		}
+		result, err := s.topologicalSort(depMap)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var order []string
+		for _, dep := range result {
+			order = append(order, dep.TableName)
+		}
+		if first == nil {
+			first = order
+		} else if !reflect.DeepEqual(order, first) {
+			t.Fatalf("run %d order %v differs from %v", i, order, first)
+		}
+	}
+}
@@ -0,0 +1,598 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mode snapshot tabel sebelum DDL yang bisa menghapus data
+const (
+	SchemaSnapshotOff   = "off"
+	SchemaSnapshotTable = "table"
+	SchemaSnapshotFile  = "file"
+)
+
+const schemaSnapshotTable = "_db_sync_schema_snapshots"
+
+// snapshotKinds adalah jenis SchemaChange yang bisa menghapus atau memotong data backup
+var snapshotKinds = map[string]bool{
+	"modify_column":             true,
+	"drop_column":               true,
+	"drop_table":                true,
+	dialect.DropPartition:       true,
+	dialect.ReorganizePartition: true,
+}
+
+// schemaSnapshotColumns adalah struktur tabel daftar snapshot, dirender oleh target backup
+var schemaSnapshotColumns = []models.ColumnInfo{
+	dialect.InternalColumn("id", "varchar", "varchar(64)", true, true),
+	dialect.InternalColumn("table_name", "varchar", "varchar(64)", true, false),
+	dialect.InternalColumn("mode", "varchar", "varchar(16)", true, false),
+	dialect.InternalColumn("location", "text", "text", true, false),
+	dialect.InternalColumn("columns", "longtext", "longtext", true, false),
+	dialect.InternalColumn("definition", "longtext", "longtext", true, false),
+	dialect.InternalColumn("row_count", "bigint", "bigint", true, false),
+	dialect.InternalColumn("reason", "longtext", "longtext", false, false),
+	dialect.InternalColumn("created_at", "datetime", "datetime", true, false),
+}
+
+// snapshotFileHeader adalah baris pertama file snapshot, baris berikutnya satu
+// array JSON per baris tabel sesuai urutan Columns. Types menandai cara nilai
+// tiap kolom ditulis; kolom snapshotBinary ditulis sebagai base64.
+type snapshotFileHeader struct {
+	TableName  string    `json:"table_name"`
+	Columns    []string  `json:"columns"`
+	Types      []string  `json:"types,omitempty"`
+	Definition []string  `json:"definition"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// snapshotBinary adalah penanda kolom biner di snapshotFileHeader.Types
+const snapshotBinary = "binary"
+
+// ensureSnapshotTable membuat tabel daftar snapshot di backup database jika belum ada
+func (s *SchemaService) ensureSnapshotTable() error {
+	s.snapshotMutex.Lock()
+	defer s.snapshotMutex.Unlock()
+
+	if s.snapshotsReady {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := s.target.TableExists(ctx, s.backupDB, schemaSnapshotTable)
+	if err != nil {
+		return fmt.Errorf("failed to check schema snapshot table: %v", err)
+	}
+
+	if !exists {
+		query := s.target.CreateTableStatement(schemaSnapshotTable, schemaSnapshotColumns, "")
+		if _, err := s.backupDB.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create schema snapshot table: %v", err)
+		}
+	}
+
+	s.snapshotsReady = true
+	return nil
+}
+
+// snapshotBeforeChanges mengambil snapshot tabel backup jika plan berisi DDL yang
+// bisa menghapus data. DDL tidak dijalankan jika snapshot gagal diambil.
+func (s *SchemaService) snapshotBeforeChanges(plan models.TablePlan) error {
+	mode := s.config.Sync.SchemaSnapshotMode
+	if mode == "" || mode == SchemaSnapshotOff {
+		return nil
+	}
+
+	var reasons []string
+	for _, change := range plan.Changes {
+		if snapshotKinds[change.Kind] {
+			reasons = append(reasons, change.Statement)
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	snapshot, err := s.takeSnapshot(plan.TableName, strings.Join(reasons, ";\n"))
+	if err != nil {
+		return fmt.Errorf("failed to snapshot table %s before schema change: %v", plan.TableName, err)
+	}
+	log.Printf("Snapshot %s of table %s taken (%d rows)", snapshot.ID, plan.TableName, snapshot.RowCount)
+
+	s.pruneSnapshots()
+	return nil
+}
+
+// takeSnapshot menyalin tabel backup ke tabel _db_sync_snap_* (CREATE TABLE ...
+// LIKE lalu INSERT SELECT) atau ke file, lalu mencatatnya di daftar snapshot
+func (s *SchemaService) takeSnapshot(tableName, reason string) (*models.TableSnapshot, error) {
+	if err := s.ensureSnapshotTable(); err != nil {
+		return nil, err
+	}
+
+	definition, err := s.definitionStatements(s.backupDB, s.target, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaStatementTimeout)
+	defer cancel()
+
+	columns, err := s.target.GetColumns(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup schema: %v", err)
+	}
+
+	now := time.Now().UTC()
+	snapshot := &models.TableSnapshot{
+		ID:         snapshotName(tableName, now),
+		TableName:  tableName,
+		Mode:       s.config.Sync.SchemaSnapshotMode,
+		Definition: definition,
+		Reason:     reason,
+		CreatedAt:  now,
+	}
+	for _, col := range columns {
+		if col.GenerationExpression == "" {
+			snapshot.Columns = append(snapshot.Columns, col.ColumnName)
+		}
+	}
+
+	if snapshot.Mode == SchemaSnapshotFile {
+		err = s.snapshotToFile(ctx, snapshot)
+	} else {
+		err = s.snapshotToTable(ctx, snapshot)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columnsData, _ := json.Marshal(snapshot.Columns)
+	definitionData, _ := json.Marshal(snapshot.Definition)
+	query := fmt.Sprintf(`INSERT INTO %s
+	            (id, table_name, mode, location, columns, definition, row_count, reason, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, schemaSnapshotTable)
+
+	_, err = s.backupDB.ExecContext(ctx, s.target.Rebind(query),
+		snapshot.ID, snapshot.TableName, snapshot.Mode, snapshot.Location, string(columnsData),
+		string(definitionData), snapshot.RowCount, nullString(snapshot.Reason), snapshot.CreatedAt)
+	if err != nil {
+		s.removeSnapshotData(*snapshot)
+		return nil, fmt.Errorf("failed to record schema snapshot: %v", err)
+	}
+
+	return snapshot, nil
+}
+
+// snapshotName membuat nama tabel snapshot, di-hash jika melebihi batas 64 karakter MySQL
+func snapshotName(tableName string, at time.Time) string {
+	suffix := "_" + at.Format("20060102150405")
+	name := internalTablePrefix + "snap_" + tableName + suffix
+	if len(name) <= 64 {
+		return name
+	}
+
+	sum := md5.Sum([]byte(tableName))
+	return internalTablePrefix + "snap_" + hex.EncodeToString(sum[:]) + suffix
+}
+
+func (s *SchemaService) snapshotToTable(ctx context.Context, snapshot *models.TableSnapshot) error {
+	snapshot.Location = snapshot.ID
+	copyName := s.target.QuoteIdentifier(snapshot.ID)
+	source := s.target.QuoteIdentifier(snapshot.TableName)
+	columns := s.quoteColumns(snapshot.Columns)
+
+	// Dialect tanpa CREATE TABLE ... LIKE (SQLite) menyalin dengan CREATE TABLE AS
+	create, _, ok := s.target.ShadowTableStatements(snapshot.TableName, snapshot.ID, "")
+	stmts := []string{fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s", copyName, columns, source)}
+	if ok {
+		stmts = []string{create, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", copyName, columns, columns, source)}
+	}
+
+	for _, stmt := range stmts {
+		if _, err := s.backupDB.ExecContext(ctx, stmt); err != nil {
+			s.backupDB.ExecContext(context.Background(), "DROP TABLE IF EXISTS "+copyName)
+			return fmt.Errorf("failed to copy table: %v", err)
+		}
+	}
+
+	return s.backupDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+copyName).Scan(&snapshot.RowCount)
+}
+
+// snapshotToFile menulis definisi dan isi tabel ke <SchemaSnapshotDir>/<id>.jsonl
+func (s *SchemaService) snapshotToFile(ctx context.Context, snapshot *models.TableSnapshot) error {
+	dir := s.config.Sync.SchemaSnapshotDir
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	snapshot.Location = filepath.Join(dir, snapshot.ID+".jsonl")
+
+	file, err := os.Create(snapshot.Location)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %v", err)
+	}
+	defer file.Close()
+
+	query := fmt.Sprintf("SELECT %s FROM %s", s.quoteColumns(snapshot.Columns), s.target.QuoteIdentifier(snapshot.TableName))
+	rows, err := s.backupDB.QueryContext(ctx, query)
+	if err != nil {
+		os.Remove(snapshot.Location)
+		return fmt.Errorf("failed to read table: %v", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		os.Remove(snapshot.Location)
+		return fmt.Errorf("failed to read table: %v", err)
+	}
+	types := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		if snapshotBinaryType(columnType.DatabaseTypeName()) {
+			types[i] = snapshotBinary
+		}
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	if err := encoder.Encode(snapshotFileHeader{
+		TableName:  snapshot.TableName,
+		Columns:    snapshot.Columns,
+		Types:      types,
+		Definition: snapshot.Definition,
+		CreatedAt:  snapshot.CreatedAt,
+	}); err != nil {
+		os.Remove(snapshot.Location)
+		return err
+	}
+
+	values := make([]interface{}, len(snapshot.Columns))
+	pointers := make([]interface{}, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			os.Remove(snapshot.Location)
+			return err
+		}
+		row := make([]interface{}, len(values))
+		for i, val := range values {
+			row[i] = s.snapshotValue(val, types[i])
+		}
+		if err := encoder.Encode(row); err != nil {
+			os.Remove(snapshot.Location)
+			return fmt.Errorf("failed to write snapshot file: %v", err)
+		}
+		snapshot.RowCount++
+	}
+	if err := rows.Err(); err != nil {
+		os.Remove(snapshot.Location)
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		os.Remove(snapshot.Location)
+		return fmt.Errorf("failed to write snapshot file: %v", err)
+	}
+	return nil
+}
+
+// snapshotValue menyiapkan nilai kolom untuk JSON; data biner ditulis sebagai
+// base64 dan waktu dalam format yang diterima kembali oleh backup saat restore
+func (s *SchemaService) snapshotValue(val interface{}, valueType string) interface{} {
+	switch v := val.(type) {
+	case []byte:
+		if valueType == snapshotBinary {
+			return base64.StdEncoding.EncodeToString(v)
+		}
+		return string(v)
+	case time.Time:
+		if s.target.Name() == "mysql" {
+			return v.Format("2006-01-02 15:04:05.999999")
+		}
+		return v.Format(time.RFC3339Nano)
+	}
+	return val
+}
+
+// snapshotBinaryType mengecek tipe kolom dari driver (DatabaseTypeName) yang
+// isinya byte mentah dan tidak aman ditulis sebagai string JSON
+func snapshotBinaryType(typeName string) bool {
+	t := parseColumnType(typeName)
+	switch t.base {
+	case "bit", "geometry":
+		return true
+	}
+	return isBinary(t)
+}
+
+func (s *SchemaService) quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = s.target.QuoteIdentifier(col)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Snapshots mengembalikan snapshot terbaru lebih dulu, opsional difilter per tabel
+func (s *SchemaService) Snapshots(tableName string) ([]models.TableSnapshot, error) {
+	if err := s.ensureSnapshotTable(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT id, table_name, mode, location, columns, definition, row_count, reason, created_at
+	          FROM %s`, schemaSnapshotTable)
+	var args []interface{}
+	if tableName != "" {
+		query += " WHERE table_name = ?"
+		args = append(args, tableName)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := s.backupDB.QueryContext(ctx, s.target.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema snapshots: %v", err)
+	}
+	defer rows.Close()
+
+	result := []models.TableSnapshot{}
+	for rows.Next() {
+		var snapshot models.TableSnapshot
+		var columns, definition string
+		var reason sql.NullString
+		err := rows.Scan(
+			&snapshot.ID,
+			&snapshot.TableName,
+			&snapshot.Mode,
+			&snapshot.Location,
+			&columns,
+			&definition,
+			&snapshot.RowCount,
+			&reason,
+			&snapshot.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Reason = reason.String
+		if err := json.Unmarshal([]byte(columns), &snapshot.Columns); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot columns: %v", err)
+		}
+		if err := json.Unmarshal([]byte(definition), &snapshot.Definition); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot definition: %v", err)
+		}
+		result = append(result, snapshot)
+	}
+
+	return result, rows.Err()
+}
+
+// pruneSnapshots menghapus snapshot di luar SchemaSnapshotKeep per tabel dan yang
+// lebih tua dari SchemaSnapshotMaxAgeDays
+func (s *SchemaService) pruneSnapshots() {
+	snapshots, err := s.Snapshots("")
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	keep := s.config.Sync.SchemaSnapshotKeep
+	maxAge := time.Duration(s.config.Sync.SchemaSnapshotMaxAgeDays) * 24 * time.Hour
+	perTable := make(map[string]int)
+
+	for _, snapshot := range snapshots {
+		perTable[snapshot.TableName]++
+		expired := maxAge > 0 && time.Since(snapshot.CreatedAt) > maxAge
+		if !expired && (keep <= 0 || perTable[snapshot.TableName] <= keep) {
+			continue
+		}
+
+		if err := s.deleteSnapshot(snapshot); err != nil {
+			log.Printf("Warning: failed to delete snapshot %s: %v", snapshot.ID, err)
+			continue
+		}
+		log.Printf("Deleted snapshot %s of table %s", snapshot.ID, snapshot.TableName)
+	}
+}
+
+func (s *SchemaService) deleteSnapshot(snapshot models.TableSnapshot) error {
+	if err := s.removeSnapshotData(snapshot); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", schemaSnapshotTable)
+	_, err := s.backupDB.ExecContext(ctx, s.target.Rebind(query), snapshot.ID)
+	return err
+}
+
+// removeSnapshotData menghapus tabel salinan atau file snapshot
+func (s *SchemaService) removeSnapshotData(snapshot models.TableSnapshot) error {
+	if snapshot.Mode == SchemaSnapshotFile {
+		if err := os.Remove(snapshot.Location); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	_, err := s.backupDB.ExecContext(ctx, "DROP TABLE IF EXISTS "+s.target.QuoteIdentifier(snapshot.Location))
+	return err
+}
+
+// RestoreSnapshot mengganti tabel backup dengan isi snapshot: tabel di-drop,
+// dibuat ulang dari definisi saat snapshot diambil, lalu datanya disalin kembali.
+// Snapshot tidak dihapus, sync schema berikutnya menyamakan tabel lagi dengan master.
+func (s *SchemaService) RestoreSnapshot(id string) (*models.TableSnapshot, error) {
+	s.beginRun()
+	defer s.endRun()
+
+	snapshots, err := s.Snapshots("")
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot *models.TableSnapshot
+	for i := range snapshots {
+		if snapshots[i].ID == id {
+			snapshot = &snapshots[i]
+			break
+		}
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	}
+
+	s.holdTable(snapshot.TableName, "snapshot restore in progress")
+	defer s.holdTable(snapshot.TableName, "")
+
+	before := s.backupDefinition(snapshot.TableName)
+	started := time.Now()
+
+	err = s.restoreSnapshot(*snapshot)
+
+	entry := models.SchemaHistoryEntry{
+		TableName:  snapshot.TableName,
+		Kind:       models.SchemaHistoryRestore,
+		Statement:  "-- restore snapshot " + snapshot.ID + "\n" + strings.Join(snapshot.Definition, ";\n"),
+		Before:     before,
+		After:      s.backupDefinition(snapshot.TableName),
+		ExecutedAt: started,
+		DurationMs: time.Since(started).Milliseconds(),
+		Outcome:    models.SchemaOutcomeSuccess,
+	}
+	if err != nil {
+		entry.Outcome = models.SchemaOutcomeFailed
+		entry.ErrorMessage = err.Error()
+	}
+	s.recordHistory(entry)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore snapshot %s: %v", id, err)
+	}
+
+	log.Printf("Restored table %s from snapshot %s (%d rows)", snapshot.TableName, snapshot.ID, snapshot.RowCount)
+	return snapshot, nil
+}
+
+func (s *SchemaService) restoreSnapshot(snapshot models.TableSnapshot) error {
+	ctx, cancel := context.WithTimeout(context.Background(), schemaStatementTimeout)
+	defer cancel()
+
+	// Pengecekan FK berlaku per sesi, tabel yang direferensikan tabel lain hanya
+	// bisa di-drop dan diisi ulang dengan pengecekan FK dimatikan
+	conn, err := s.backupDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	disable, enable := s.target.ForeignKeyChecksStatements()
+	if disable != "" {
+		if _, err := conn.ExecContext(ctx, disable); err != nil {
+			log.Printf("Warning: failed to disable foreign key checks on backup: %v", err)
+		} else {
+			defer conn.ExecContext(context.Background(), enable)
+		}
+	}
+
+	stmts := append([]string{"DROP TABLE IF EXISTS " + s.target.QuoteIdentifier(snapshot.TableName)}, snapshot.Definition...)
+	for _, stmt := range stmts {
+		log.Printf("  Executing: %s", stmt)
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if snapshot.Mode == SchemaSnapshotFile {
+		return s.restoreFromFile(ctx, conn, snapshot)
+	}
+
+	columns := s.quoteColumns(snapshot.Columns)
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+		s.target.QuoteIdentifier(snapshot.TableName), columns, columns, s.target.QuoteIdentifier(snapshot.Location)))
+	return err
+}
+
+func (s *SchemaService) restoreFromFile(ctx context.Context, conn *sql.Conn, snapshot models.TableSnapshot) error {
+	file, err := os.Open(snapshot.Location)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot file: %v", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	decoder.UseNumber()
+
+	var header snapshotFileHeader
+	if err := decoder.Decode(&header); err != nil {
+		return fmt.Errorf("failed to read snapshot file: %v", err)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(header.Columns)), ", ")
+	query := s.target.Rebind(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		s.target.QuoteIdentifier(snapshot.TableName), s.quoteColumns(header.Columns), placeholders))
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for decoder.More() {
+		var row []interface{}
+		if err := decoder.Decode(&row); err != nil {
+			return fmt.Errorf("failed to read snapshot file: %v", err)
+		}
+		for i, val := range row {
+			switch v := val.(type) {
+			case json.Number:
+				row[i] = v.String()
+			case string:
+				if i < len(header.Types) && header.Types[i] == snapshotBinary {
+					data, err := base64.StdEncoding.DecodeString(v)
+					if err != nil {
+						return fmt.Errorf("invalid binary value for column %s: %v", header.Columns[i], err)
+					}
+					row[i] = data
+				}
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
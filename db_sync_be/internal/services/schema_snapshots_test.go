@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"db-sync-scheduler/internal/config"
+)
+
+func TestFileSnapshotRestoresBinaryColumns(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Sync.SchemaSnapshotMode = SchemaSnapshotFile
+	cfg.Sync.SchemaSnapshotDir = t.TempDir()
+
+	ddl := []string{`CREATE TABLE files (id INTEGER PRIMARY KEY, name TEXT NOT NULL, data BLOB)`}
+	s := newSQLiteSyncService(t, cfg, nil, ddl)
+
+	// Bukan UTF-8 valid, rusak jika ditulis sebagai string JSON
+	data := []byte{0x00, 0xff, 0xfe, 'a', 0x80, '"', '\n'}
+	if _, err := s.backupDB.Exec("INSERT INTO files VALUES (1, 'logo.png', ?), (2, 'empty', NULL)", data); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := s.schemaService.takeSnapshot("files", "test")
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if snapshot.RowCount != 2 {
+		t.Fatalf("snapshot has %d rows, want 2", snapshot.RowCount)
+	}
+
+	if _, err := s.backupDB.Exec("DELETE FROM files"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.schemaService.RestoreSnapshot(snapshot.ID); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	var name string
+	var restored []byte
+	if err := s.backupDB.QueryRow("SELECT name, data FROM files WHERE id = 1").Scan(&name, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if name != "logo.png" || !bytes.Equal(restored, data) {
+		t.Fatalf("restored row = %q %x, want %q %x", name, restored, "logo.png", data)
+	}
+
+	var empty []byte
+	if err := s.backupDB.QueryRow("SELECT data FROM files WHERE id = 2").Scan(&empty); err != nil {
+		t.Fatal(err)
+	}
+	if empty != nil {
+		t.Fatalf("restored NULL blob as %x", empty)
+	}
+}
+
+func TestRestoreSchemaSnapshotResetsTableStatus(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Sync.SchemaSnapshotMode = SchemaSnapshotTable
+
+	ddl := []string{`CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT NOT NULL)`}
+	s := newSQLiteSyncService(t, cfg, nil, ddl)
+
+	if _, err := s.backupDB.Exec("INSERT INTO offices VALUES (1, 'Paris')"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := s.schemaService.takeSnapshot("offices", "test")
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	s.updateTableStatus("offices", "success", "", 7, 7)
+
+	if _, err := s.RestoreSchemaSnapshot(snapshot.ID); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	status := s.tableStatus["offices"]
+	if status.LastSyncID != 0 || !status.LastSyncTime.IsZero() {
+		t.Errorf("status after restore = id %d time %s, want progress reset", status.LastSyncID, status.LastSyncTime)
+	}
+	if status.Status != "warning" {
+		t.Errorf("status = %q, want %q", status.Status, "warning")
+	}
+}
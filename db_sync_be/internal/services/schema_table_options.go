@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Kebijakan untuk partisi RANGE/LIST backup yang sudah tidak ada di master
+const (
+	DroppedPartitionKeep = "keep"
+	DroppedPartitionDrop = "drop"
+)
+
+// compareTableOptions membandingkan engine, charset/collation default, ROW_FORMAT,
+// comment dan partisi tabel. Opsi tabel tidak portabel antar database, jadi hanya
+// dibandingkan jika master dan backup memakai dialect yang sama.
+func (s *SchemaService) compareTableOptions(tableName string) (options, partitions []models.SchemaChange, err error) {
+	if s.source.Name() != s.target.Name() {
+		return nil, nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	master, err := s.source.GetTableOptions(ctx, s.masterDB, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get master table options: %v", err)
+	}
+
+	backup, err := s.target.GetTableOptions(ctx, s.backupDB, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get backup table options: %v", err)
+	}
+
+	if master == nil || backup == nil {
+		return nil, nil, nil
+	}
+
+	differs := map[string]bool{
+		dialect.TableOptionEngine:    !strings.EqualFold(master.Engine, backup.Engine),
+		dialect.TableOptionCollation: master.Collation != backup.Collation,
+		dialect.TableOptionRowFormat: master.RowFormat != backup.RowFormat,
+		dialect.TableOptionComment:   master.Comment != backup.Comment,
+	}
+	for _, option := range []string{dialect.TableOptionEngine, dialect.TableOptionCollation, dialect.TableOptionRowFormat, dialect.TableOptionComment} {
+		if !differs[option] {
+			continue
+		}
+		stmt, ok := s.target.TableOptionStatement(tableName, option, *master)
+		if !ok {
+			continue
+		}
+		options = append(options, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "table_option",
+			Object:    option,
+			Statement: stmt,
+		})
+	}
+
+	for _, change := range s.partitionChanges(tableName, master.Partitioning, backup.Partitioning) {
+		stmt, ok := s.target.PartitionStatement(tableName, change)
+		if !ok {
+			continue
+		}
+		partitions = append(partitions, models.SchemaChange{
+			TableName:   tableName,
+			Kind:        change.Action,
+			Object:      partitionNames(change.Partitioning.Partitions),
+			Statement:   stmt,
+			Destructive: change.Action == dialect.DropPartition,
+		})
+	}
+
+	return options, partitions, nil
+}
+
+// partitionChanges menghitung operasi partisi yang membuat backup sama dengan
+// master. Partisi RANGE/LIST dengan skema yang sama diubah per partisi (rotasi
+// bulanan cukup ADD/DROP PARTITION); skema yang berbeda atau jumlah partisi
+// HASH/KEY yang berubah dipartisi ulang seluruhnya.
+func (s *SchemaService) partitionChanges(tableName string, master, backup *models.Partitioning) []models.PartitionChange {
+	switch {
+	case master == nil && backup == nil:
+		return nil
+	case master == nil:
+		return []models.PartitionChange{{Action: dialect.RemovePartitioning}}
+	case backup == nil || !samePartitionScheme(*master, *backup):
+		return []models.PartitionChange{{Action: dialect.PartitionBy, Partitioning: *master}}
+	case !strings.HasPrefix(master.Method, "RANGE") && !strings.HasPrefix(master.Method, "LIST"):
+		if len(master.Partitions) != len(backup.Partitions) {
+			return []models.PartitionChange{{Action: dialect.PartitionBy, Partitioning: *master}}
+		}
+		return nil
+	}
+
+	backupByName := make(map[string]models.Partition)
+	for _, part := range backup.Partitions {
+		backupByName[part.Name] = part
+	}
+	masterNames := make(map[string]bool)
+	var added []models.Partition
+	for _, part := range master.Partitions {
+		masterNames[part.Name] = true
+		existing, exists := backupByName[part.Name]
+		if !exists {
+			added = append(added, part)
+			continue
+		}
+		if existing.Description != part.Description {
+			log.Printf("Partition %s.%s bounds changed on master, repartitioning backup table", tableName, part.Name)
+			return []models.PartitionChange{{Action: dialect.PartitionBy, Partitioning: *master}}
+		}
+	}
+
+	var changes []models.PartitionChange
+	dropRemoved := s.config.Sync.DroppedPartitionPolicy == DroppedPartitionDrop
+	var removed []models.Partition
+	for _, part := range backup.Partitions {
+		if !masterNames[part.Name] {
+			removed = append(removed, part)
+		}
+	}
+	if len(removed) > 0 && dropRemoved {
+		log.Printf("Partitions %s.%s no longer exist on master, dropping them from backup", tableName, partitionNames(removed))
+		changes = append(changes, models.PartitionChange{
+			Action:       dialect.DropPartition,
+			Partitioning: models.Partitioning{Method: master.Method, Partitions: removed},
+		})
+	}
+
+	if len(added) == 0 {
+		return changes
+	}
+
+	// Partisi RANGE baru hanya bisa ditambahkan di atas partisi terakhir; jika
+	// backup punya partisi MAXVALUE yang tetap ada, partisi itu dipecah
+	last := backup.Partitions[len(backup.Partitions)-1]
+	catchAll := strings.HasPrefix(master.Method, "RANGE") && strings.Contains(last.Description, "MAXVALUE") &&
+		(masterNames[last.Name] || !dropRemoved)
+	if catchAll {
+		changes = append(changes, models.PartitionChange{
+			Action:       dialect.ReorganizePartition,
+			Partitioning: models.Partitioning{Method: master.Method, Partitions: append(added, last)},
+			Reorganize:   last.Name,
+		})
+		return changes
+	}
+
+	changes = append(changes, models.PartitionChange{
+		Action:       dialect.AddPartition,
+		Partitioning: models.Partitioning{Method: master.Method, Partitions: added},
+	})
+	return changes
+}
+
+// samePartitionScheme membandingkan metode dan ekspresi partisi tanpa
+// memperhatikan quote dan spasi
+func samePartitionScheme(a, b models.Partitioning) bool {
+	normalize := func(expr string) string {
+		return strings.ToLower(strings.NewReplacer("`", "", " ", "").Replace(expr))
+	}
+	return a.Method == b.Method && normalize(a.Expression) == normalize(b.Expression)
+}
+
+func partitionNames(partitions []models.Partition) string {
+	names := make([]string, len(partitions))
+	for i, part := range partitions {
+		names[i] = part.Name
+	}
+	return strings.Join(names, ",")
+}
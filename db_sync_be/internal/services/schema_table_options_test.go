@@ -0,0 +1,126 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+)
+
+func TestPartitionChanges(t *testing.T) {
+	monthly := func(names ...string) *models.Partitioning {
+		bounds := map[string]string{
+			"p202401": "'2024-02-01'", "p202402": "'2024-03-01'", "p202403": "'2024-04-01'",
+			"p202404": "'2024-05-01'", "pmax": "MAXVALUE",
+		}
+		p := &models.Partitioning{Method: "RANGE COLUMNS", Expression: "`created_at`"}
+		for _, name := range names {
+			p.Partitions = append(p.Partitions, models.Partition{Name: name, Description: bounds[name]})
+		}
+		return p
+	}
+	partitions := func(p *models.Partitioning, names ...string) models.Partitioning {
+		result := models.Partitioning{Method: p.Method}
+		for _, part := range p.Partitions {
+			for _, name := range names {
+				if part.Name == name {
+					result.Partitions = append(result.Partitions, part)
+				}
+			}
+		}
+		return result
+	}
+	hash := func(n int) *models.Partitioning {
+		p := &models.Partitioning{Method: "HASH", Expression: "id"}
+		for i := 0; i < n; i++ {
+			p.Partitions = append(p.Partitions, models.Partition{Name: string(rune('a' + i))})
+		}
+		return p
+	}
+
+	rotated := monthly("p202402", "p202403", "p202404")
+	rotatedWithMax := monthly("p202402", "p202403", "p202404", "pmax")
+
+	tests := []struct {
+		name   string
+		policy string
+		master *models.Partitioning
+		backup *models.Partitioning
+		want   []models.PartitionChange
+	}{
+		{
+			name:   "not partitioned",
+			master: nil, backup: nil,
+		},
+		{
+			name:   "same partitions",
+			master: monthly("p202401", "p202402"), backup: monthly("p202401", "p202402"),
+		},
+		{
+			name:   "partitioning removed on master",
+			master: nil, backup: monthly("p202401"),
+			want: []models.PartitionChange{{Action: dialect.RemovePartitioning}},
+		},
+		{
+			name:   "backup not partitioned yet",
+			master: monthly("p202401"), backup: nil,
+			want: []models.PartitionChange{{Action: dialect.PartitionBy, Partitioning: *monthly("p202401")}},
+		},
+		{
+			name:   "monthly rotation keeps old partitions by default",
+			master: rotated, backup: monthly("p202401", "p202402", "p202403"),
+			want: []models.PartitionChange{{Action: dialect.AddPartition, Partitioning: partitions(rotated, "p202404")}},
+		},
+		{
+			name:   "monthly rotation drops old partitions with drop policy",
+			policy: DroppedPartitionDrop,
+			master: rotated, backup: monthly("p202401", "p202402", "p202403"),
+			want: []models.PartitionChange{
+				{Action: dialect.DropPartition, Partitioning: partitions(monthly("p202401"), "p202401")},
+				{Action: dialect.AddPartition, Partitioning: partitions(rotated, "p202404")},
+			},
+		},
+		{
+			name:   "new month splits the MAXVALUE partition",
+			master: rotatedWithMax, backup: monthly("p202402", "p202403", "pmax"),
+			want: []models.PartitionChange{{
+				Action:       dialect.ReorganizePartition,
+				Partitioning: partitions(rotatedWithMax, "p202404", "pmax"),
+				Reorganize:   "pmax",
+			}},
+		},
+		{
+			name:   "changed bounds repartition the table",
+			master: &models.Partitioning{Method: "RANGE COLUMNS", Expression: "`created_at`", Partitions: []models.Partition{{Name: "p202401", Description: "'2024-01-15'"}}},
+			backup: monthly("p202401"),
+			want: []models.PartitionChange{{Action: dialect.PartitionBy, Partitioning: models.Partitioning{
+				Method: "RANGE COLUMNS", Expression: "`created_at`", Partitions: []models.Partition{{Name: "p202401", Description: "'2024-01-15'"}},
+			}}},
+		},
+		{
+			name:   "expression differs only in quoting",
+			master: &models.Partitioning{Method: "RANGE COLUMNS", Expression: "created_at", Partitions: monthly("p202401").Partitions},
+			backup: monthly("p202401"),
+		},
+		{
+			name:   "hash partition count changed",
+			master: hash(8), backup: hash(4),
+			want: []models.PartitionChange{{Action: dialect.PartitionBy, Partitioning: *hash(8)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.AppConfig{}
+			cfg.Sync.DroppedPartitionPolicy = tt.policy
+			s := newMySQLSchemaService(t, cfg)
+
+			got := s.partitionChanges("orders", tt.master, tt.backup)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("partitionChanges()\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
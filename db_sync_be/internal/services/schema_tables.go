@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kebijakan untuk tabel backup yang sudah tidak ada di master
+const (
+	DroppedTableKeep    = "keep"
+	DroppedTableArchive = "archive"
+	DroppedTableDrop    = "drop"
+)
+
+// archivedTablePrefix dipakai kebijakan archive, tabel dengan prefix ini tidak
+// pernah dianggap kandidat rename atau di-archive ulang
+const archivedTablePrefix = "_archived_"
+
+var (
+	autoIncrementPattern   = regexp.MustCompile(`(?i)\s*AUTO_INCREMENT=\d+`)
+	createTableNamePattern = regexp.MustCompile("(?i)^\\s*CREATE\\s+TABLE\\s+(IF\\s+NOT\\s+EXISTS\\s+)?(`[^`]+`|\"[^\"]+\"|\\S+)")
+)
+
+// tableSets membandingkan daftar tabel master dan backup. missing adalah tabel
+// master yang belum ada di backup, orphans tabel backup yang tidak ada di master
+// (selain tabel internal db_sync dan tabel yang sudah di-archive).
+func (s *SchemaService) tableSets() (missing, orphans []string, err error) {
+	masterTables, err := s.GetAllTables()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	backupTables, err := s.target.ListTables(ctx, s.backupDB)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get backup tables: %v", err)
+	}
+
+	for _, table := range masterTables {
+		if !slices.Contains(backupTables, table) {
+			missing = append(missing, table)
+		}
+	}
+	for _, table := range backupTables {
+		if slices.Contains(masterTables, table) ||
+			strings.HasPrefix(table, internalTablePrefix) || strings.HasPrefix(table, archivedTablePrefix) {
+			continue
+		}
+		orphans = append(orphans, table)
+	}
+
+	return missing, orphans, nil
+}
+
+func (s *SchemaService) masterTableExists(tableName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.source.TableExists(ctx, s.masterDB, tableName)
+}
+
+// planDroppedTable menerapkan DroppedTablePolicy untuk tabel backup yang sudah
+// tidak ada di master. Tabel yang terdeteksi di-rename ditangani lewat tabel barunya.
+func (s *SchemaService) planDroppedTable(tableName string) (models.TablePlan, error) {
+	plan := models.TablePlan{TableName: tableName, Action: PlanActionDrop}
+
+	exists, err := s.TableExists(tableName)
+	if err != nil {
+		return plan, err
+	}
+	if !exists {
+		return plan, fmt.Errorf("table %s does not exist on master or backup", tableName)
+	}
+
+	if strings.HasPrefix(tableName, internalTablePrefix) || strings.HasPrefix(tableName, archivedTablePrefix) {
+		return plan, nil
+	}
+
+	renames, held, err := s.detectTableRenames()
+	if err != nil {
+		return plan, err
+	}
+	if held[tableName] {
+		return plan, nil
+	}
+	for _, oldName := range renames {
+		if oldName == tableName {
+			return plan, nil
+		}
+	}
+
+	var changes []models.SchemaChange
+	switch s.config.Sync.DroppedTablePolicy {
+	case DroppedTableArchive:
+		archived := archivedTablePrefix + tableName
+		log.Printf("Table %s no longer exists on master, renaming it to %s", tableName, archived)
+		plan.Action = PlanActionArchive
+		changes = append(changes, models.SchemaChange{
+			TableName: tableName,
+			Kind:      "archive_table",
+			Object:    archived,
+			Statement: s.target.RenameTableStatement(tableName, archived),
+		})
+
+	case DroppedTableDrop:
+		log.Printf("Table %s no longer exists on master, dropping it from backup", tableName)
+		changes = append(changes, models.SchemaChange{
+			TableName:   tableName,
+			Kind:        "drop_table",
+			Object:      tableName,
+			Statement:   "DROP TABLE " + s.target.QuoteIdentifier(tableName),
+			Destructive: true,
+		})
+	}
+
+	plan.Changes = s.markApprovals(changes)
+	plan.Blocked = changesBlocked(plan.Changes)
+	return plan, nil
+}
+
+// detectTableRenames memasangkan tabel master yang belum ada di backup dengan
+// tabel backup yang sudah hilang di master. Pasangan harus punya definisi yang
+// sama selain namanya dan jumlah baris yang mirip, dan menjadi satu-satunya
+// kandidat bagi keduanya. renames berisi nama baru -> nama lama yang boleh
+// dijalankan; held berisi tabel yang rename-nya masih menunggu konfirmasi.
+func (s *SchemaService) detectTableRenames() (renames map[string]string, held map[string]bool, err error) {
+	renames = make(map[string]string)
+	held = make(map[string]bool)
+
+	mode := s.config.Sync.RenameDetection
+	if mode != RenameDetectionAuto && mode != RenameDetectionConfirm {
+		return renames, held, nil
+	}
+
+	missing, orphans, err := s.tableSets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var candidates []models.TableRename
+	masterCount := make(map[string]int)
+	backupCount := make(map[string]int)
+	for _, newName := range missing {
+		for _, oldName := range orphans {
+			same, err := s.sameTableSignature(newName, oldName)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !same {
+				continue
+			}
+
+			masterRows, backupRows, err := s.tableRowCounts(newName, oldName)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !rowCountsSimilar(masterRows, backupRows) {
+				continue
+			}
+
+			candidates = append(candidates, models.TableRename{
+				OldName:    oldName,
+				NewName:    newName,
+				MasterRows: masterRows,
+				BackupRows: backupRows,
+			})
+			masterCount[newName]++
+			backupCount[oldName]++
+		}
+	}
+
+	// Tabel dengan lebih dari satu kandidat tidak bisa dipastikan, dibuat ulang saja
+	var pairs []models.TableRename
+	for _, c := range candidates {
+		if masterCount[c.NewName] == 1 && backupCount[c.OldName] == 1 {
+			pairs = append(pairs, c)
+		}
+	}
+
+	if mode == RenameDetectionAuto {
+		for _, pair := range pairs {
+			log.Printf("Table %s looks renamed to %s, renaming it in backup", pair.OldName, pair.NewName)
+			renames[pair.NewName] = pair.OldName
+		}
+		return renames, held, nil
+	}
+
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	// Pasangan yang sudah tidak terdeteksi lagi tidak perlu ditunggu
+	previous := s.pendingTableRenames
+	s.pendingTableRenames = make(map[string]models.TableRename)
+
+	for _, pair := range pairs {
+		key := renameKey("", pair.OldName, pair.NewName)
+
+		accept, decided := s.tableRenameDecisions[key]
+		if !decided {
+			if pending, ok := previous[key]; ok {
+				pair.DetectedAt = pending.DetectedAt
+			} else {
+				pair.DetectedAt = time.Now()
+				log.Printf("Table %s looks renamed to %s, waiting for confirmation", pair.OldName, pair.NewName)
+			}
+			s.pendingTableRenames[key] = pair
+			held[pair.OldName] = true
+			held[pair.NewName] = true
+			continue
+		}
+
+		if accept {
+			renames[pair.NewName] = pair.OldName
+		}
+	}
+
+	return renames, held, nil
+}
+
+// sameTableSignature membandingkan definisi tabel master dan backup tanpa nama
+// tabelnya. Dialect yang sama memakai CREATE TABLE asli; selain itu kolom (nama,
+// urutan dan tipe) dan primary key.
+func (s *SchemaService) sameTableSignature(masterTable, backupTable string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if s.source.Name() == s.target.Name() {
+		masterCreate, err := s.source.ShowCreateTable(ctx, s.masterDB, masterTable)
+		if err != nil {
+			return false, fmt.Errorf("failed to get master create statement: %v", err)
+		}
+		backupCreate, err := s.target.ShowCreateTable(ctx, s.backupDB, backupTable)
+		if err != nil {
+			return false, fmt.Errorf("failed to get backup create statement: %v", err)
+		}
+		if masterCreate != "" && backupCreate != "" {
+			return createSignature(s.source, masterCreate) == createSignature(s.target, backupCreate), nil
+		}
+	}
+
+	masterColumns, err := s.source.GetColumns(ctx, s.masterDB, masterTable)
+	if err != nil {
+		return false, fmt.Errorf("failed to get master schema: %v", err)
+	}
+	backupColumns, err := s.target.GetColumns(ctx, s.backupDB, backupTable)
+	if err != nil {
+		return false, fmt.Errorf("failed to get backup schema: %v", err)
+	}
+	if len(masterColumns) != len(backupColumns) {
+		return false, nil
+	}
+	for i := range masterColumns {
+		if masterColumns[i].ColumnName != backupColumns[i].ColumnName ||
+			s.target.ColumnsDifferent(masterColumns[i], backupColumns[i]) {
+			return false, nil
+		}
+	}
+
+	masterPK, err := s.source.GetPrimaryKeyColumns(ctx, s.masterDB, masterTable)
+	if err != nil {
+		return false, fmt.Errorf("failed to get master primary key: %v", err)
+	}
+	backupPK, err := s.target.GetPrimaryKeyColumns(ctx, s.backupDB, backupTable)
+	if err != nil {
+		return false, fmt.Errorf("failed to get backup primary key: %v", err)
+	}
+	return slices.Equal(masterPK, backupPK), nil
+}
+
+// createSignature menghapus nama tabel, FK dan nilai AUTO_INCREMENT dari CREATE
+// TABLE; FK backup bisa ditunda dan AUTO_INCREMENT berbeda selama sync berjalan
+func createSignature(d dialect.Dialect, createStmt string) string {
+	stmt := d.StripForeignKeys(createStmt)
+	stmt = createTableNamePattern.ReplaceAllString(stmt, "CREATE TABLE")
+	stmt = autoIncrementPattern.ReplaceAllString(stmt, "")
+	return objectSignature(stmt)
+}
+
+func (s *SchemaService) tableRowCounts(masterTable, backupTable string) (masterRows, backupRows int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	query := "SELECT COUNT(*) FROM " + s.source.QuoteIdentifier(masterTable)
+	if err := s.masterDB.QueryRowContext(ctx, query).Scan(&masterRows); err != nil {
+		return 0, 0, fmt.Errorf("failed to count master rows of %s: %v", masterTable, err)
+	}
+
+	query = "SELECT COUNT(*) FROM " + s.target.QuoteIdentifier(backupTable)
+	if err := s.backupDB.QueryRowContext(ctx, query).Scan(&backupRows); err != nil {
+		return 0, 0, fmt.Errorf("failed to count backup rows of %s: %v", backupTable, err)
+	}
+
+	return masterRows, backupRows, nil
+}
+
+// rowCountsSimilar menoleransi selisih 10%, baris yang ditulis di master sejak
+// sync terakhir belum ada di backup
+func rowCountsSimilar(masterRows, backupRows int64) bool {
+	diff := masterRows - backupRows
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff*10 <= max(masterRows, backupRows)
+}
+
+// PendingTableRenames mengembalikan rename tabel yang menunggu konfirmasi
+func (s *SchemaService) PendingTableRenames() []models.TableRename {
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	renames := make([]models.TableRename, 0, len(s.pendingTableRenames))
+	for _, pending := range s.pendingTableRenames {
+		renames = append(renames, pending)
+	}
+
+	sort.Slice(renames, func(i, j int) bool {
+		return renames[i].OldName < renames[j].OldName
+	})
+
+	return renames
+}
+
+// HasPendingTableRename mengembalikan true jika tabel (nama lama atau baru)
+// punya rename yang belum dikonfirmasi
+func (s *SchemaService) HasPendingTableRename(tableName string) bool {
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	for _, pending := range s.pendingTableRenames {
+		if pending.OldName == tableName || pending.NewName == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveTableRename mencatat keputusan untuk rename tabel yang menunggu
+// konfirmasi. accept memindahkan tabel dengan rename; reject membuat tabel baru
+// dan menangani tabel lama sesuai DroppedTablePolicy.
+func (s *SchemaService) ResolveTableRename(oldName, newName string, accept bool) error {
+	s.renameMutex.Lock()
+	defer s.renameMutex.Unlock()
+
+	key := renameKey("", oldName, newName)
+	if _, ok := s.pendingTableRenames[key]; !ok {
+		return fmt.Errorf("no pending table rename %s -> %s", oldName, newName)
+	}
+
+	delete(s.pendingTableRenames, key)
+	s.tableRenameDecisions[key] = accept
+	return nil
+}
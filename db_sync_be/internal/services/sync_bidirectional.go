@@ -0,0 +1,492 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SyncModeOneWay        = "one_way"
+	SyncModeBidirectional = "bidirectional"
+
+	ConflictMasterWins     = "master_wins"
+	ConflictLastWriterWins = "last_writer_wins"
+	ConflictManual         = "manual"
+
+	originMaster = "master"
+	originBackup = "backup"
+)
+
+// rowChecksum adalah nilai PK dan checksum satu baris
+type rowChecksum struct {
+	pkValues []interface{}
+	checksum string
+}
+
+// syncTableBidirectional melakukan sinkronisasi dua arah untuk satu tabel.
+// Perubahan dideteksi dengan membandingkan checksum tiap sisi terhadap checkpoint
+// terakhir, sehingga perubahan hasil replikasi tidak dikirim balik ke sumbernya.
+func (s *SyncService) syncTableBidirectional(tableName string) {
+	if s.blockedBySchema(tableName) {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.tableStatus[tableName] == nil {
+		s.tableStatus[tableName] = &models.SyncStatus{TableName: tableName}
+	}
+	s.tableStatus[tableName].Status = "syncing"
+	s.mutex.Unlock()
+
+	// Checkpoint memakai checksum MySQL yang harus sama di kedua sisi
+	if s.source.Name() != "mysql" || s.target.Name() != "mysql" {
+		log.Printf("Bidirectional sync requires MySQL on both master and backup, skipping %s", tableName)
+		s.updateTableStatus(tableName, "error", "bidirectional sync requires mysql master and backup", 0, 0)
+		return
+	}
+
+	pkColumns, err := s.getPrimaryKeyColumns(tableName)
+	if err != nil {
+		log.Printf("Error getting primary key for %s: %v", tableName, err)
+		s.updateTableStatus(tableName, "error", err.Error(), 0, 0)
+		return
+	}
+
+	if len(pkColumns) == 0 {
+		log.Printf("Table %s has no primary key, skipping...", tableName)
+		s.updateTableStatus(tableName, "skipped", "no primary key", 0, 0)
+		return
+	}
+
+	columns, err := s.getTableColumns(tableName)
+	if err != nil {
+		log.Printf("Error getting columns for %s: %v", tableName, err)
+		s.updateTableStatus(tableName, "error", err.Error(), 0, 0)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	masterSums, err := s.loadRowChecksums(ctx, s.masterDB, s.source, tableName, pkColumns, columns, "")
+	if err != nil {
+		log.Printf("Error reading master checksums for %s: %v", tableName, err)
+		s.updateTableStatus(tableName, "error", err.Error(), 0, 0)
+		return
+	}
+
+	backupSums, err := s.loadRowChecksums(ctx, s.backupDB, s.target, tableName, pkColumns, columns, "")
+	if err != nil {
+		log.Printf("Error reading backup checksums for %s: %v", tableName, err)
+		s.updateTableStatus(tableName, "error", err.Error(), 0, 0)
+		return
+	}
+
+	states, err := s.conflicts.LoadStates(tableName)
+	if err != nil {
+		log.Printf("Error loading row state for %s: %v", tableName, err)
+		s.updateTableStatus(tableName, "error", err.Error(), 0, 0)
+		return
+	}
+
+	// Sebelum putaran pertama selesai, baris yang hanya ada di backup belum bisa
+	// dibedakan dari sisa delete di master
+	seeded, err := s.conflicts.Seeded(tableName)
+	if err != nil {
+		log.Printf("Error loading seeded state for %s: %v", tableName, err)
+		s.updateTableStatus(tableName, "error", err.Error(), 0, 0)
+		return
+	}
+
+	pending, err := s.conflicts.Pending(tableName)
+	if err != nil {
+		log.Printf("Warning: failed to load pending conflicts for %s: %v", tableName, err)
+	}
+
+	// Gabungkan semua key dari master, backup dan checkpoint, urutkan supaya deterministik
+	keySet := make(map[string]bool)
+	for key := range masterSums {
+		keySet[key] = true
+	}
+	for key := range backupSums {
+		keySet[key] = true
+	}
+	for key := range states {
+		keySet[key] = true
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	applied := 0
+	conflicts := 0
+	completed := true
+	var lastErr error
+
+	for _, key := range keys {
+		if !s.IsRunning() {
+			completed = false
+			break
+		}
+
+		m, inMaster := masterSums[key]
+		b, inBackup := backupSums[key]
+		state, hasState := states[key]
+
+		// Kedua sisi sudah sama, cukup perbarui checkpoint
+		if inMaster && inBackup && m.checksum == b.checksum {
+			if !hasState || state.Checksum != m.checksum {
+				origin := originMaster
+				if hasState {
+					origin = state.Origin
+				}
+				if err := s.conflicts.SaveState(tableName, key, rowState{Checksum: m.checksum, Origin: origin}); err != nil {
+					lastErr = err
+				}
+			}
+			if pending[key] {
+				if err := s.conflicts.Remove(tableName, key); err != nil {
+					lastErr = err
+				}
+			}
+			continue
+		}
+
+		// Terhapus di kedua sisi
+		if !inMaster && !inBackup {
+			if err := s.conflicts.DeleteState(tableName, key); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+
+		pkValues := m.pkValues
+		if !inMaster {
+			pkValues = b.pkValues
+		}
+
+		trustBackupInserts := seeded && !pending[key]
+		switch rowChangeOrigin(m.checksum, inMaster, b.checksum, inBackup, state, hasState, trustBackupInserts) {
+		case originMaster:
+			err = s.applyRowChange(ctx, tableName, pkColumns, key, pkValues, originMaster, m.checksum)
+		case originBackup:
+			err = s.applyRowChange(ctx, tableName, pkColumns, key, pkValues, originBackup, b.checksum)
+		default:
+			conflicts++
+			err = s.resolveConflict(ctx, tableName, pkColumns, key, pkValues, hasState, m.checksum, b.checksum)
+		}
+
+		if err != nil {
+			log.Printf("  Error syncing row %s in %s: %v", key, tableName, err)
+			lastErr = err
+			continue
+		}
+
+		applied++
+	}
+
+	if lastErr == nil && completed && !seeded {
+		lastErr = s.conflicts.MarkSeeded(tableName)
+	}
+
+	if lastErr != nil {
+		s.updateTableStatus(tableName, "error", lastErr.Error(), 0, applied)
+		return
+	}
+
+	s.updateTableStatus(tableName, "success", "", 0, applied)
+	log.Printf("Table %s synced bidirectionally: %d changes applied, %d conflicts\n", tableName, applied, conflicts)
+}
+
+// rowChangeOrigin menentukan sisi yang perubahannya disalin: originMaster,
+// originBackup, atau "" jika konflik. Baris yang hanya ada di backup tanpa
+// checkpoint baru dianggap insert di backup jika trustBackupInserts. Pada putaran
+// pertama baris itu biasanya sisa baris yang sudah dihapus di master selama
+// one-way sync (yang tidak pernah menyalin delete), sehingga jadi konflik.
+func rowChangeOrigin(masterChecksum string, inMaster bool, backupChecksum string, inBackup bool, state rowState, hasState, trustBackupInserts bool) string {
+	if !hasState && !inMaster && !trustBackupInserts {
+		return ""
+	}
+
+	masterChanged := rowChangedSinceCheckpoint(masterChecksum, inMaster, state, hasState)
+	backupChanged := rowChangedSinceCheckpoint(backupChecksum, inBackup, state, hasState)
+
+	switch {
+	case masterChanged && !backupChanged:
+		return originMaster
+	case backupChanged && !masterChanged:
+		return originBackup
+	}
+	return ""
+}
+
+// rowChangedSinceCheckpoint mengecek apakah satu sisi berubah sejak checkpoint terakhir
+func rowChangedSinceCheckpoint(checksum string, exists bool, state rowState, hasState bool) bool {
+	if !hasState {
+		return exists
+	}
+	if !exists {
+		return true
+	}
+	return checksum != state.Checksum
+}
+
+// applyRowChange menyalin baris dari sisi origin ke sisi lainnya lalu menyimpan checkpoint
+func (s *SyncService) applyRowChange(ctx context.Context, tableName string, pkColumns []string, key string, pkValues []interface{}, origin, checksum string) error {
+	fromDB, toDB := s.masterDB, s.backupDB
+	from, to := s.source, s.target
+	if origin == originBackup {
+		fromDB, toDB = s.backupDB, s.masterDB
+		from, to = s.target, s.source
+	}
+
+	row, err := s.fetchRowByPKValues(ctx, fromDB, from, tableName, pkColumns, pkValues)
+	if err != nil {
+		return fmt.Errorf("failed to fetch row from %s: %v", origin, err)
+	}
+
+	// Baris dihapus di sisi origin, hapus juga di sisi lainnya
+	if row == nil {
+		if err := s.deleteRowByPKValues(ctx, toDB, to, tableName, pkColumns, pkValues); err != nil {
+			return fmt.Errorf("failed to delete row: %v", err)
+		}
+		return s.conflicts.DeleteState(tableName, key)
+	}
+
+	if err := s.upsertRowInto(ctx, toDB, to, tableName, pkColumns, row); err != nil {
+		return fmt.Errorf("failed to apply row from %s: %v", origin, err)
+	}
+
+	return s.conflicts.SaveState(tableName, key, rowState{Checksum: checksum, Origin: origin})
+}
+
+// resolveConflict menangani baris yang berubah di kedua sisi sesuai strategi yang dikonfigurasi
+func (s *SyncService) resolveConflict(ctx context.Context, tableName string, pkColumns []string, key string, pkValues []interface{}, hasState bool, masterChecksum, backupChecksum string) error {
+	reason := "changed on both sides since last checkpoint"
+	if !hasState {
+		reason = "row differs on both sides without checkpoint"
+		if masterChecksum == "" {
+			reason = "row exists only on backup without checkpoint"
+		}
+	}
+
+	switch s.config.Sync.ConflictResolution {
+	case ConflictManual:
+		masterRow, err := s.fetchRowByPKValues(ctx, s.masterDB, s.source, tableName, pkColumns, pkValues)
+		if err != nil {
+			return err
+		}
+		backupRow, err := s.fetchRowByPKValues(ctx, s.backupDB, s.target, tableName, pkColumns, pkValues)
+		if err != nil {
+			return err
+		}
+		log.Printf("  Conflict on %s row %s recorded for manual review", tableName, key)
+		return s.conflicts.Record(tableName, key, masterRow, backupRow, reason)
+
+	case ConflictLastWriterWins:
+		masterRow, err := s.fetchRowByPKValues(ctx, s.masterDB, s.source, tableName, pkColumns, pkValues)
+		if err != nil {
+			return err
+		}
+		backupRow, err := s.fetchRowByPKValues(ctx, s.backupDB, s.target, tableName, pkColumns, pkValues)
+		if err != nil {
+			return err
+		}
+		if s.lastWriter(masterRow, backupRow) == originBackup {
+			log.Printf("  Conflict on %s row %s resolved: backup is newer", tableName, key)
+			return s.applyRowChange(ctx, tableName, pkColumns, key, pkValues, originBackup, backupChecksum)
+		}
+		log.Printf("  Conflict on %s row %s resolved: master is newer", tableName, key)
+		return s.applyRowChange(ctx, tableName, pkColumns, key, pkValues, originMaster, masterChecksum)
+
+	default:
+		log.Printf("  Conflict on %s row %s resolved: master wins", tableName, key)
+		return s.applyRowChange(ctx, tableName, pkColumns, key, pkValues, originMaster, masterChecksum)
+	}
+}
+
+// lastWriter membandingkan kolom timestamp kedua baris. Jika salah satu baris
+// sudah dihapus atau timestamp tidak tersedia, master dianggap pemenang.
+func (s *SyncService) lastWriter(masterRow, backupRow map[string]interface{}) string {
+	if masterRow == nil || backupRow == nil {
+		return originMaster
+	}
+
+	column := s.config.Sync.ConflictTimestampColumn
+	masterTime, ok1 := toTime(masterRow[column])
+	backupTime, ok2 := toTime(backupRow[column])
+	if !ok1 || !ok2 {
+		return originMaster
+	}
+
+	if backupTime.After(masterTime) {
+		return originBackup
+	}
+	return originMaster
+}
+
+func toTime(val interface{}) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05.999999", "2006-01-02 15:04:05", time.RFC3339} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// ResolveConflict me-resolve konflik manual dengan memilih sisi pemenang ("master" atau "backup")
+func (s *SyncService) ResolveConflict(tableName, pkValue, winner string) error {
+	if tableName == "" || pkValue == "" {
+		return fmt.Errorf("table name and pk value are required")
+	}
+	if winner != originMaster && winner != originBackup {
+		return fmt.Errorf("winner must be %q or %q", originMaster, originBackup)
+	}
+
+	pkColumns, err := s.getPrimaryKeyColumns(tableName)
+	if err != nil {
+		return err
+	}
+	if len(pkColumns) == 0 {
+		return fmt.Errorf("table %s has no primary key", tableName)
+	}
+
+	pkValues, err := rowKeyValues(pkValue, pkColumns)
+	if err != nil {
+		return err
+	}
+
+	columns, err := s.getTableColumns(tableName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, d := s.masterDB, s.source
+	if winner == originBackup {
+		db, d = s.backupDB, s.target
+	}
+
+	filter, args := pkFilter(d, pkColumns, pkValues)
+	sums, err := s.loadRowChecksums(ctx, db, d, tableName, pkColumns, columns, filter, args...)
+	if err != nil {
+		return err
+	}
+
+	if err := s.applyRowChange(ctx, tableName, pkColumns, pkValue, pkValues, winner, sums[pkValue].checksum); err != nil {
+		return err
+	}
+
+	log.Printf("Conflict on %s row %s resolved manually: %s wins", tableName, pkValue, winner)
+	return s.conflicts.Remove(tableName, pkValue)
+}
+
+// ListConflicts mengembalikan konflik yang menunggu resolusi manual
+func (s *SyncService) ListConflicts(tableName string) ([]models.SyncConflict, error) {
+	return s.conflicts.List(tableName)
+}
+
+// loadRowChecksums menghitung checksum setiap baris, di-key dengan gabungan nilai PK
+func (s *SyncService) loadRowChecksums(ctx context.Context, db *sql.DB, d dialect.Dialect, tableName string, pkColumns, columns []string, filter string, args ...interface{}) (map[string]rowChecksum, error) {
+	query := fmt.Sprintf("SELECT %s, %s FROM %s",
+		dialect.QuoteIdentifiers(d, pkColumns), d.ChecksumExpression(columns), d.QuoteIdentifier(tableName))
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+
+	rows, err := db.QueryContext(ctx, d.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]rowChecksum)
+	for rows.Next() {
+		scanValues := make([]interface{}, len(pkColumns)+1)
+		scanPointers := make([]interface{}, len(pkColumns)+1)
+		for i := range scanValues {
+			scanPointers[i] = &scanValues[i]
+		}
+
+		if err := rows.Scan(scanPointers...); err != nil {
+			return nil, err
+		}
+
+		pkValues := make([]interface{}, len(pkColumns))
+		keyParts := make([]string, len(pkColumns))
+		for i := range pkColumns {
+			if b, ok := scanValues[i].([]byte); ok {
+				pkValues[i] = string(b)
+			} else {
+				pkValues[i] = scanValues[i]
+			}
+			keyParts[i] = formatPKValue(pkValues[i])
+		}
+
+		result[joinRowKey(keyParts)] = rowChecksum{
+			pkValues: pkValues,
+			checksum: formatPKValue(scanValues[len(pkColumns)]),
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// fetchRowByPKValues mengambil satu baris berdasarkan (composite) primary key
+func (s *SyncService) fetchRowByPKValues(ctx context.Context, db *sql.DB, d dialect.Dialect, tableName string, pkColumns []string, pkValues []interface{}) (map[string]interface{}, error) {
+	filter, args := pkFilter(d, pkColumns, pkValues)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", d.QuoteIdentifier(tableName), filter)
+
+	rows, err := db.QueryContext(ctx, d.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := s.scanRowsToMaps(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	if err := s.dropGeneratedColumns(tableName, results[0]); err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// deleteRowByPKValues menghapus satu baris berdasarkan (composite) primary key
+func (s *SyncService) deleteRowByPKValues(ctx context.Context, db sqlExecutor, d dialect.Dialect, tableName string, pkColumns []string, pkValues []interface{}) error {
+	filter, args := pkFilter(d, pkColumns, pkValues)
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", d.QuoteIdentifier(tableName), filter)
+
+	_, err := db.ExecContext(ctx, d.Rebind(query), args...)
+	return err
+}
+
+func pkFilter(d dialect.Dialect, pkColumns []string, pkValues []interface{}) (string, []interface{}) {
+	var conditions []string
+	for _, pk := range pkColumns {
+		conditions = append(conditions, fmt.Sprintf("%s = ?", d.QuoteIdentifier(pk)))
+	}
+	return strings.Join(conditions, " AND "), pkValues
+}
@@ -0,0 +1,39 @@
+package services
+
+import "testing"
+
+func TestRowChangeOrigin(t *testing.T) {
+	checkpoint := rowState{Checksum: "c1", Origin: originMaster}
+
+	tests := []struct {
+		name     string
+		master   string
+		inMaster bool
+		backup   string
+		inBackup bool
+		state    rowState
+		hasState bool
+		seeded   bool
+		want     string
+	}{
+		{"first run, only on master", "c1", true, "", false, rowState{}, false, false, originMaster},
+		{"first run, only on backup", "", false, "c1", true, rowState{}, false, false, ""},
+		{"first run, differs on both sides", "c1", true, "c2", true, rowState{}, false, false, ""},
+		{"inserted on master", "c1", true, "", false, rowState{}, false, true, originMaster},
+		{"inserted on backup", "", false, "c1", true, rowState{}, false, true, originBackup},
+		{"updated on master", "c2", true, "c1", true, checkpoint, true, true, originMaster},
+		{"updated on backup", "c1", true, "c2", true, checkpoint, true, true, originBackup},
+		{"deleted on master", "", false, "c1", true, checkpoint, true, true, originMaster},
+		{"deleted on backup", "c1", true, "", false, checkpoint, true, true, originBackup},
+		{"updated on both sides", "c2", true, "c3", true, checkpoint, true, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rowChangeOrigin(tt.master, tt.inMaster, tt.backup, tt.inBackup, tt.state, tt.hasState, tt.seeded)
+			if got != tt.want {
+				t.Errorf("rowChangeOrigin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
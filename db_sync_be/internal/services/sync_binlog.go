@@ -0,0 +1,867 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+)
+
+const (
+	CaptureModePolling = "polling"
+	CaptureModeBinlog  = "binlog"
+	CaptureModeTrigger = "trigger"
+
+	// internalTablePrefix dipakai semua tabel milik db_sync, tidak pernah direplikasi
+	internalTablePrefix = "_db_sync_"
+
+	binlogCheckpointTable = "_db_sync_binlog_checkpoint"
+	binlogCheckpointName  = "master"
+
+	binlogRetryDelay = 10 * time.Second
+	// Transaksi yang tidak menyentuh tabel yang disinkronkan cukup di-checkpoint sesekali
+	binlogIdleCheckpointInterval = 10 * time.Second
+)
+
+// ddlQueryPattern mengenali DDL yang bisa mengubah struktur tabel, komentar di
+// awal query (mis. dari ORM atau tool migration) dilewati
+var ddlQueryPattern = regexp.MustCompile(`^(?is)(?:/\*.*?\*/\s*)*(?:CREATE|ALTER|DROP|RENAME)\s`)
+
+// binlogCheckpoint adalah posisi binlog terakhir yang sudah di-commit ke backup
+type binlogCheckpoint struct {
+	File     string
+	Position uint32
+	GTIDSet  string
+}
+
+func (c binlogCheckpoint) String() string {
+	if c.GTIDSet != "" {
+		return c.GTIDSet
+	}
+	return fmt.Sprintf("%s:%d", c.File, c.Position)
+}
+
+// binlogCheckpointColumns adalah struktur tabel checkpoint, dirender oleh dialect backup
+var binlogCheckpointColumns = []models.ColumnInfo{
+	dialect.InternalColumn("name", "varchar", "varchar(64)", true, true),
+	dialect.InternalColumn("binlog_file", "varchar", "varchar(255)", true, false),
+	dialect.InternalColumn("binlog_pos", "bigint", "bigint", true, false),
+	dialect.InternalColumn("gtid_set", "text", "text", false, false),
+	dialect.InternalColumn("updated_at", "datetime", "datetime", true, false),
+}
+
+// binlogTable adalah metadata tabel master untuk men-decode row event
+type binlogTable struct {
+	columns   []string
+	dataTypes map[string]string // hanya kolom integer unsigned
+	pkColumns []string
+	generated map[string]bool // kolom generated yang dihitung sendiri oleh backup
+	enums     map[string]binlogEnum
+	resynced  bool // sudah full resync karena jumlah kolom event tidak cocok
+}
+
+// binlogEnum adalah daftar nilai kolom ENUM/SET. Row event mengirim ENUM sebagai
+// index (mulai dari 1) dan SET sebagai bitmask, bukan teks nilainya.
+type binlogEnum struct {
+	set    bool
+	values []string
+}
+
+// binlogChange adalah efek akhir satu baris dalam satu transaksi binlog.
+// row bernilai nil jika baris dihapus.
+type binlogChange struct {
+	tableName string
+	pkColumns []string
+	pkValues  []interface{}
+	key       string
+	row       map[string]interface{}
+	seq       int
+}
+
+// binlogCapture membaca row event dari binlog master sebagai replication client
+// dan menerapkannya ke backup per transaksi, bersama checkpoint posisinya
+type binlogCapture struct {
+	s         *SyncService
+	tables    map[string]*binlogTable
+	levels    map[string]int
+	pending   map[string]*binlogChange
+	held      map[string]*binlogChange // perubahan tabel yang di-block schema
+	seq       int
+	lastSaved time.Time
+}
+
+func newBinlogCapture(s *SyncService) *binlogCapture {
+	return &binlogCapture{
+		s:       s,
+		tables:  make(map[string]*binlogTable),
+		pending: make(map[string]*binlogChange),
+		held:    make(map[string]*binlogChange),
+	}
+}
+
+// startBinlogCapture menjalankan CDC binlog menggantikan cron. Dipanggil dengan s.mutex terkunci.
+func (s *SyncService) startBinlogCapture() error {
+	if s.source.Name() != "mysql" {
+		return fmt.Errorf("binlog capture requires a mysql master, got %s", s.source.Name())
+	}
+	if s.config.Sync.Mode == SyncModeBidirectional {
+		return fmt.Errorf("binlog capture only supports %s mode", SyncModeOneWay)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.captureCancel = cancel
+	s.isRunning = true
+	s.lastRunTime = time.Now()
+
+	log.Println("Sync service started in binlog capture mode")
+
+	go s.runBinlogCapture(ctx)
+	return nil
+}
+
+// runBinlogCapture menjalankan capture sampai dihentikan, reconnect jika stream terputus
+func (s *SyncService) runBinlogCapture(ctx context.Context) {
+	// Tunggu capture sebelumnya (Stop lalu Start cepat) benar-benar selesai
+	s.captureMutex.Lock()
+	defer s.captureMutex.Unlock()
+
+	capture := newBinlogCapture(s)
+	for {
+		err := capture.run(ctx)
+		if ctx.Err() != nil {
+			log.Println("Binlog capture stopped")
+			return
+		}
+
+		log.Printf("Binlog capture error: %v (retrying in %s)", err, binlogRetryDelay)
+		select {
+		case <-ctx.Done():
+			log.Println("Binlog capture stopped")
+			return
+		case <-time.After(binlogRetryDelay):
+		}
+	}
+}
+
+func (c *binlogCapture) run(ctx context.Context) error {
+	if err := c.checkMasterSettings(ctx); err != nil {
+		return err
+	}
+
+	if c.s.syncSchema {
+		if err := c.s.schemaService.SyncAllSchemas(); err != nil {
+			log.Printf("Schema sync warning: %v", err)
+		}
+	}
+
+	if err := c.ensureCheckpointTable(ctx); err != nil {
+		return err
+	}
+
+	cp, found, err := c.loadCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		// Posisi dicatat sebelum snapshot supaya perubahan selama initial load tidak terlewat;
+		// event yang ter-replay setelahnya idempotent (upsert/delete per PK)
+		cp, err = c.masterPosition(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("No binlog checkpoint found, running initial load before streaming from %s", cp)
+		c.s.syncAllTables()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := c.saveCheckpoint(ctx, c.s.backupDB, cp); err != nil {
+			return err
+		}
+	}
+
+	c.pending = make(map[string]*binlogChange)
+
+	return c.stream(ctx, cp)
+}
+
+// checkMasterSettings memastikan binlog berisi full row image
+func (c *binlogCapture) checkMasterSettings(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var format, rowImage string
+	err := c.s.masterDB.QueryRowContext(ctx, "SELECT @@GLOBAL.binlog_format, @@GLOBAL.binlog_row_image").Scan(&format, &rowImage)
+	if err != nil {
+		return fmt.Errorf("failed to read master binlog settings: %v", err)
+	}
+
+	if !strings.EqualFold(format, "ROW") {
+		return fmt.Errorf("binlog capture requires binlog_format=ROW, master uses %s", format)
+	}
+	if !strings.EqualFold(rowImage, "FULL") {
+		return fmt.Errorf("binlog capture requires binlog_row_image=FULL, master uses %s", rowImage)
+	}
+
+	return nil
+}
+
+// masterPosition membaca posisi binlog master saat ini
+func (c *binlogCapture) masterPosition(ctx context.Context) (binlogCheckpoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := c.s.masterDB.QueryContext(ctx, "SHOW MASTER STATUS")
+	if err != nil {
+		// MySQL 8.4 mengganti SHOW MASTER STATUS
+		rows, err = c.s.masterDB.QueryContext(ctx, "SHOW BINARY LOG STATUS")
+		if err != nil {
+			return binlogCheckpoint{}, fmt.Errorf("failed to read master binlog position: %v", err)
+		}
+	}
+	defer rows.Close()
+
+	status, err := c.s.scanRowsToMaps(rows)
+	if err != nil {
+		return binlogCheckpoint{}, fmt.Errorf("failed to read master binlog position: %v", err)
+	}
+	if len(status) == 0 {
+		return binlogCheckpoint{}, fmt.Errorf("binary logging is not enabled on master")
+	}
+
+	pos, err := strconv.ParseUint(formatPKValue(status[0]["Position"]), 10, 32)
+	if err != nil {
+		return binlogCheckpoint{}, fmt.Errorf("invalid binlog position: %v", err)
+	}
+
+	cp := binlogCheckpoint{
+		File:     formatPKValue(status[0]["File"]),
+		Position: uint32(pos),
+	}
+
+	if c.s.config.Sync.BinlogUseGTID {
+		if gtid, ok := status[0]["Executed_Gtid_Set"].(string); ok {
+			cp.GTIDSet = strings.ReplaceAll(gtid, "\n", "")
+		}
+	}
+
+	return cp, nil
+}
+
+// stream membaca event binlog mulai dari checkpoint sampai context dibatalkan atau terjadi error
+func (c *binlogCapture) stream(ctx context.Context, cp binlogCheckpoint) error {
+	cfg := c.s.config
+
+	port, err := strconv.ParseUint(cfg.MasterDB.PortOrDefault(), 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid master port %q: %v", cfg.MasterDB.PortOrDefault(), err)
+	}
+
+	syncer := replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		ServerID:        cfg.Sync.BinlogServerID,
+		Flavor:          mysql.MySQLFlavor,
+		Host:            cfg.MasterDB.Host,
+		Port:            uint16(port),
+		User:            cfg.MasterDB.User,
+		Password:        cfg.MasterDB.Password,
+		UseDecimal:      true,
+		HeartbeatPeriod: 30 * time.Second,
+		ReadTimeout:     90 * time.Second,
+	})
+	defer syncer.Close()
+
+	var streamer *replication.BinlogStreamer
+	if cfg.Sync.BinlogUseGTID && cp.GTIDSet != "" {
+		gset, err := mysql.ParseGTIDSet(mysql.MySQLFlavor, cp.GTIDSet)
+		if err != nil {
+			return fmt.Errorf("invalid gtid set in checkpoint: %v", err)
+		}
+		streamer, err = syncer.StartSyncGTID(gset)
+		if err != nil {
+			return fmt.Errorf("failed to start binlog stream: %v", err)
+		}
+	} else {
+		streamer, err = syncer.StartSync(mysql.Position{Name: cp.File, Pos: cp.Position})
+		if err != nil {
+			return fmt.Errorf("failed to start binlog stream: %v", err)
+		}
+	}
+
+	log.Printf("Streaming binlog from %s", cp)
+	c.s.setBinlogPosition(cp)
+
+	for {
+		ev, err := streamer.GetEvent(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch e := ev.Event.(type) {
+		case *replication.RotateEvent:
+			cp.File = string(e.NextLogName)
+			cp.Position = uint32(e.Position)
+
+		case *replication.RowsEvent:
+			if err := c.addRows(ctx, ev.Header.EventType, e); err != nil {
+				return err
+			}
+
+		case *replication.XIDEvent:
+			cp.Position = ev.Header.LogPos
+			if e.GSet != nil {
+				cp.GTIDSet = e.GSet.String()
+			}
+			if err := c.flush(ctx, cp); err != nil {
+				return err
+			}
+
+		case *replication.QueryEvent:
+			cp.Position = ev.Header.LogPos
+			if e.GSet != nil {
+				cp.GTIDSet = e.GSet.String()
+			}
+			if err := c.handleQuery(ctx, e, cp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleQuery menangani BEGIN/COMMIT dan DDL dari master
+func (c *binlogCapture) handleQuery(ctx context.Context, e *replication.QueryEvent, cp binlogCheckpoint) error {
+	query := strings.TrimSpace(string(e.Query))
+
+	switch strings.ToUpper(query) {
+	case "BEGIN":
+		return nil
+	case "COMMIT":
+		// Engine non-transaksional (MyISAM) menutup transaksi dengan QueryEvent
+		return c.flush(ctx, cp)
+	}
+
+	// Query lain (SAVEPOINT, GRANT, DDL database lain) tidak mengubah tabel
+	// yang disinkronkan, cache metadata dan schema backup tidak perlu disentuh
+	if !ddlQueryPattern.MatchString(query) || !c.masterQuery(e.Schema, query) {
+		return nil
+	}
+
+	// DDL selalu di-commit sendiri, cukup simpan posisinya
+	if err := c.flush(ctx, cp); err != nil {
+		return err
+	}
+
+	// Struktur atau relasi tabel bisa berubah, muat ulang metadata
+	c.tables = make(map[string]*binlogTable)
+	c.levels = nil
+
+	log.Printf("Binlog DDL: %s", query)
+
+	if c.s.syncSchema {
+		if err := c.s.schemaService.SyncAllSchemas(); err != nil {
+			log.Printf("Schema sync warning: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// masterQuery mengecek apakah query berjalan di database master, baik sebagai
+// database default sesi maupun lewat nama tabel yang ditulis lengkap (db.tabel)
+func (c *binlogCapture) masterQuery(schema []byte, query string) bool {
+	name := c.s.config.MasterDB.Name
+	if string(schema) == name {
+		return true
+	}
+	return strings.Contains(query, name+".") || strings.Contains(query, "`"+name+"`.")
+}
+
+// addRows menampung perubahan baris dari row event sampai transaksinya di-commit
+func (c *binlogCapture) addRows(ctx context.Context, eventType replication.EventType, e *replication.RowsEvent) error {
+	if string(e.Table.Schema) != c.s.config.MasterDB.Name {
+		return nil
+	}
+
+	tableName := string(e.Table.Table)
+	if strings.HasPrefix(tableName, internalTablePrefix) {
+		return nil
+	}
+
+	meta, err := c.table(ctx, tableName, e.Table)
+	if err != nil {
+		return err
+	}
+
+	if len(meta.pkColumns) == 0 {
+		return nil
+	}
+
+	// Binlog lebih lama dari schema master saat ini (kolom sudah berubah), tidak bisa
+	// di-decode. Baris event dilewati dan tabel disalin ulang penuh dari master, sekali
+	// per metadata tabel (cache di-reset saat DDL) supaya event berikutnya tidak ikut resync.
+	if len(meta.columns) != int(e.ColumnCount) {
+		if !meta.resynced {
+			msg := fmt.Sprintf("binlog event has %d columns, table has %d", e.ColumnCount, len(meta.columns))
+			log.Printf("Warning: cannot decode binlog rows for %s (%s), running full resync", tableName, msg)
+			c.s.resetTableStatus(tableName, "error", msg)
+			c.s.syncTable(c.s.masterDB, c.s.backupDB, tableName)
+			meta.resynced = true
+		}
+		return nil
+	}
+
+	switch eventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+		for _, values := range e.Rows {
+			c.record(tableName, meta, values, false)
+		}
+
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+		for _, values := range e.Rows {
+			c.record(tableName, meta, values, true)
+		}
+
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+		// Rows berisi pasangan before/after image. Jika PK berubah, key lama terhapus;
+		// jika tidak, after image menggantikan before image di key yang sama.
+		for i := 0; i+1 < len(e.Rows); i += 2 {
+			c.record(tableName, meta, e.Rows[i], true)
+			c.record(tableName, meta, e.Rows[i+1], false)
+		}
+	}
+
+	return nil
+}
+
+// table mengambil metadata tabel dari master, di-cache sampai ada DDL
+func (c *binlogCapture) table(ctx context.Context, tableName string, tableMap *replication.TableMapEvent) (*binlogTable, error) {
+	if meta, ok := c.tables[tableName]; ok {
+		return meta, nil
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	columns, err := c.s.source.GetColumns(queryCtx, c.s.masterDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns for %s: %v", tableName, err)
+	}
+
+	pkColumns, err := c.s.source.GetPrimaryKeyColumns(queryCtx, c.s.masterDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key for %s: %v", tableName, err)
+	}
+
+	meta := &binlogTable{
+		dataTypes: make(map[string]string),
+		pkColumns: pkColumns,
+		generated: make(map[string]bool),
+		enums:     make(map[string]binlogEnum),
+	}
+	for _, col := range columns {
+		meta.columns = append(meta.columns, col.ColumnName)
+		if c.s.generatedOnBackup(col) {
+			meta.generated[col.ColumnName] = true
+		}
+		if strings.Contains(strings.ToLower(col.ColumnType), "unsigned") {
+			meta.dataTypes[col.ColumnName] = strings.ToLower(col.DataType)
+		}
+		if dataType := strings.ToLower(col.DataType); dataType == "enum" || dataType == "set" {
+			meta.enums[col.ColumnName] = binlogEnum{set: dataType == "set", values: enumValues(col.ColumnType)}
+		}
+	}
+
+	// Dengan binlog_row_metadata=FULL nama kolom ikut dikirim di event
+	if names := tableMap.ColumnNameString(); len(names) == int(tableMap.ColumnCount) {
+		meta.columns = names
+	}
+
+	if len(pkColumns) == 0 {
+		log.Printf("Table %s has no primary key, binlog changes are skipped", tableName)
+	}
+
+	c.tables[tableName] = meta
+	return meta, nil
+}
+
+// record menyimpan efek terakhir satu baris dalam transaksi yang sedang berjalan
+func (c *binlogCapture) record(tableName string, meta *binlogTable, values []interface{}, deleted bool) {
+	row := make(map[string]interface{}, len(meta.columns))
+	for i, col := range meta.columns {
+		if meta.generated[col] {
+			continue
+		}
+		if enum, ok := meta.enums[col]; ok {
+			row[col] = enum.value(values[i])
+			continue
+		}
+		row[col] = binlogValue(values[i], meta.dataTypes[col])
+	}
+
+	pkValues := make([]interface{}, len(meta.pkColumns))
+	keyParts := make([]string, len(meta.pkColumns))
+	for i, pk := range meta.pkColumns {
+		pkValues[i] = row[pk]
+		keyParts[i] = formatPKValue(row[pk])
+	}
+
+	change := &binlogChange{
+		tableName: tableName,
+		pkColumns: meta.pkColumns,
+		pkValues:  pkValues,
+		key:       joinRowKey(keyParts),
+		row:       row,
+		seq:       c.seq,
+	}
+	if deleted {
+		change.row = nil
+	}
+
+	c.pending[tableName+"\x00"+change.key] = change
+	c.seq++
+}
+
+// binlogValue menyesuaikan nilai dari row event dengan nilai yang dihasilkan query biasa
+func binlogValue(val interface{}, unsignedType string) interface{} {
+	switch v := val.(type) {
+	case []byte:
+		return string(v)
+	case int8:
+		if unsignedType != "" {
+			return uint8(v)
+		}
+	case int16:
+		if unsignedType != "" {
+			return uint16(v)
+		}
+	case int32:
+		if unsignedType == "mediumint" {
+			return uint32(v) & 0xFFFFFF
+		}
+		if unsignedType != "" {
+			return uint32(v)
+		}
+	case int64:
+		if unsignedType != "" {
+			return uint64(v)
+		}
+	}
+	return val
+}
+
+// value mengubah index ENUM atau bitmask SET dari row event menjadi teks nilainya,
+// sama dengan hasil SELECT. Index 0 adalah nilai kosong untuk ENUM yang tidak valid.
+func (e binlogEnum) value(val interface{}) interface{} {
+	n, ok := val.(int64)
+	if !ok {
+		return binlogValue(val, "")
+	}
+
+	if !e.set {
+		if n < 1 || int(n) > len(e.values) {
+			return ""
+		}
+		return e.values[n-1]
+	}
+
+	var members []string
+	for i, v := range e.values {
+		if n&(1<<uint(i)) != 0 {
+			members = append(members, v)
+		}
+	}
+	return strings.Join(members, ",")
+}
+
+// enumValues mengambil daftar nilai dari tipe kolom seperti enum('a','b') atau set('x','y')
+func enumValues(columnType string) []string {
+	var values []string
+	for _, match := range enumValuePattern.FindAllStringSubmatch(columnType, -1) {
+		values = append(values, strings.ReplaceAll(match[1], "''", "'"))
+	}
+	return values
+}
+
+// orderedChanges mengurutkan perubahan sesuai FK: upsert dari parent ke child,
+// delete dari child ke parent. Urutan commit dipertahankan di dalam level yang sama.
+func (c *binlogCapture) orderedChanges() []*binlogChange {
+	levels := c.dependencyLevels()
+
+	var upserts, deletes []*binlogChange
+	for _, change := range c.pending {
+		if change.row == nil {
+			deletes = append(deletes, change)
+		} else {
+			upserts = append(upserts, change)
+		}
+	}
+
+	sort.Slice(upserts, func(i, j int) bool {
+		li, lj := levels[upserts[i].tableName], levels[upserts[j].tableName]
+		if li != lj {
+			return li < lj
+		}
+		return upserts[i].seq < upserts[j].seq
+	})
+
+	sort.Slice(deletes, func(i, j int) bool {
+		li, lj := levels[deletes[i].tableName], levels[deletes[j].tableName]
+		if li != lj {
+			return li > lj
+		}
+		return deletes[i].seq < deletes[j].seq
+	})
+
+	return append(upserts, deletes...)
+}
+
+// dependencyLevels memetakan tabel ke level FK dari GetAllTablesWithDependencies
+func (c *binlogCapture) dependencyLevels() map[string]int {
+	if c.levels != nil {
+		return c.levels
+	}
+
+	c.levels = make(map[string]int)
+
+	deps, err := c.s.schemaService.GetAllTablesWithDependencies()
+	if err != nil {
+		log.Printf("Warning: failed to get table dependencies, applying in commit order: %v", err)
+		return c.levels
+	}
+
+	for _, dep := range deps {
+		c.levels[dep.TableName] = dep.Level
+	}
+
+	return c.levels
+}
+
+// flush menerapkan transaksi yang ditampung ke backup dan menyimpan checkpoint
+// di transaksi backup yang sama, sehingga posisi dan data selalu konsisten
+func (c *binlogCapture) flush(ctx context.Context, cp binlogCheckpoint) error {
+	c.holdBlocked()
+
+	if len(c.pending) == 0 {
+		c.s.setBinlogPosition(cp)
+		if time.Since(c.lastSaved) < binlogIdleCheckpointInterval {
+			return nil
+		}
+		return c.saveCheckpoint(ctx, c.s.backupDB, cp)
+	}
+
+	changes := c.orderedChanges()
+	quarantineEnabled := c.s.config.Sync.QuarantineEnabled && c.s.quarantine != nil
+
+	tx, err := c.s.backupDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin backup transaction: %v", err)
+	}
+
+	type failedChange struct {
+		change *binlogChange
+		err    error
+	}
+	var failed []failedChange
+	applied := make(map[string]int)
+
+	for _, change := range changes {
+		// Savepoint supaya satu baris yang gagal bisa di-quarantine tanpa membatalkan transaksi
+		if quarantineEnabled {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT db_sync_row"); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to create savepoint: %v", err)
+			}
+		}
+
+		if err := c.apply(ctx, tx, change); err != nil {
+			if !quarantineEnabled || ctx.Err() != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to apply %s row %s: %v", change.tableName, change.key, err)
+			}
+
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT db_sync_row"); rbErr != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to rollback savepoint: %v", rbErr)
+			}
+
+			failed = append(failed, failedChange{change: change, err: err})
+			continue
+		}
+
+		if quarantineEnabled {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT db_sync_row"); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to release savepoint: %v", err)
+			}
+		}
+
+		applied[change.tableName]++
+	}
+
+	if err := c.saveCheckpoint(ctx, tx, cp); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit backup transaction: %v", err)
+	}
+
+	c.pending = make(map[string]*binlogChange)
+	c.s.setBinlogPosition(cp)
+
+	for tableName, count := range applied {
+		c.s.recordCapturedRows(tableName, count)
+	}
+
+	// Quarantine ditulis setelah commit (backup SQLite hanya punya satu koneksi)
+	for _, f := range failed {
+		if err := c.s.quarantine.Add(f.change.tableName, f.change.key, f.change.row, f.err); err != nil {
+			log.Printf("Warning: failed to quarantine %s row %s: %v", f.change.tableName, f.change.key, err)
+		}
+	}
+
+	return nil
+}
+
+// holdBlocked menahan perubahan tabel yang di-block schema (rename atau
+// perubahan yang menunggu approval) di memori, dan mengembalikannya ke pending
+// setelah tabel di-unblock. Perubahan yang lebih baru untuk baris yang sama
+// menggantikan yang ditahan. seq tidak di-reset antar transaksi supaya urutan
+// perubahan yang ditahan tetap sebelum perubahan sesudahnya. Checkpoint tetap
+// maju, jadi perubahan yang ditahan hilang jika proses restart sebelum tabel
+// di-unblock; tabel itu perlu full sync ulang.
+func (c *binlogCapture) holdBlocked() {
+	blocked := make(map[string]bool)
+	for key, change := range c.pending {
+		isBlocked, ok := blocked[change.tableName]
+		if !ok {
+			isBlocked = c.s.blockedBySchema(change.tableName)
+			blocked[change.tableName] = isBlocked
+		}
+		if isBlocked {
+			c.held[key] = change
+			delete(c.pending, key)
+		}
+	}
+
+	for key, change := range c.held {
+		isBlocked, ok := blocked[change.tableName]
+		if !ok {
+			isBlocked = c.s.schemaService.SchemaBlocked(change.tableName) != ""
+			blocked[change.tableName] = isBlocked
+		}
+		if isBlocked {
+			continue
+		}
+		if _, ok := c.pending[key]; !ok {
+			c.pending[key] = change
+		}
+		delete(c.held, key)
+	}
+}
+
+func (c *binlogCapture) apply(ctx context.Context, tx *sql.Tx, change *binlogChange) error {
+	if change.row == nil {
+		return c.s.deleteRowByPKValues(ctx, tx, c.s.target, change.tableName, change.pkColumns, change.pkValues)
+	}
+	return c.s.upsertRowInto(ctx, tx, c.s.target, change.tableName, change.pkColumns, change.row)
+}
+
+// ensureCheckpointTable membuat tabel checkpoint binlog di backup database jika belum ada
+func (c *binlogCapture) ensureCheckpointTable(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	exists, err := c.s.target.TableExists(ctx, c.s.backupDB, binlogCheckpointTable)
+	if err != nil {
+		return fmt.Errorf("failed to check binlog checkpoint table: %v", err)
+	}
+
+	if !exists {
+		query := c.s.target.CreateTableStatement(binlogCheckpointTable, binlogCheckpointColumns, "")
+		if _, err := c.s.backupDB.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create binlog checkpoint table: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *binlogCapture) loadCheckpoint(ctx context.Context) (binlogCheckpoint, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT binlog_file, binlog_pos, gtid_set FROM %s WHERE name = ?", binlogCheckpointTable)
+
+	var cp binlogCheckpoint
+	var pos int64
+	var gtidSet sql.NullString
+	err := c.s.backupDB.QueryRowContext(ctx, c.s.target.Rebind(query), binlogCheckpointName).Scan(&cp.File, &pos, &gtidSet)
+	if err == sql.ErrNoRows {
+		return cp, false, nil
+	}
+	if err != nil {
+		return cp, false, fmt.Errorf("failed to load binlog checkpoint: %v", err)
+	}
+
+	cp.Position = uint32(pos)
+	cp.GTIDSet = gtidSet.String
+
+	return cp, true, nil
+}
+
+// saveCheckpoint menyimpan posisi binlog dengan upsert dialect backup. UPDATE lalu
+// INSERT tidak bisa dipakai: MySQL melaporkan 0 affected rows jika nilainya sama.
+func (c *binlogCapture) saveCheckpoint(ctx context.Context, db sqlExecutor, cp binlogCheckpoint) error {
+	now := time.Now()
+
+	columns := make([]string, len(binlogCheckpointColumns))
+	for i, col := range binlogCheckpointColumns {
+		columns[i] = col.ColumnName
+	}
+	query := c.s.target.UpsertStatement(binlogCheckpointTable, columns, []string{"name"})
+
+	_, err := db.ExecContext(ctx, query, binlogCheckpointName, cp.File, int64(cp.Position), cp.GTIDSet, now)
+	if err != nil {
+		return fmt.Errorf("failed to save binlog checkpoint: %v", err)
+	}
+
+	c.lastSaved = now
+	return nil
+}
+
+func (s *SyncService) setBinlogPosition(cp binlogCheckpoint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.binlogPosition = cp.String()
+	s.lastRunTime = time.Now()
+}
+
+// recordCapturedRows menambah jumlah baris yang diterapkan dari binlog untuk satu tabel
+func (s *SyncService) recordCapturedRows(tableName string, count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tableStatus[tableName] == nil {
+		s.tableStatus[tableName] = &models.SyncStatus{TableName: tableName}
+	}
+
+	s.tableStatus[tableName].Status = "streaming"
+	s.tableStatus[tableName].TotalSynced += count
+	s.tableStatus[tableName].LastSyncTime = time.Now()
+	s.tableStatus[tableName].ErrorMessage = ""
+}
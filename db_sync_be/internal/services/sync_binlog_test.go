@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"db-sync-scheduler/internal/config"
+
+	"github.com/go-mysql-org/go-mysql/replication"
+)
+
+func TestBinlogEnumValue(t *testing.T) {
+	status := binlogEnum{values: enumValues("enum('Shipped','On Hold','Customer''s call')")}
+	perms := binlogEnum{set: true, values: enumValues("set('read','write','admin')")}
+
+	tests := []struct {
+		name string
+		enum binlogEnum
+		val  interface{}
+		want interface{}
+	}{
+		{"enum first", status, int64(1), "Shipped"},
+		{"enum with space", status, int64(2), "On Hold"},
+		{"enum escaped quote", status, int64(3), "Customer's call"},
+		{"enum invalid index", status, int64(0), ""},
+		{"enum out of range", status, int64(9), ""},
+		{"enum null", status, nil, nil},
+		{"set single", perms, int64(2), "write"},
+		{"set multiple", perms, int64(5), "read,admin"},
+		{"set empty", perms, int64(0), ""},
+		{"set null", perms, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.enum.value(tt.val); got != tt.want {
+				t.Errorf("value(%v) = %#v, want %#v", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleQueryFilter(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.MasterDB.Name = "classicmodels"
+	c := &binlogCapture{s: &SyncService{config: cfg}}
+
+	tests := []struct {
+		name   string
+		schema string
+		query  string
+		want   bool
+	}{
+		{"alter in master", "classicmodels", "ALTER TABLE customers ADD COLUMN note TEXT", true},
+		{"lowercase create", "classicmodels", "create table t (id int)", true},
+		{"leading comment", "classicmodels", "/* migrate */ DROP TABLE `t` /* generated by server */", true},
+		{"qualified name", "", "ALTER TABLE `classicmodels`.`orders` ADD INDEX (status)", true},
+		{"other database", "analytics", "ALTER TABLE events ADD COLUMN x INT", false},
+		{"savepoint", "classicmodels", "SAVEPOINT sp1", false},
+		{"grant", "classicmodels", "GRANT SELECT ON *.* TO 'u'@'%'", false},
+		{"create prefix of other word", "classicmodels", "CREATED_BY_TOOL", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ddlQueryPattern.MatchString(tt.query) && c.masterQuery([]byte(tt.schema), tt.query)
+			if got != tt.want {
+				t.Errorf("schema %q query %q handled = %v, want %v", tt.schema, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinlogFlushHoldsBlockedTables(t *testing.T) {
+	ddl := []string{
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT)`,
+	}
+	s := newSQLiteSyncService(t, nil, ddl, ddl)
+	c := newBinlogCapture(s)
+	c.levels = map[string]int{}
+
+	ctx := context.Background()
+	if err := c.ensureCheckpointTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	customers := &binlogTable{columns: []string{"id", "name"}, pkColumns: []string{"id"}}
+	orders := &binlogTable{columns: []string{"id", "status"}, pkColumns: []string{"id"}}
+
+	s.schemaService.heldTables["orders"] = "column change waiting for approval"
+
+	c.record("customers", customers, []interface{}{int64(1), "Atelier"}, false)
+	c.record("orders", orders, []interface{}{int64(10), "Shipped"}, false)
+	if err := c.flush(ctx, binlogCheckpoint{File: "binlog.000001", Position: 100}); err != nil {
+		t.Fatalf("flush with blocked table: %v", err)
+	}
+
+	count := func(table string) int {
+		var n int
+		if err := s.backupDB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if got := count("customers"); got != 1 {
+		t.Errorf("customers rows = %d, want 1", got)
+	}
+	if got := count("orders"); got != 0 {
+		t.Errorf("orders rows = %d while blocked, want 0", got)
+	}
+	if len(c.held) != 1 {
+		t.Fatalf("held changes = %d, want 1", len(c.held))
+	}
+
+	// Perubahan baru untuk baris yang sama menggantikan yang ditahan
+	c.record("orders", orders, []interface{}{int64(10), "Cancelled"}, false)
+	if err := c.flush(ctx, binlogCheckpoint{File: "binlog.000001", Position: 200}); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(s.schemaService.heldTables, "orders")
+	if err := c.flush(ctx, binlogCheckpoint{File: "binlog.000001", Position: 300}); err != nil {
+		t.Fatal(err)
+	}
+
+	var status string
+	if err := s.backupDB.QueryRow("SELECT status FROM orders WHERE id = 10").Scan(&status); err != nil {
+		t.Fatalf("held row not applied after unblock: %v", err)
+	}
+	if status != "Cancelled" {
+		t.Errorf("status = %q, want %q", status, "Cancelled")
+	}
+	if len(c.held) != 0 {
+		t.Errorf("held changes = %d after unblock, want 0", len(c.held))
+	}
+}
+
+func TestBinlogColumnMismatchResyncsTable(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.MasterDB.Name = "classicmodels"
+	master := []string{
+		`CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT, phone TEXT)`,
+		`INSERT INTO offices VALUES (1, 'San Francisco', '+1 650 219 4782'), (2, 'Boston', '+1 215 837 0825')`,
+	}
+	backup := []string{`CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT, phone TEXT)`}
+	s := newSQLiteSyncService(t, cfg, master, backup)
+	s.isRunning = true
+
+	// Progres lama tidak boleh membuat resync melewatkan baris
+	s.updateTableStatus("offices", "streaming", "", 2, 2)
+
+	c := newBinlogCapture(s)
+	tableMap := &replication.TableMapEvent{Schema: []byte("classicmodels"), Table: []byte("offices"), ColumnCount: 2}
+	event := &replication.RowsEvent{Table: tableMap, ColumnCount: 2, Rows: [][]interface{}{{int64(1), "San Francisco"}}}
+
+	if err := c.addRows(context.Background(), replication.WRITE_ROWS_EVENTv2, event); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := s.backupDB.QueryRow("SELECT COUNT(*) FROM offices").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("backup rows after resync = %d, want 2", count)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("pending changes = %d, want undecodable rows to be skipped", len(c.pending))
+	}
+	if !c.tables["offices"].resynced {
+		t.Error("table metadata not marked as resynced")
+	}
+}
+
+func TestBinlogSaveCheckpointUpserts(t *testing.T) {
+	s := newSQLiteSyncService(t, nil, nil, nil)
+	c := newBinlogCapture(s)
+
+	ctx := context.Background()
+	if err := c.ensureCheckpointTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoints := []binlogCheckpoint{
+		{File: "binlog.000001", Position: 100},
+		{File: "binlog.000001", Position: 100}, // posisi sama, mis. event tanpa perubahan
+		{File: "binlog.000002", Position: 4, GTIDSet: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"},
+	}
+	for _, cp := range checkpoints {
+		if err := c.saveCheckpoint(ctx, s.backupDB, cp); err != nil {
+			t.Fatalf("save %s: %v", cp, err)
+		}
+	}
+
+	got, found, err := c.loadCheckpoint(ctx)
+	if err != nil || !found {
+		t.Fatalf("loadCheckpoint() = %v, %v", found, err)
+	}
+	if got != checkpoints[2] {
+		t.Errorf("checkpoint = %+v, want %+v", got, checkpoints[2])
+	}
+
+	var rows int
+	if err := s.backupDB.QueryRow("SELECT COUNT(*) FROM " + binlogCheckpointTable).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 {
+		t.Errorf("checkpoint rows = %d, want 1", rows)
+	}
+}
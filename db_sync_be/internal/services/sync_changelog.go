@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// changelogChange adalah gabungan entry changelog untuk satu baris. Baris selalu
+// diambil ulang dari master, sehingga cukup diketahui PK-nya saja. row bernilai
+// nil jika baris sudah tidak ada di master.
+type changelogChange struct {
+	tableName string
+	pkColumns []string
+	pkValues  []interface{}
+	key       string
+	ids       []int64
+	firstID   int64
+	row       map[string]interface{}
+}
+
+// startTriggerCapture memvalidasi konfigurasi untuk capture berbasis trigger
+func (s *SyncService) startTriggerCapture() error {
+	if s.source.Name() != "mysql" {
+		return fmt.Errorf("trigger capture requires a mysql master, got %s", s.source.Name())
+	}
+	if s.config.Sync.Mode == SyncModeBidirectional {
+		return fmt.Errorf("trigger capture only supports %s mode", SyncModeOneWay)
+	}
+	if s.changelog == nil {
+		return fmt.Errorf("changelog service is not configured")
+	}
+	return nil
+}
+
+// runSyncCycle menjalankan satu siklus sinkronisasi sesuai capture mode. Mode
+// trigger melakukan snapshot penuh hanya pada run awal, selanjutnya cukup changelog.
+func (s *SyncService) runSyncCycle(initial bool) {
+	if s.config.Sync.CaptureMode != CaptureModeTrigger {
+		s.syncAllTables()
+		return
+	}
+
+	if initial {
+		s.syncAllTables()
+	}
+	s.drainChangelog()
+}
+
+// drainChangelog memproses changelog master secara bertahap lalu menghapus entry
+// yang sudah diterapkan. Entry tabel yang ditahan schema atau yang gagal
+// diterapkan tanpa quarantine dibiarkan di changelog dan dilewati sampai siklus
+// berikutnya, supaya tidak menghambat tabel lain.
+func (s *SyncService) drainChangelog() {
+	installed, err := s.changelog.InstalledTables()
+	if err != nil {
+		log.Printf("Error reading changelog triggers: %v", err)
+		return
+	}
+	if len(installed) == 0 {
+		log.Println("No changelog triggers installed on master, run `dbsyncctl triggers install` first")
+		return
+	}
+
+	levels := make(map[string]int)
+	if deps, err := s.schemaService.GetAllTablesWithDependencies(); err != nil {
+		log.Printf("Warning: failed to get table dependencies, applying in changelog order: %v", err)
+	} else {
+		for _, dep := range deps {
+			levels[dep.TableName] = dep.Level
+		}
+	}
+
+	pkCache := make(map[string][]string)
+	blocked := make(map[string]bool)
+	totalApplied, skipped := 0, 0
+	var lastID int64
+
+	for s.IsRunning() {
+		entries, err := s.changelog.Fetch(lastID, s.batchSize)
+		if err != nil {
+			log.Printf("Error fetching changelog: %v", err)
+			return
+		}
+
+		if len(entries) == 0 {
+			break
+		}
+		lastID = entries[len(entries)-1].ID
+
+		var ackIDs []int64
+		var resolved []*changelogChange
+
+		for _, change := range collapseChangelog(entries) {
+			isBlocked, ok := blocked[change.tableName]
+			if !ok {
+				isBlocked = s.blockedBySchema(change.tableName)
+				blocked[change.tableName] = isBlocked
+			}
+			if isBlocked {
+				skipped += len(change.ids)
+				continue
+			}
+
+			pkColumns, ok := pkCache[change.tableName]
+			if !ok {
+				pkColumns, err = s.getPrimaryKeyColumns(change.tableName)
+				if err != nil {
+					log.Printf("Error getting primary key for %s: %v", change.tableName, err)
+					skipped += len(change.ids)
+					continue
+				}
+				pkCache[change.tableName] = pkColumns
+			}
+
+			if len(pkColumns) != len(change.pkValues) {
+				// Primary key sudah berubah sejak entry dicatat, entry tidak bisa dipakai lagi
+				log.Printf("Warning: discarding changelog entry for %s row %s: primary key changed", change.tableName, change.key)
+				ackIDs = append(ackIDs, change.ids...)
+				continue
+			}
+			change.pkColumns = pkColumns
+
+			if err := s.fetchChangelogRow(change); err != nil {
+				// Entry tidak di-ack, dicoba lagi di siklus berikutnya
+				log.Printf("  Error fetching %s row %s: %v", change.tableName, change.key, err)
+				skipped += len(change.ids)
+				continue
+			}
+
+			resolved = append(resolved, change)
+		}
+
+		applied := make(map[string]int)
+		failed := make(map[string]string)
+
+		for _, change := range orderChangelogChanges(resolved, levels) {
+			if err := s.applyChangelogChange(change); err != nil {
+				if !s.config.Sync.QuarantineEnabled || s.quarantine == nil {
+					log.Printf("  Error applying %s row %s: %v", change.tableName, change.key, err)
+					failed[change.tableName] = err.Error()
+					skipped += len(change.ids)
+					continue
+				}
+
+				if qErr := s.quarantine.Add(change.tableName, change.key, change.row, err); qErr != nil {
+					log.Printf("  Error quarantining %s row %s: %v", change.tableName, change.key, qErr)
+					failed[change.tableName] = qErr.Error()
+					skipped += len(change.ids)
+					continue
+				}
+			} else {
+				applied[change.tableName]++
+			}
+
+			ackIDs = append(ackIDs, change.ids...)
+		}
+
+		if err := s.changelog.Ack(ackIDs); err != nil {
+			log.Printf("Error acknowledging changelog: %v", err)
+			return
+		}
+
+		for tableName, count := range applied {
+			s.recordCapturedRows(tableName, count)
+			totalApplied += count
+		}
+		for tableName, msg := range failed {
+			s.markTableStatus(tableName, "error", msg)
+		}
+
+		if len(entries) < s.batchSize {
+			break
+		}
+	}
+
+	if totalApplied > 0 {
+		log.Printf("Changelog drained: %d rows applied", totalApplied)
+	}
+	if skipped > 0 {
+		log.Printf("Changelog: %d entries left for the next cycle", skipped)
+	}
+}
+
+// fetchChangelogRow mengambil kondisi terbaru baris dari master
+func (s *SyncService) fetchChangelogRow(change *changelogChange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	row, err := s.fetchRowByPKValues(ctx, s.masterDB, s.source, change.tableName, change.pkColumns, change.pkValues)
+	if err != nil {
+		return err
+	}
+
+	change.row = row
+	return nil
+}
+
+// applyChangelogChange menyalin baris ke backup, atau menghapusnya dari backup
+// jika baris sudah tidak ada di master
+func (s *SyncService) applyChangelogChange(change *changelogChange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if change.row == nil {
+		return s.deleteRowByPKValues(ctx, s.backupDB, s.target, change.tableName, change.pkColumns, change.pkValues)
+	}
+
+	return s.upsertRowInto(ctx, s.backupDB, s.target, change.tableName, change.pkColumns, change.row)
+}
+
+// orderChangelogChanges mengurutkan perubahan sesuai FK: upsert dari parent ke
+// child, lalu delete dari child ke parent. Urutan changelog dipertahankan di
+// dalam level yang sama.
+func orderChangelogChanges(changes []*changelogChange, levels map[string]int) []*changelogChange {
+	var upserts, deletes []*changelogChange
+	for _, change := range changes {
+		if change.row == nil {
+			deletes = append(deletes, change)
+		} else {
+			upserts = append(upserts, change)
+		}
+	}
+
+	sort.SliceStable(upserts, func(i, j int) bool {
+		li, lj := levels[upserts[i].tableName], levels[upserts[j].tableName]
+		if li != lj {
+			return li < lj
+		}
+		return upserts[i].firstID < upserts[j].firstID
+	})
+
+	sort.SliceStable(deletes, func(i, j int) bool {
+		li, lj := levels[deletes[i].tableName], levels[deletes[j].tableName]
+		if li != lj {
+			return li > lj
+		}
+		return deletes[i].firstID < deletes[j].firstID
+	})
+
+	return append(upserts, deletes...)
+}
+
+// collapseChangelog menggabungkan entry untuk baris yang sama
+func collapseChangelog(entries []changelogEntry) []*changelogChange {
+	byKey := make(map[string]*changelogChange)
+	var changes []*changelogChange
+
+	for _, entry := range entries {
+		keyParts := make([]string, len(entry.PKValues))
+		for i, val := range entry.PKValues {
+			keyParts[i] = formatPKValue(val)
+		}
+		key := joinRowKey(keyParts)
+
+		change, ok := byKey[entry.TableName+"\x00"+key]
+		if !ok {
+			change = &changelogChange{
+				tableName: entry.TableName,
+				pkValues:  entry.PKValues,
+				key:       key,
+				firstID:   entry.ID,
+			}
+			byKey[entry.TableName+"\x00"+key] = change
+			changes = append(changes, change)
+		}
+		change.ids = append(change.ids, entry.ID)
+	}
+
+	return changes
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"db-sync-scheduler/internal/dialect"
+	"db-sync-scheduler/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// syncCycle menyalin tabel-tabel dalam satu siklus FK lewat satu sesi backup
+// dengan FK check dimatikan, lalu memverifikasi tidak ada baris yatim setelahnya
+func (s *SyncService) syncCycle(master sqlExecutor, group []models.TableDependency) {
+	tables := make([]string, len(group))
+	for i, dep := range group {
+		tables[i] = dep.TableName
+	}
+
+	log.Printf("Syncing FK cycle %v (Level: %d) in one session with foreign key checks disabled", tables, group[0].Level)
+
+	var backup sqlExecutor = s.backupDB
+	release, conn, err := s.beginUncheckedSession()
+	if err != nil {
+		log.Printf("Warning: %v, syncing cycle with foreign key checks enabled", err)
+	} else {
+		backup = conn
+	}
+
+	for _, dep := range group {
+		if !s.IsRunning() {
+			break
+		}
+		s.syncTable(master, backup, dep.TableName)
+	}
+
+	if release != nil {
+		release()
+	}
+
+	if s.IsRunning() {
+		s.verifyCycle(tables)
+	}
+}
+
+// beginUncheckedSession mem-pin satu koneksi backup dengan FK check dimatikan.
+// release menyalakan kembali FK check; jika gagal, koneksi dibuang dari pool
+// supaya sesi tanpa FK check tidak dipakai ulang.
+func (s *SyncService) beginUncheckedSession() (func(), sqlExecutor, error) {
+	disable, enable := s.target.ForeignKeyChecksStatements()
+	if disable == "" {
+		return nil, nil, fmt.Errorf("%s backup cannot disable foreign key checks", s.target.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := s.backupDB.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pin backup connection: %v", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, disable); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to disable foreign key checks: %v", err)
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, enable); err != nil {
+			log.Printf("Warning: failed to re-enable foreign key checks, discarding connection: %v", err)
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+
+	return release, conn, nil
+}
+
+// verifyCycle menghitung baris yatim untuk setiap FK antar tabel dalam siklus.
+// Tabel yang punya baris yatim ditandai "warning" di status sync.
+func (s *SyncService) verifyCycle(tables []string) {
+	inCycle := make(map[string]bool)
+	for _, table := range tables {
+		inCycle[table] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	for _, table := range tables {
+		fks, err := s.schemaService.GetForeignKeys(table)
+		if err != nil {
+			log.Printf("Warning: failed to verify FK cycle for %s: %v", table, err)
+			continue
+		}
+
+		var problems []string
+		for _, fk := range dialect.GroupForeignKeys(fks) {
+			if !inCycle[fk.ReferencedTableName] {
+				continue
+			}
+
+			orphans, err := countOrphanRows(ctx, s.backupDB, s.target, fk)
+			if err != nil {
+				log.Printf("Warning: failed to verify %s.%s: %v", table, fk.ConstraintName, err)
+				continue
+			}
+			if orphans > 0 {
+				problems = append(problems, fmt.Sprintf("%d rows violate %s", orphans, fk.ConstraintName))
+			}
+		}
+
+		if len(problems) > 0 {
+			msg := "FK cycle verification: " + strings.Join(problems, ", ")
+			log.Printf("Warning: %s: %s", table, msg)
+			s.markTableStatus(table, "warning", msg)
+		}
+	}
+}
+
+// markTableStatus mengubah status tabel tanpa menyentuh progres sync-nya,
+// mis. "warning" untuk tabel yang sudah di-sync tapi perlu diperiksa
+func (s *SyncService) markTableStatus(tableName, status, msg string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tableStatus[tableName] == nil {
+		s.tableStatus[tableName] = &models.SyncStatus{TableName: tableName}
+	}
+	s.tableStatus[tableName].Status = status
+	s.tableStatus[tableName].ErrorMessage = msg
+}
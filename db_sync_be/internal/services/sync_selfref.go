@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// selfRefLookupChunk membatasi jumlah placeholder per query lookup parent di backup
+const selfRefLookupChunk = 500
+
+// selfRefBackfillTimeout adalah batas waktu tiap UPDATE back-fill, bukan seluruh back-fill
+const selfRefBackfillTimeout = 30 * time.Second
+
+// selfReference adalah FK yang menunjuk ke tabel itu sendiri, mis.
+// employees.reportsTo → employees.employeeNumber
+type selfReference struct {
+	column           string
+	referencedColumn string
+}
+
+// selfRefBackfill adalah nilai FK yang sementara di-NULL-kan karena parent-nya
+// belum ada di backup, diisi kembali setelah semua batch tabel selesai
+type selfRefBackfill struct {
+	pkValues []interface{}
+	column   string
+	value    interface{}
+	row      map[string]interface{}
+}
+
+// selfRefLoader memuat tabel self-referencing dengan urutan parent dulu. Di dalam
+// satu batch baris diurutkan mengikuti hierarki; baris yang parent-nya belum ada
+// (mis. ada di batch berikutnya) disimpan dengan kolom FK NULL lalu di-backfill.
+// Nilai nil berarti tabel tidak punya self-reference.
+type selfRefLoader struct {
+	s         *SyncService
+	tableName string
+	pkColumns []string
+	refs      []selfReference
+	backfills []selfRefBackfill
+}
+
+// newSelfRefLoader mengembalikan nil jika tabel tidak punya FK ke dirinya sendiri
+func (s *SyncService) newSelfRefLoader(tableName string, pkColumns []string) *selfRefLoader {
+	fks, err := s.schemaService.GetForeignKeys(tableName)
+	if err != nil {
+		log.Printf("Warning: failed to get foreign keys for %s, self-references not handled: %v", tableName, err)
+		return nil
+	}
+
+	var refs []selfReference
+	for _, fk := range fks {
+		if fk.ReferencedTableName == tableName && fk.ColumnName != fk.ReferencedColumnName {
+			refs = append(refs, selfReference{column: fk.ColumnName, referencedColumn: fk.ReferencedColumnName})
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	return &selfRefLoader{s: s, tableName: tableName, pkColumns: pkColumns, refs: refs}
+}
+
+// prepare mengurutkan batch parent dulu dan men-NULL-kan FK yang parent-nya belum tersedia
+func (l *selfRefLoader) prepare(rows []map[string]interface{}) []map[string]interface{} {
+	if l == nil || len(rows) == 0 {
+		return rows
+	}
+
+	ordered := l.orderParentFirst(rows)
+
+	available, err := l.availableParents(ordered)
+	if err != nil {
+		// Tanpa informasi backup, anggap semua parent di luar batch belum ada
+		log.Printf("Warning: failed to look up parents of %s in backup: %v", l.tableName, err)
+	}
+
+	result := make([]map[string]interface{}, len(ordered))
+	for i, row := range ordered {
+		result[i] = row
+		copied := false
+
+		for _, ref := range l.refs {
+			val := row[ref.column]
+			if val == nil || available[ref.referencedColumn][formatPKValue(val)] {
+				continue
+			}
+
+			if !copied {
+				result[i] = copyRow(row)
+				copied = true
+			}
+			result[i][ref.column] = nil
+			pkValues := make([]interface{}, len(l.pkColumns))
+			for j, pk := range l.pkColumns {
+				pkValues[j] = row[pk]
+			}
+			l.backfills = append(l.backfills, selfRefBackfill{
+				pkValues: pkValues,
+				column:   ref.column,
+				value:    val,
+				row:      row,
+			})
+		}
+
+		// Baris ini sudah di-upsert ketika baris berikutnya diproses
+		for _, ref := range l.refs {
+			if val := row[ref.referencedColumn]; val != nil {
+				available[ref.referencedColumn][formatPKValue(val)] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// orderParentFirst mengurutkan baris sehingga parent di batch yang sama selalu
+// lebih dulu. Urutan asli dipertahankan selama tidak melanggar hierarki.
+func (l *selfRefLoader) orderParentFirst(rows []map[string]interface{}) []map[string]interface{} {
+	index := make(map[string]map[string]int)
+	for _, ref := range l.refs {
+		if index[ref.referencedColumn] == nil {
+			index[ref.referencedColumn] = make(map[string]int)
+		}
+		for i, row := range rows {
+			if val := row[ref.referencedColumn]; val != nil {
+				index[ref.referencedColumn][formatPKValue(val)] = i
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(rows))
+	ordered := make([]map[string]interface{}, 0, len(rows))
+
+	var visit func(i int)
+	visit = func(i int) {
+		// visiting berarti siklus di data (mis. A → B → A), putus di sini
+		if state[i] != unvisited {
+			return
+		}
+		state[i] = visiting
+
+		for _, ref := range l.refs {
+			val := rows[i][ref.column]
+			if val == nil {
+				continue
+			}
+			if parent, ok := index[ref.referencedColumn][formatPKValue(val)]; ok && parent != i {
+				visit(parent)
+			}
+		}
+
+		state[i] = done
+		ordered = append(ordered, rows[i])
+	}
+
+	for i := range rows {
+		visit(i)
+	}
+
+	return ordered
+}
+
+// availableParents mengembalikan nilai kolom referensi yang sudah ada di backup,
+// hanya untuk parent yang tidak ada di batch
+func (l *selfRefLoader) availableParents(rows []map[string]interface{}) (map[string]map[string]bool, error) {
+	available := make(map[string]map[string]bool)
+	inBatch := make(map[string]map[string]bool)
+	for _, ref := range l.refs {
+		available[ref.referencedColumn] = make(map[string]bool)
+		inBatch[ref.referencedColumn] = make(map[string]bool)
+		for _, row := range rows {
+			if val := row[ref.referencedColumn]; val != nil {
+				inBatch[ref.referencedColumn][formatPKValue(val)] = true
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, ref := range l.refs {
+		seen := make(map[string]bool)
+		var missing []interface{}
+		for _, row := range rows {
+			val := row[ref.column]
+			if val == nil {
+				continue
+			}
+			key := formatPKValue(val)
+			if inBatch[ref.referencedColumn][key] || seen[key] {
+				continue
+			}
+			seen[key] = true
+			missing = append(missing, val)
+		}
+
+		for start := 0; start < len(missing); start += selfRefLookupChunk {
+			end := start + selfRefLookupChunk
+			if end > len(missing) {
+				end = len(missing)
+			}
+
+			found, err := l.lookupBackup(ctx, ref.referencedColumn, missing[start:end])
+			if err != nil {
+				return available, err
+			}
+			for _, key := range found {
+				available[ref.referencedColumn][key] = true
+			}
+		}
+	}
+
+	return available, nil
+}
+
+func (l *selfRefLoader) lookupBackup(ctx context.Context, column string, values []interface{}) ([]string, error) {
+	target := l.s.target
+	placeholders := make([]string, len(values))
+	for i := range values {
+		placeholders[i] = "?"
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s)",
+		target.QuoteIdentifier(column), target.QuoteIdentifier(l.tableName),
+		target.QuoteIdentifier(column), strings.Join(placeholders, ", "))
+
+	rows, err := l.s.backupDB.QueryContext(ctx, target.Rebind(query), values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []string
+	for rows.Next() {
+		var val interface{}
+		if err := rows.Scan(&val); err != nil {
+			return nil, err
+		}
+		found = append(found, formatPKValue(val))
+	}
+
+	return found, rows.Err()
+}
+
+// backfill mengisi kembali FK yang di-NULL-kan. Baris yang parent-nya tetap tidak
+// ada di-quarantine (jika diaktifkan) dengan data aslinya.
+func (l *selfRefLoader) backfill() int {
+	if l == nil || len(l.backfills) == 0 {
+		return 0
+	}
+
+	target := l.s.target
+	quarantineEnabled := l.s.config.Sync.QuarantineEnabled && l.s.quarantine != nil
+	filled := 0
+
+	for _, b := range l.backfills {
+		filter, args := pkFilter(target, l.pkColumns, b.pkValues)
+		query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s",
+			target.QuoteIdentifier(l.tableName), target.QuoteIdentifier(b.column), filter)
+
+		ctx, cancel := context.WithTimeout(context.Background(), selfRefBackfillTimeout)
+		_, err := l.s.backupDB.ExecContext(ctx, target.Rebind(query), append([]interface{}{b.value}, args...)...)
+		cancel()
+
+		if err != nil {
+			pkValue := rowKey(b.row, l.pkColumns)
+			if !quarantineEnabled {
+				log.Printf("  Error back-filling %s.%s for row %s: %v", l.tableName, b.column, pkValue, err)
+				continue
+			}
+			if qErr := l.s.quarantine.Add(l.tableName, pkValue, b.row, err); qErr != nil {
+				log.Printf("  Error quarantining %s row %s: %v", l.tableName, pkValue, qErr)
+			}
+			continue
+		}
+
+		filled++
+	}
+
+	log.Printf("  Self-references back-filled: %d of %d", filled, len(l.backfills))
+	l.backfills = nil
+	return filled
+}
+
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	rowCopy := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		rowCopy[k] = v
+	}
+	return rowCopy
+}
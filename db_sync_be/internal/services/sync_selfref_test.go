@@ -0,0 +1,55 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSelfRefBackfillCompositePrimaryKey(t *testing.T) {
+	ddl := []string{`CREATE TABLE nodes (
+		tenant TEXT NOT NULL,
+		id INTEGER NOT NULL UNIQUE,
+		parent INTEGER REFERENCES nodes (id),
+		PRIMARY KEY (tenant, id))`}
+	s := newSQLiteSyncService(t, nil, ddl, ddl)
+
+	pkColumns := []string{"tenant", "id"}
+	l := s.newSelfRefLoader("nodes", pkColumns)
+	if l == nil {
+		t.Fatal("expected a self-reference loader for nodes")
+	}
+
+	// Parent baris 1 baru datang di batch kedua, FK-nya di-NULL-kan sementara
+	batches := [][]map[string]interface{}{
+		{{"tenant": "t1", "id": int64(1), "parent": int64(3)}},
+		{
+			{"tenant": "t1", "id": int64(2), "parent": nil},
+			{"tenant": "t1", "id": int64(3), "parent": nil},
+		},
+	}
+	for _, batch := range batches {
+		if _, _, err := s.upsertDataToBackup(s.backupDB, "nodes", pkColumns, l.prepare(batch)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if filled := l.backfill(); filled != 1 {
+		t.Fatalf("backfill filled %d rows, want 1", filled)
+	}
+
+	// Hanya baris (t1, 1) yang diisi, baris lain dengan tenant sama tidak tersentuh
+	want := map[int64]sql.NullInt64{
+		1: {Int64: 3, Valid: true},
+		2: {},
+		3: {},
+	}
+	for id, parent := range want {
+		var got sql.NullInt64
+		if err := s.backupDB.QueryRow("SELECT parent FROM nodes WHERE id = ?", id).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != parent {
+			t.Errorf("row %d parent = %+v, want %+v", id, got, parent)
+		}
+	}
+}
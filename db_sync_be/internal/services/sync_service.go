@@ -2,11 +2,16 @@ package services
 
 import (
 	"context"
+	"crypto/md5"
 	"database/sql"
 	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
 	"db-sync-scheduler/internal/models"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -24,14 +29,24 @@ type SyncService struct {
 	batchSize     int
 	tableStatus   map[string]*models.SyncStatus
 	schemaService *SchemaService
+	source        dialect.Dialect
+	target        dialect.Dialect
+	quarantine    *QuarantineService
+	conflicts     *ConflictService
+	changelog     *ChangelogService
 	syncSchema    bool
 	lastRunTime   time.Time
 	nextRunTime   time.Time
 	config        *config.AppConfig
+
+	// CDC binlog (CaptureModeBinlog)
+	captureCancel  context.CancelFunc
+	captureMutex   sync.Mutex
+	binlogPosition string
 }
 
 // NewSyncService creates a new sync service
-func NewSyncService(masterDB, backupDB *sql.DB, schemaService *SchemaService, cronSchedule string, batchSize int, autoSchemaSync bool, cfg *config.AppConfig) *SyncService {
+func NewSyncService(masterDB, backupDB *sql.DB, schemaService *SchemaService, quarantine *QuarantineService, conflicts *ConflictService, changelog *ChangelogService, cronSchedule string, batchSize int, autoSchemaSync bool, cfg *config.AppConfig) *SyncService {
 	return &SyncService{
 		masterDB:      masterDB,
 		backupDB:      backupDB,
@@ -41,6 +56,11 @@ func NewSyncService(masterDB, backupDB *sql.DB, schemaService *SchemaService, cr
 		batchSize:     batchSize,
 		tableStatus:   make(map[string]*models.SyncStatus),
 		schemaService: schemaService,
+		source:        schemaService.Source(),
+		target:        schemaService.Target(),
+		quarantine:    quarantine,
+		conflicts:     conflicts,
+		changelog:     changelog,
 		syncSchema:    autoSchemaSync,
 		config:        cfg,
 	}
@@ -55,6 +75,68 @@ func (s *SyncService) StartSync() error {
 		return fmt.Errorf("sync already running")
 	}
 
+	switch s.config.Sync.SnapshotScope {
+	case "", SnapshotScopeNone, SnapshotScopeRun, SnapshotScopeLevel:
+	default:
+		return fmt.Errorf("unsupported snapshot scope: %s", s.config.Sync.SnapshotScope)
+	}
+
+	switch s.config.Sync.DroppedColumnPolicy {
+	case "", DroppedColumnKeep, DroppedColumnDrop, DroppedColumnDeprecate:
+	default:
+		return fmt.Errorf("unsupported dropped column policy: %s", s.config.Sync.DroppedColumnPolicy)
+	}
+
+	switch s.config.Sync.DroppedPartitionPolicy {
+	case "", DroppedPartitionKeep, DroppedPartitionDrop:
+	default:
+		return fmt.Errorf("unsupported dropped partition policy: %s", s.config.Sync.DroppedPartitionPolicy)
+	}
+
+	switch s.config.Sync.DroppedTablePolicy {
+	case "", DroppedTableKeep, DroppedTableArchive, DroppedTableDrop:
+	default:
+		return fmt.Errorf("unsupported dropped table policy: %s", s.config.Sync.DroppedTablePolicy)
+	}
+
+	switch s.config.Sync.SchemaSnapshotMode {
+	case "", SchemaSnapshotOff, SchemaSnapshotTable, SchemaSnapshotFile:
+	default:
+		return fmt.Errorf("unsupported schema snapshot mode: %s", s.config.Sync.SchemaSnapshotMode)
+	}
+
+	switch s.config.Sync.SchemaMigrationFormat {
+	case "", MigrationFormatGolangMigrate, MigrationFormatFlyway:
+	default:
+		return fmt.Errorf("unsupported schema migration format: %s", s.config.Sync.SchemaMigrationFormat)
+	}
+
+	switch s.config.Sync.RenameDetection {
+	case "", RenameDetectionOff, RenameDetectionAuto, RenameDetectionConfirm:
+	default:
+		return fmt.Errorf("unsupported rename detection mode: %s", s.config.Sync.RenameDetection)
+	}
+
+	switch s.config.Sync.BackupTriggers {
+	case "", BackupTriggersSkip, BackupTriggersDisabled:
+	default:
+		return fmt.Errorf("unsupported backup triggers mode: %s", s.config.Sync.BackupTriggers)
+	}
+
+	if s.config.Sync.DeferForeignKeys && !canAddForeignKeys(s.target) {
+		return fmt.Errorf("deferred foreign keys are not supported with a %s backup: foreign keys cannot be added after the tables are created", s.target.Name())
+	}
+
+	if s.config.Sync.CaptureMode == CaptureModeBinlog {
+		return s.startBinlogCapture()
+	}
+
+	if s.config.Sync.CaptureMode == CaptureModeTrigger {
+		if err := s.startTriggerCapture(); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Starting synchronization service with schedule: %s", s.cronSchedule)
 
 	// Add cron job
@@ -73,7 +155,7 @@ func (s *SyncService) StartSync() error {
 		}
 
 		// Sync data
-		s.syncAllTables()
+		s.runSyncCycle(false)
 	})
 
 	if err != nil {
@@ -101,7 +183,7 @@ func (s *SyncService) StartSync() error {
 				log.Printf("Schema sync warning: %v", err)
 			}
 		}
-		s.syncAllTables()
+		s.runSyncCycle(true)
 
 		// Update next run time after initial sync
 		s.mutex.Lock()
@@ -128,6 +210,12 @@ func (s *SyncService) StopSync() error {
 	ctx := s.cron.Stop()
 	<-ctx.Done() // Wait for running jobs to finish
 
+	// Stop binlog capture, checkpoint sudah tersimpan per transaksi
+	if s.captureCancel != nil {
+		s.captureCancel()
+		s.captureCancel = nil
+	}
+
 	s.isRunning = false
 	log.Println("Synchronization service stopped")
 
@@ -147,12 +235,31 @@ func (s *SyncService) syncAllTables() {
 
 	log.Printf("Found %d tables to sync (ordered by FK dependencies)\n", len(tableDeps))
 
+	// Snapshot hanya untuk one_way, mode bidirectional juga menulis ke master
+	reader := &snapshotReader{s: s, scope: s.config.Sync.SnapshotScope}
+	if s.config.Sync.Mode == SyncModeBidirectional || reader.scope == "" {
+		reader.scope = SnapshotScopeNone
+	}
+	defer reader.Close()
+
 	// Sync setiap tabel berdasarkan dependency order
-	for _, dep := range tableDeps {
+	for i := 0; i < len(tableDeps); i++ {
+		dep := tableDeps[i]
 		if !s.IsRunning() {
 			break
 		}
 
+		// Anggota satu siklus FK selalu berurutan, di-sync bersama dalam satu sesi
+		if dep.HasCircular && s.config.Sync.Mode != SyncModeBidirectional {
+			end := i + 1
+			for end < len(tableDeps) && cycleKey(tableDeps[end]) == cycleKey(dep) {
+				end++
+			}
+			s.syncCycle(reader.executorFor(dep.Level), tableDeps[i:end])
+			i = end - 1
+			continue
+		}
+
 		// Log dependency info
 		if len(dep.DependsOn) > 0 {
 			log.Printf("Syncing table: %s (Level: %d, Dependencies: %v)",
@@ -162,18 +269,50 @@ func (s *SyncService) syncAllTables() {
 				dep.TableName, dep.Level)
 		}
 
-		if dep.HasCircular {
-			log.Printf("Table %s has circular dependency, syncing with caution", dep.TableName)
+		if s.config.Sync.Mode == SyncModeBidirectional {
+			if dep.HasCircular {
+				log.Printf("Table %s is part of FK cycle %v, syncing with caution", dep.TableName, dep.Cycle)
+			}
+			s.syncTableBidirectional(dep.TableName)
+		} else {
+			s.syncTable(reader.executorFor(dep.Level), s.backupDB, dep.TableName)
 		}
-
-		s.syncTable(dep.TableName)
 	}
 
 	log.Println("All tables sync completed")
+
+	// Initial load selesai, FK yang ditunda bisa dibuat dan divalidasi
+	if s.IsRunning() && s.schemaService.ForeignKeysDeferred() {
+		if err := s.schemaService.CreateDeferredForeignKeys(); err != nil {
+			log.Printf("Error creating deferred foreign keys: %v", err)
+		}
+	}
 }
 
-// syncTable melakukan sinkronisasi satu tabel
-func (s *SyncService) syncTable(tableName string) {
+// syncTable melakukan sinkronisasi satu tabel. Data master dibaca lewat master,
+// yaitu pool master atau koneksi snapshot yang di-pin; data ditulis lewat backup,
+// yaitu pool backup atau sesi dengan FK check mati untuk tabel dalam siklus.
+func (s *SyncService) syncTable(master, backup sqlExecutor, tableName string) {
+	if s.blockedBySchema(tableName) {
+		return
+	}
+
+	// Shadow copy bisa mulai setelah cek di atas; lock tabel memastikan tabel
+	// tidak ditukar selagi baris masih ditulis
+	lock := s.schemaService.tableLock(tableName)
+	if !lock.TryLock() {
+		log.Printf("Table %s is being synced or copied to a shadow table, skipping...", tableName)
+		return
+	}
+	defer lock.Unlock()
+
+	// LastSyncTime berikutnya adalah saat pembacaan dimulai (atau saat snapshot
+	// dibuka), bukan saat selesai; baris yang berubah selama tabel dibaca tetap
+	// terambil oleh poll updated_at berikutnya
+	readStartedAt := time.Now()
+	if snapshot, ok := master.(*masterSnapshot); ok {
+		readStartedAt = snapshot.startedAt
+	}
 
 	// Get atau create status untuk tabel ini
 	s.mutex.Lock()
@@ -192,30 +331,36 @@ func (s *SyncService) syncTable(tableName string) {
 	totalSynced := 0
 	currentOffset := status.LastSyncID
 	lastSyncTime := status.LastSyncTime
+	syncedUntil := readStartedAt
 
-	// Dapatkan primary key column
-	pkColumn, err := s.getPrimaryKeyColumn(tableName)
+	// Dapatkan primary key column; offset incremental memakai kolom PK pertama,
+	// upsert memakai semua kolom PK
+	pkColumns, err := s.getPrimaryKeyColumns(tableName)
 	if err != nil {
 		log.Printf("Error getting primary key for %s: %v", tableName, err)
-		s.updateTableStatus(tableName, "error", err.Error(), currentOffset, totalSynced)
+		s.updateTableStatusAt(tableName, "error", err.Error(), currentOffset, totalSynced, lastSyncTime)
 		return
 	}
 
-	if pkColumn == "" {
+	if len(pkColumns) == 0 {
 		log.Printf("Table %s has no primary key, skipping...", tableName)
-		s.updateTableStatus(tableName, "skipped", "no primary key", currentOffset, totalSynced)
+		s.updateTableStatusAt(tableName, "skipped", "no primary key", currentOffset, totalSynced, lastSyncTime)
 		return
 	}
+	pkColumn := pkColumns[0]
 
 	// Cek apakah tabel punya kolom updated_at
 	hasUpdatedAt := s.hasUpdatedAtColumn(tableName)
 
+	// Tabel dengan FK ke dirinya sendiri dimuat parent dulu
+	selfRefs := s.newSelfRefLoader(tableName, pkColumns)
+
 	// STEP 1: Sync data baru (incremental by ID)
 	for s.IsRunning() {
-		rows, err := s.fetchDataFromMaster(tableName, pkColumn, currentOffset, s.batchSize)
+		rows, err := s.fetchDataFromMaster(master, tableName, pkColumn, currentOffset, s.batchSize)
 		if err != nil {
 			log.Printf("Error fetching data from %s: %v", tableName, err)
-			s.updateTableStatus(tableName, "error", err.Error(), currentOffset, totalSynced)
+			s.updateTableStatusAt(tableName, "error", err.Error(), currentOffset, totalSynced, lastSyncTime)
 			return
 		}
 
@@ -223,10 +368,10 @@ func (s *SyncService) syncTable(tableName string) {
 			break
 		}
 
-		synced, lastID, err := s.upsertDataToBackup(tableName, pkColumn, rows)
+		synced, lastID, err := s.upsertDataToBackup(backup, tableName, pkColumns, selfRefs.prepare(rows))
 		if err != nil {
 			log.Printf("Error upserting data to %s: %v", tableName, err)
-			s.updateTableStatus(tableName, "error", err.Error(), currentOffset, totalSynced)
+			s.updateTableStatusAt(tableName, "error", err.Error(), currentOffset, totalSynced, lastSyncTime)
 			return
 		}
 
@@ -244,13 +389,16 @@ func (s *SyncService) syncTable(tableName string) {
 	if hasUpdatedAt && !lastSyncTime.IsZero() {
 		log.Printf("  Checking for updated records since %s", lastSyncTime.Format("2006-01-02 15:04:05"))
 
-		updatedRows, err := s.fetchUpdatedDataFromMaster(tableName, pkColumn, lastSyncTime)
+		updatedRows, err := s.fetchUpdatedDataFromMaster(master, tableName, pkColumn, lastSyncTime)
 		if err != nil {
+			// Perubahan dicoba lagi dari lastSyncTime yang sama di siklus berikutnya
 			log.Printf("Error fetching updated data from %s: %v", tableName, err)
+			syncedUntil = lastSyncTime
 		} else if len(updatedRows) > 0 {
-			synced, _, err := s.upsertDataToBackup(tableName, pkColumn, updatedRows)
+			synced, _, err := s.upsertDataToBackup(backup, tableName, pkColumns, selfRefs.prepare(updatedRows))
 			if err != nil {
 				log.Printf("Error upserting updated data to %s: %v", tableName, err)
+				syncedUntil = lastSyncTime
 			} else {
 				totalSynced += synced
 				log.Printf("  Updated data: %d records synced", synced)
@@ -259,11 +407,11 @@ func (s *SyncService) syncTable(tableName string) {
 	} else if s.config.Sync.EnableChecksumSync {
 		log.Printf("performing checksum-based sync for changed records")
 
-		changedRows, err := s.fetchChangedDataByChecksum(tableName, pkColumn)
+		changedRows, err := s.fetchChangedDataByChecksum(master, tableName, pkColumn)
 		if err != nil {
 			log.Printf("error fetching changed data from %s: %v", tableName, err)
 		} else if len(changedRows) > 0 {
-			synced, _, err := s.upsertDataToBackup(tableName, pkColumn, changedRows)
+			synced, _, err := s.upsertDataToBackup(backup, tableName, pkColumns, selfRefs.prepare(changedRows))
 			if err != nil {
 				log.Printf("error upserting changed data to %s: %v", tableName, err)
 			} else {
@@ -277,86 +425,51 @@ func (s *SyncService) syncTable(tableName string) {
 		log.Printf("Checksum sync disabled, skipping update detection for table without updated_at")
 	}
 
-	s.updateTableStatus(tableName, "success", "", currentOffset, totalSynced)
+	// Parent dari semua batch sudah ada, isi kembali FK yang sempat di-NULL-kan
+	selfRefs.backfill()
+
+	s.updateTableStatusAt(tableName, "success", "", currentOffset, totalSynced, syncedUntil)
 	log.Printf("Table %s synced: %d records\n", tableName, totalSynced)
 }
 
-// getPrimaryKeyColumn mendapatkan nama kolom primary key
-func (s *SyncService) getPrimaryKeyColumn(tableName string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	query := `SELECT COLUMN_NAME
-	          FROM information_schema.KEY_COLUMN_USAGE
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_NAME = ?
-	          AND CONSTRAINT_NAME = 'PRIMARY'
-	          LIMIT 1`
-
-	var pkColumn string
-	err := s.masterDB.QueryRowContext(ctx, query, tableName).Scan(&pkColumn)
-	if err != nil {
-		return "", nil // Tidak ada primary key
+// blockedBySchema mengecek apakah data tabel belum boleh di-sync karena schema
+// backup belum lengkap, mis. rename kolom atau DDL yang masih menunggu approval
+func (s *SyncService) blockedBySchema(tableName string) bool {
+	reason := s.schemaService.SchemaBlocked(tableName)
+	if reason == "" {
+		return false
 	}
 
-	return pkColumn, nil
+	log.Printf("Table %s is blocked (%s), skipping...", tableName, reason)
+	s.markTableStatus(tableName, "blocked", reason)
+	return true
 }
 
 func (s *SyncService) getPrimaryKeyColumns(tableName string) ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := `SELECT COLUMN_NAME
-	          FROM information_schema.KEY_COLUMN_USAGE
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_NAME = ?
-	          AND CONSTRAINT_NAME = 'PRIMARY'
-	          ORDER BY ORDINAL_POSITION`
-
-	rows, err := s.masterDB.QueryContext(ctx, query, tableName)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var pkColumns []string
-	for rows.Next() {
-		var columnName string
-		if err := rows.Scan(&columnName); err != nil {
-			return nil, err
-		}
-		pkColumns = append(pkColumns, columnName)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return pkColumns, nil
+	return s.source.GetPrimaryKeyColumns(ctx, s.masterDB, tableName)
 }
 
 // hasUpdatedAtColumn mengecek apakah tabel punya kolom updated_at
 func (s *SyncService) hasUpdatedAtColumn(tableName string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	query := `SELECT COUNT(*)
-	          FROM information_schema.COLUMNS
-	          WHERE TABLE_SCHEMA = DATABASE()
-	          AND TABLE_NAME = ?
-	          AND COLUMN_NAME = 'updated_at'`
-
-	var count int
-	err := s.masterDB.QueryRowContext(ctx, query, tableName).Scan(&count)
+	columns, err := s.getTableColumns(tableName)
 	if err != nil {
 		return false
 	}
 
-	return count > 0
+	for _, col := range columns {
+		if col == "updated_at" {
+			return true
+		}
+	}
+
+	return false
 }
 
 // fetchDataFromMaster mengambil data dari master database
-func (s *SyncService) fetchDataFromMaster(tableName, pkColumn string, offset, limit int) ([]map[string]interface{}, error) {
+func (s *SyncService) fetchDataFromMaster(master sqlExecutor, tableName, pkColumn string, offset, limit int) ([]map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -365,10 +478,10 @@ func (s *SyncService) fetchDataFromMaster(tableName, pkColumn string, offset, li
 		operator = ">="
 	}
 
-	query := fmt.Sprintf("SELECT * FROM `%s` WHERE `%s` %s ? ORDER BY `%s` LIMIT ?",
-		tableName, pkColumn, operator, pkColumn)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s %s ? ORDER BY %s LIMIT ?",
+		s.source.QuoteIdentifier(tableName), s.source.QuoteIdentifier(pkColumn), operator, s.source.QuoteIdentifier(pkColumn))
 
-	rows, err := s.masterDB.QueryContext(ctx, query, offset, limit)
+	rows, err := master.QueryContext(ctx, s.source.Rebind(query), offset, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -413,15 +526,15 @@ func (s *SyncService) fetchDataFromMaster(tableName, pkColumn string, offset, li
 }
 
 // fetchUpdatedDataFromMaster mengambil data yang di-update sejak lastSyncTime
-func (s *SyncService) fetchUpdatedDataFromMaster(tableName, pkColumn string, lastSyncTime time.Time) ([]map[string]interface{}, error) {
+func (s *SyncService) fetchUpdatedDataFromMaster(master sqlExecutor, tableName, pkColumn string, lastSyncTime time.Time) ([]map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Query untuk ambil data yang updated_at > lastSyncTime
-	query := fmt.Sprintf("SELECT * FROM `%s` WHERE `updated_at` > ? ORDER BY `updated_at` LIMIT 1000",
-		tableName)
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s > ? ORDER BY %s LIMIT 1000",
+		s.source.QuoteIdentifier(tableName), s.source.QuoteIdentifier("updated_at"), s.source.QuoteIdentifier("updated_at"))
 
-	rows, err := s.masterDB.QueryContext(ctx, query, lastSyncTime)
+	rows, err := master.QueryContext(ctx, s.source.Rebind(query), lastSyncTime)
 	if err != nil {
 		return nil, err
 	}
@@ -467,34 +580,60 @@ func (s *SyncService) fetchUpdatedDataFromMaster(tableName, pkColumn string, las
 
 // getTableColumns retrieves all column names for a table
 func (s *SyncService) getTableColumns(tableName string) ([]string, error) {
-	query := `SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS 
-			  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? 
-			  ORDER BY ORDINAL_POSITION`
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	rows, err := s.masterDB.Query(query, tableName)
+	columnInfos, err := s.source.GetColumns(ctx, s.masterDB, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns for table %s: %w", tableName, err)
 	}
-	defer rows.Close()
 
 	var columns []string
-	for rows.Next() {
-		var columnName string
-		if err := rows.Scan(&columnName); err != nil {
-			return nil, fmt.Errorf("failed to scan column name: %w", err)
+	for _, col := range columnInfos {
+		if s.generatedOnBackup(col) {
+			continue
 		}
-		columns = append(columns, columnName)
+		columns = append(columns, col.ColumnName)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating columns: %w", err)
+	return columns, nil
+}
+
+// generatedOnBackup mengecek apakah kolom generated juga dibuat sebagai kolom
+// generated di backup (dialect sama), nilainya tidak boleh ditulis
+func (s *SyncService) generatedOnBackup(col models.ColumnInfo) bool {
+	return col.GenerationExpression != "" && s.source.Name() == s.target.Name()
+}
+
+// dropGeneratedColumns membuang kolom generated dari baris hasil SELECT *, sama
+// seperti capture binlog, karena backup menghitung nilainya sendiri
+func (s *SyncService) dropGeneratedColumns(tableName string, rows ...map[string]interface{}) error {
+	if s.source.Name() != s.target.Name() {
+		return nil
 	}
 
-	return columns, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	columns, err := s.source.GetColumns(ctx, s.masterDB, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to get columns for table %s: %w", tableName, err)
+	}
+
+	for _, col := range columns {
+		if !s.generatedOnBackup(col) {
+			continue
+		}
+		for _, row := range rows {
+			delete(row, col.ColumnName)
+		}
+	}
+
+	return nil
 }
 
 // fetchChangedDataByChecksum membandingkan checksum data antara master dan backup untuk mendeteksi perubahan
-func (s *SyncService) fetchChangedDataByChecksum(tableName, pkColumn string) ([]map[string]interface{}, error) {
+func (s *SyncService) fetchChangedDataByChecksum(master sqlExecutor, tableName, pkColumn string) ([]map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
@@ -510,26 +649,20 @@ func (s *SyncService) fetchChangedDataByChecksum(tableName, pkColumn string) ([]
 		return nil, fmt.Errorf("failed to get table columns: %w", err)
 	}
 
-	// Build CONCAT_WS expression with COALESCE for NULL handling
-	var concatColumns []string
-	for _, col := range columns {
-		concatColumns = append(concatColumns, fmt.Sprintf("COALESCE(`%s`, '')", col))
-	}
-	concatExpr := strings.Join(concatColumns, ", ")
-
-	// build PK columns for SELECT
-	var pkSelectColumns []string
-	for _, pk := range pkColumns {
-		pkSelectColumns = append(pkSelectColumns, fmt.Sprintf("`%s`", pk))
+	// Checksum SQL hanya bisa dibandingkan jika kedua sisi memakai dialect yang
+	// sama dan punya fungsi hash, selain itu bandingkan data di aplikasi
+	checksumExpr := s.source.ChecksumExpression(columns)
+	if checksumExpr == "" || s.source.Name() != s.target.Name() {
+		return s.fetchChangedDataByDigest(ctx, master, tableName, pkColumns)
 	}
-	pkSelectExpr := strings.Join(pkSelectColumns, ", ")
 
 	// Get all master data with checksums
+	pkSelectExpr := dialect.QuoteIdentifiers(s.source, pkColumns)
 	masterQuery := fmt.Sprintf(
-		"SELECT *, MD5(CONCAT_WS('|', %s)) as row_checksum FROM `%s` ORDER BY %s",
-		concatExpr, tableName, pkSelectExpr)
+		"SELECT *, %s FROM %s ORDER BY %s",
+		checksumExpr, s.source.QuoteIdentifier(tableName), pkSelectExpr)
 
-	masterRows, err := s.masterDB.QueryContext(ctx, masterQuery)
+	masterRows, err := master.QueryContext(ctx, masterQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query master data: %w", err)
 	}
@@ -540,9 +673,10 @@ func (s *SyncService) fetchChangedDataByChecksum(tableName, pkColumn string) ([]
 		return nil, fmt.Errorf("failed to scan master rows: %w", err)
 	}
 
+	backupPKExpr := dialect.QuoteIdentifiers(s.target, pkColumns)
 	backupQuery := fmt.Sprintf(
-		"SELECT %s, MD5(CONCAT_WS('|', %s)) as row_checksum FROM `%s` ORDER BY %s",
-		pkSelectExpr, concatExpr, tableName, pkSelectExpr)
+		"SELECT %s, %s FROM %s ORDER BY %s",
+		backupPKExpr, s.target.ChecksumExpression(columns), s.target.QuoteIdentifier(tableName), backupPKExpr)
 
 	backupRows, err := s.backupDB.QueryContext(ctx, backupQuery)
 	if err != nil {
@@ -585,7 +719,7 @@ func (s *SyncService) fetchChangedDataByChecksum(tableName, pkColumn string) ([]
 			}
 			keyParts = append(keyParts, pkStr)
 		}
-		compositeKey := strings.Join(keyParts, "|")
+		compositeKey := joinRowKey(keyParts)
 
 		// Last value is checksum - convert byte array to string
 		checksumValue := scanValues[len(pkColumns)]
@@ -635,7 +769,7 @@ func (s *SyncService) fetchChangedDataByChecksum(tableName, pkColumn string) ([]
 			masterChecksum = fmt.Sprintf("%v", masterChecksumValue)
 		}
 
-		compositeKey := strings.Join(keyParts, "|")
+		compositeKey := joinRowKey(keyParts)
 		backupChecksum, exists := backupChecksums[compositeKey]
 
 		// If row doesn't exist in backup OR checksums are different
@@ -652,6 +786,147 @@ func (s *SyncService) fetchChangedDataByChecksum(tableName, pkColumn string) ([]
 	return changedRows, nil
 }
 
+// fetchChangedDataByDigest membandingkan data master dan backup dengan checksum yang
+// dihitung di aplikasi, untuk dialect yang tidak punya fungsi hash
+func (s *SyncService) fetchChangedDataByDigest(ctx context.Context, master sqlExecutor, tableName string, pkColumns []string) ([]map[string]interface{}, error) {
+	masterRows, err := master.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s", s.source.QuoteIdentifier(tableName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query master data: %w", err)
+	}
+	defer masterRows.Close()
+
+	masterData, err := s.scanRowsToMaps(masterRows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan master rows: %w", err)
+	}
+
+	backupRows, err := s.backupDB.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s", s.target.QuoteIdentifier(tableName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backup data: %w", err)
+	}
+	defer backupRows.Close()
+
+	backupData, err := s.scanRowsToMaps(backupRows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan backup rows: %w", err)
+	}
+
+	backupDigests := make(map[string]string)
+	for _, row := range backupData {
+		backupDigests[rowKey(row, pkColumns)] = rowDigest(row)
+	}
+
+	var changedRows []map[string]interface{}
+	for _, masterRow := range masterData {
+		backupDigest, exists := backupDigests[rowKey(masterRow, pkColumns)]
+		if !exists || backupDigest != rowDigest(masterRow) {
+			changedRows = append(changedRows, masterRow)
+		}
+	}
+
+	return changedRows, nil
+}
+
+// rowKey menggabungkan nilai PK menjadi satu key
+func rowKey(row map[string]interface{}, pkColumns []string) string {
+	keyParts := make([]string, len(pkColumns))
+	for i, pk := range pkColumns {
+		keyParts[i] = formatPKValue(row[pk])
+	}
+	return joinRowKey(keyParts)
+}
+
+// joinRowKey menggabungkan nilai PK dengan "|"; "|" dan "\" di dalam nilai
+// di-escape dengan "\" supaya key bisa dipecah kembali oleh rowKeyValues
+func joinRowKey(parts []string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = rowKeyEscaper.Replace(part)
+	}
+	return strings.Join(escaped, "|")
+}
+
+var rowKeyEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`)
+
+// rowKeyValues memecah key hasil rowKey kembali menjadi nilai per kolom PK
+func rowKeyValues(key string, pkColumns []string) ([]interface{}, error) {
+	var values []interface{}
+	var part strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key):
+			i++
+			part.WriteByte(key[i])
+		case key[i] == '|':
+			values = append(values, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(key[i])
+		}
+	}
+	values = append(values, part.String())
+
+	if len(values) != len(pkColumns) {
+		return nil, fmt.Errorf("pk value %q does not match primary key columns %v", key, pkColumns)
+	}
+	return values, nil
+}
+
+// rowKeyHash adalah MD5 hex dari key baris. Tabel internal memakainya sebagai
+// unique key karena key PK komposit yang di-escape bisa melebihi panjang index varchar.
+func rowKeyHash(key string) string {
+	sum := md5.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// rowDigest menghitung MD5 dari nilai-nilai baris yang sudah dinormalisasi,
+// supaya representasi yang berbeda antar driver tetap menghasilkan digest yang sama
+func rowDigest(row map[string]interface{}) string {
+	columns := make([]string, 0, len(row))
+	for col := range row {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		parts[i] = col + "=" + normalizeDigestValue(row[col])
+	}
+
+	sum := md5.Sum([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeDigestValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return normalizeDigestValue(string(v))
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999")
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if t, ok := toTime(v); ok {
+			return normalizeDigestValue(t)
+		}
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 func (s *SyncService) scanRowsToMaps(rows *sql.Rows) ([]map[string]interface{}, error) {
 	columns, err := rows.Columns()
 	if err != nil {
@@ -687,54 +962,61 @@ func (s *SyncService) scanRowsToMaps(rows *sql.Rows) ([]map[string]interface{},
 	return results, rows.Err()
 }
 
-// upsertDataToBackup melakukan insert atau update data ke backup database
-func (s *SyncService) upsertDataToBackup(tableName, pkColumn string, rows []map[string]interface{}) (int, int, error) {
+// upsertDataToBackup melakukan insert atau update data ke backup database.
+// Baris yang gagal di-apply dipindahkan ke quarantine (jika diaktifkan) supaya
+// baris berikutnya tetap bisa diproses.
+func (s *SyncService) upsertDataToBackup(backup sqlExecutor, tableName string, pkColumns []string, rows []map[string]interface{}) (int, int, error) {
 	if len(rows) == 0 {
 		return 0, 0, nil
 	}
 
+	if err := s.dropGeneratedColumns(tableName, rows...); err != nil {
+		return 0, 0, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
+	quarantineEnabled := s.config.Sync.QuarantineEnabled && s.quarantine != nil
+
+	var quarantinedKeys map[string]bool
+	if quarantineEnabled {
+		keys, err := s.quarantine.PendingKeys(tableName)
+		if err != nil {
+			log.Printf("Warning: failed to load quarantined keys for %s: %v", tableName, err)
+		}
+		quarantinedKeys = keys
+	}
+
 	synced := 0
 	lastID := 0
 
 	for _, row := range rows {
-		// Build column names and placeholders
-		var columns []string
-		var placeholders []string
-		var values []interface{}
-		var updates []string
-
-		for col, val := range row {
-			columns = append(columns, fmt.Sprintf("`%s`", col))
-			placeholders = append(placeholders, "?")
-			values = append(values, val)
-
-			// Untuk ON DUPLICATE KEY UPDATE
-			if col != pkColumn {
-				updates = append(updates, fmt.Sprintf("`%s` = VALUES(`%s`)", col, col))
+		pkValue := rowKey(row, pkColumns)
+
+		if err := s.upsertRowInto(ctx, backup, s.target, tableName, pkColumns, row); err != nil {
+			// Timeout atau context habis bukan kesalahan baris, jangan di-quarantine
+			if !quarantineEnabled || ctx.Err() != nil {
+				return synced, lastID, fmt.Errorf("failed to upsert row: %v", err)
 			}
-		}
 
-		// Build query
-		query := fmt.Sprintf(
-			"INSERT INTO `%s` (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
-			tableName,
-			strings.Join(columns, ", "),
-			strings.Join(placeholders, ", "),
-			strings.Join(updates, ", "),
-		)
+			if qErr := s.quarantine.Add(tableName, pkValue, row, err); qErr != nil {
+				return synced, lastID, qErr
+			}
+		} else {
+			synced++
 
-		_, err := s.backupDB.ExecContext(ctx, query, values...)
-		if err != nil {
-			return synced, lastID, fmt.Errorf("failed to upsert row: %v", err)
+			if quarantinedKeys[pkValue] {
+				if err := s.quarantine.Remove(tableName, pkValue); err != nil {
+					log.Printf("Warning: failed to release quarantined row %s in %s: %v", pkValue, tableName, err)
+				} else {
+					log.Printf("  Row %s in %s released from quarantine", pkValue, tableName)
+				}
+			}
 		}
 
-		synced++
-
-		// Update last ID
-		if pkVal, ok := row[pkColumn]; ok {
+		// Update last ID, termasuk baris yang di-quarantine supaya tidak diulang terus
+		if pkVal, ok := row[pkColumns[0]]; ok {
 			switch v := pkVal.(type) {
 			case int:
 				lastID = v
@@ -749,7 +1031,48 @@ func (s *SyncService) upsertDataToBackup(tableName, pkColumn string, rows []map[
 	return synced, lastID, nil
 }
 
+// upsertRow melakukan insert atau update satu baris ke backup database
+func (s *SyncService) upsertRow(ctx context.Context, tableName string, pkColumns []string, row map[string]interface{}) error {
+	return s.upsertRowInto(ctx, s.backupDB, s.target, tableName, pkColumns, row)
+}
+
+// sqlExecutor dipenuhi oleh *sql.DB, *sql.Conn dan *sql.Tx
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// upsertRowInto melakukan insert atau update satu baris ke database tujuan
+func (s *SyncService) upsertRowInto(ctx context.Context, db sqlExecutor, target dialect.Dialect, tableName string, pkColumns []string, row map[string]interface{}) error {
+	var columns []string
+	var values []interface{}
+
+	for col, val := range row {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	query := target.UpsertStatement(tableName, columns, pkColumns)
+
+	_, err := db.ExecContext(ctx, query, values...)
+	return err
+}
+
+// formatPKValue mengubah nilai primary key menjadi string untuk disimpan/dibandingkan
+func formatPKValue(val interface{}) string {
+	if b, ok := val.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
 func (s *SyncService) updateTableStatus(tableName, status, errMsg string, lastID, totalSynced int) {
+	s.updateTableStatusAt(tableName, status, errMsg, lastID, totalSynced, time.Now())
+}
+
+// updateTableStatusAt menyimpan status tabel dengan LastSyncTime yang diberikan,
+// yaitu batas bawah poll updated_at berikutnya
+func (s *SyncService) updateTableStatusAt(tableName, status, errMsg string, lastID, totalSynced int, syncTime time.Time) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -760,7 +1083,23 @@ func (s *SyncService) updateTableStatus(tableName, status, errMsg string, lastID
 	s.tableStatus[tableName].Status = status
 	s.tableStatus[tableName].LastSyncID = lastID
 	s.tableStatus[tableName].TotalSynced = totalSynced
-	s.tableStatus[tableName].LastSyncTime = time.Now()
+	s.tableStatus[tableName].LastSyncTime = syncTime
+	s.tableStatus[tableName].ErrorMessage = errMsg
+}
+
+// resetTableStatus mengosongkan progres sync tabel (LastSyncID dan LastSyncTime),
+// sehingga syncTable berikutnya menyalin ulang seluruh tabel
+func (s *SyncService) resetTableStatus(tableName, status, errMsg string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tableStatus[tableName] == nil {
+		s.tableStatus[tableName] = &models.SyncStatus{TableName: tableName}
+	}
+
+	s.tableStatus[tableName].Status = status
+	s.tableStatus[tableName].LastSyncID = 0
+	s.tableStatus[tableName].LastSyncTime = time.Time{}
 	s.tableStatus[tableName].ErrorMessage = errMsg
 }
 
@@ -796,6 +1135,14 @@ func (s *SyncService) GetStatus() map[string]interface{} {
 		"cronSchedule":   s.cronSchedule,
 		"batchSize":      s.batchSize,
 		"autoSchemaSync": s.syncSchema,
+		"mode":           s.config.Sync.Mode,
+		"captureMode":    s.config.Sync.CaptureMode,
+		"binlogPosition": s.binlogPosition,
+		"snapshotScope":  s.config.Sync.SnapshotScope,
+		"schemaApproval": s.config.Sync.RequireSchemaApproval,
+		"schemaProgress": s.schemaService.SchemaProgress(),
+		"masterDriver":   s.source.Name(),
+		"backupDriver":   s.target.Name(),
 		"lastRun":        lastRun,
 		"nextRun":        nextRun,
 		"tables":         tableStatusCopy,
@@ -839,3 +1186,215 @@ func (s *SyncService) TriggerSchemaSync() error {
 	log.Println("Manual schema sync triggered")
 	return s.schemaService.SyncAllSchemas()
 }
+
+// PendingRenames mengembalikan rename kolom yang menunggu konfirmasi
+func (s *SyncService) PendingRenames() []models.ColumnRename {
+	return s.schemaService.PendingRenames()
+}
+
+// ResolveRename menerima atau menolak rename kolom, lalu langsung menyamakan
+// schema tabel supaya sync datanya tidak tertahan sampai jadwal berikutnya
+func (s *SyncService) ResolveRename(tableName, oldName, newName string, accept bool) error {
+	if tableName == "" || oldName == "" || newName == "" {
+		return fmt.Errorf("table name, old name and new name are required")
+	}
+
+	if err := s.schemaService.ResolveRename(tableName, oldName, newName, accept); err != nil {
+		return err
+	}
+
+	return s.schemaService.SyncSchema(tableName)
+}
+
+// PendingTableRenames mengembalikan rename tabel yang menunggu konfirmasi
+func (s *SyncService) PendingTableRenames() []models.TableRename {
+	return s.schemaService.PendingTableRenames()
+}
+
+// ResolveTableRename menerima atau menolak rename tabel, lalu langsung menyamakan
+// schema tabel baru supaya sync datanya tidak tertahan sampai jadwal berikutnya
+func (s *SyncService) ResolveTableRename(oldName, newName string, accept bool) error {
+	if oldName == "" || newName == "" {
+		return fmt.Errorf("old name and new name are required")
+	}
+
+	if err := s.schemaService.ResolveTableRename(oldName, newName, accept); err != nil {
+		return err
+	}
+
+	return s.schemaService.SyncSchema(newName)
+}
+
+// DependencyGraph mengembalikan graph dependency FK tabel master
+func (s *SyncService) DependencyGraph() (models.DependencyGraph, error) {
+	return s.schemaService.DependencyGraph()
+}
+
+// SchemaDiff mengembalikan DDL yang akan dijalankan di backup tanpa menjalankannya,
+// untuk satu tabel atau semua tabel jika tableName kosong
+func (s *SyncService) SchemaDiff(tableName string) ([]models.TablePlan, error) {
+	if tableName == "" {
+		return s.schemaService.PlanAllSchemas()
+	}
+
+	plan, err := s.schemaService.PlanSchema(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return []models.TablePlan{plan}, nil
+}
+
+// ApproveSchemaChanges menyetujui DDL yang tertahan lalu langsung menjalankannya
+func (s *SyncService) ApproveSchemaChanges(tableName, statement string) (int, error) {
+	if tableName == "" {
+		return 0, fmt.Errorf("table name is required")
+	}
+
+	approved, err := s.schemaService.ApproveChanges(tableName, statement)
+	if err != nil {
+		return 0, err
+	}
+
+	return approved, s.schemaService.SyncSchema(tableName)
+}
+
+// SchemaHistory mengembalikan DDL yang dijalankan di backup dan drift yang
+// terdeteksi, terbaru lebih dulu. Limit default 100, maksimal 1000.
+func (s *SyncService) SchemaHistory(tableName string, limit int) ([]models.SchemaHistoryEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	return s.schemaService.History(tableName, limit)
+}
+
+// SchemaSnapshots mengembalikan snapshot tabel backup terbaru lebih dulu
+func (s *SyncService) SchemaSnapshots(tableName string) ([]models.TableSnapshot, error) {
+	return s.schemaService.Snapshots(tableName)
+}
+
+// RestoreSchemaSnapshot mengembalikan tabel backup dari snapshot. Jika auto
+// schema sync aktif, run berikutnya menyamakan tabel lagi dengan master.
+// Isi tabel kembali ke saat snapshot, jadi progres sync tabel di-reset supaya
+// disalin ulang penuh; mode capture langsung menjalankan resync karena tidak
+// pernah memanggil syncTable lagi.
+func (s *SyncService) RestoreSchemaSnapshot(id string) (*models.TableSnapshot, error) {
+	if id == "" {
+		return nil, fmt.Errorf("snapshot id is required")
+	}
+
+	snapshot, err := s.schemaService.RestoreSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := snapshot.TableName
+	s.resetTableStatus(tableName, "warning", fmt.Sprintf("restored from snapshot %s, waiting for full resync", snapshot.ID))
+
+	// Checkpoint bidirectional tidak lagi sesuai isi backup; tanpa reset, baris
+	// yang hilang karena restore dianggap delete di backup dan dikirim ke master
+	if s.config.Sync.Mode == SyncModeBidirectional && s.conflicts != nil {
+		if err := s.conflicts.ResetTable(tableName); err != nil {
+			return snapshot, err
+		}
+	}
+
+	switch s.config.Sync.CaptureMode {
+	case CaptureModeBinlog, CaptureModeTrigger:
+		if s.IsRunning() {
+			go s.syncTable(s.masterDB, s.backupDB, tableName)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// ListQuarantined mengembalikan baris yang sedang di-quarantine
+func (s *SyncService) ListQuarantined(tableName string) ([]models.QuarantinedRow, error) {
+	return s.quarantine.List(tableName)
+}
+
+// RetryQuarantined mencoba ulang baris di quarantine menggunakan data terbaru dari master.
+// Jika pkValue kosong, semua baris quarantine untuk tabel tersebut akan dicoba ulang.
+func (s *SyncService) RetryQuarantined(tableName, pkValue string) (int, error) {
+	if tableName == "" {
+		return 0, fmt.Errorf("table name is required")
+	}
+
+	var pkValues []string
+	if pkValue != "" {
+		pkValues = []string{pkValue}
+	} else {
+		keys, err := s.quarantine.PendingKeys(tableName)
+		if err != nil {
+			return 0, err
+		}
+		for key := range keys {
+			pkValues = append(pkValues, key)
+		}
+	}
+
+	pkColumns, err := s.getPrimaryKeyColumns(tableName)
+	if err != nil {
+		return 0, err
+	}
+	if len(pkColumns) == 0 {
+		return 0, fmt.Errorf("table %s has no primary key", tableName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	recovered := 0
+	var lastErr error
+
+	for _, key := range pkValues {
+		values, err := rowKeyValues(key, pkColumns)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		row, err := s.fetchRowByPKValues(ctx, s.masterDB, s.source, tableName, pkColumns, values)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch row %s from master: %v", key, err)
+			continue
+		}
+
+		// Baris sudah tidak ada di master, tidak ada yang perlu di-apply
+		if row == nil {
+			log.Printf("Quarantined row %s in %s no longer exists on master, discarding", key, tableName)
+			if err := s.quarantine.Remove(tableName, key); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+
+		if err := s.upsertRow(ctx, tableName, pkColumns, row); err != nil {
+			if qErr := s.quarantine.Add(tableName, key, row, err); qErr != nil {
+				return recovered, qErr
+			}
+			lastErr = fmt.Errorf("retry failed for row %s: %v", key, err)
+			continue
+		}
+
+		if err := s.quarantine.Remove(tableName, key); err != nil {
+			lastErr = err
+			continue
+		}
+
+		recovered++
+	}
+
+	return recovered, lastErr
+}
+
+// DiscardQuarantined menghapus baris dari quarantine tanpa meng-apply-nya
+func (s *SyncService) DiscardQuarantined(tableName, pkValue string) error {
+	if tableName == "" || pkValue == "" {
+		return fmt.Errorf("table name and pk value are required")
+	}
+	return s.quarantine.Remove(tableName, pkValue)
+}
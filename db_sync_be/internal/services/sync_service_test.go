@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"db-sync-scheduler/internal/config"
+	"db-sync-scheduler/internal/dialect"
+
+	_ "modernc.org/sqlite"
+)
+
+// newSQLiteSyncService membuat SyncService dengan master dan backup SQLite di
+// direktori sementara; schema keduanya diisi lewat masterDDL dan backupDDL
+func newSQLiteSyncService(t *testing.T, cfg *config.AppConfig, masterDDL, backupDDL []string) *SyncService {
+	t.Helper()
+
+	dir := t.TempDir()
+	open := func(name string, ddl []string) *sql.DB {
+		db, err := sql.Open("sqlite", filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+		for _, stmt := range ddl {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("%s: %v", stmt, err)
+			}
+		}
+		return db
+	}
+
+	masterDB := open("master.db", masterDDL)
+	backupDB := open("backup.db", backupDDL)
+
+	d, err := dialect.New("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil {
+		cfg = &config.AppConfig{}
+	}
+
+	schema := NewSchemaService(masterDB, backupDB, d, d, nil, cfg)
+	quarantine := NewQuarantineService(backupDB, d)
+	return NewSyncService(masterDB, backupDB, schema, quarantine, nil, nil, "", 100, false, cfg)
+}
+
+func TestUpsertDataToBackupCompositePrimaryKey(t *testing.T) {
+	ddl := []string{`CREATE TABLE orderdetails (
+		orderNumber INTEGER NOT NULL,
+		productCode TEXT NOT NULL,
+		quantityOrdered INTEGER NOT NULL,
+		PRIMARY KEY (orderNumber, productCode))`}
+	s := newSQLiteSyncService(t, nil, ddl, ddl)
+
+	rows := []map[string]interface{}{
+		{"orderNumber": int64(10100), "productCode": "S18_1749", "quantityOrdered": int64(30)},
+		{"orderNumber": int64(10100), "productCode": "S18_2248", "quantityOrdered": int64(50)},
+	}
+	pkColumns := []string{"orderNumber", "productCode"}
+
+	synced, _, err := s.upsertDataToBackup(s.backupDB, "orderdetails", pkColumns, rows)
+	if err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	if synced != 2 {
+		t.Fatalf("synced = %d, want 2", synced)
+	}
+
+	// Upsert ulang mengubah baris yang sama, bukan menambah baris baru
+	rows[1]["quantityOrdered"] = int64(55)
+	if _, _, err := s.upsertDataToBackup(s.backupDB, "orderdetails", pkColumns, rows); err != nil {
+		t.Fatalf("second upsert failed: %v", err)
+	}
+
+	var count, quantity int
+	s.backupDB.QueryRow("SELECT COUNT(*) FROM orderdetails").Scan(&count)
+	s.backupDB.QueryRow("SELECT quantityOrdered FROM orderdetails WHERE productCode = 'S18_2248'").Scan(&quantity)
+	if count != 2 || quantity != 55 {
+		t.Fatalf("backup has %d rows, quantity %d; want 2 rows, quantity 55", count, quantity)
+	}
+}
+
+func TestQuarantineCompositePrimaryKey(t *testing.T) {
+	master := []string{`CREATE TABLE orderdetails (
+		orderNumber INTEGER NOT NULL,
+		productCode TEXT NOT NULL,
+		quantityOrdered INTEGER NOT NULL,
+		PRIMARY KEY (orderNumber, productCode))`}
+	backup := []string{`CREATE TABLE orderdetails (
+		orderNumber INTEGER NOT NULL,
+		productCode TEXT NOT NULL,
+		quantityOrdered INTEGER NOT NULL CHECK (quantityOrdered > 0),
+		PRIMARY KEY (orderNumber, productCode))`}
+	cfg := &config.AppConfig{}
+	cfg.Sync.QuarantineEnabled = true
+	s := newSQLiteSyncService(t, cfg, master, backup)
+
+	pkColumns := []string{"orderNumber", "productCode"}
+	rows := []map[string]interface{}{
+		{"orderNumber": int64(10100), "productCode": "S18_1749", "quantityOrdered": int64(-1)},
+		{"orderNumber": int64(10100), "productCode": "S18_2248", "quantityOrdered": int64(-2)},
+		{"orderNumber": int64(10100), "productCode": "S18_4409", "quantityOrdered": int64(22)},
+	}
+	if _, _, err := s.upsertDataToBackup(s.backupDB, "orderdetails", pkColumns, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// Baris gagal dengan orderNumber sama tetap tercatat terpisah, baris yang
+	// berhasil tidak melepas quarantine baris lain
+	quarantined, err := s.ListQuarantined("orderdetails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 2 {
+		t.Fatalf("quarantined %d rows, want 2: %+v", len(quarantined), quarantined)
+	}
+
+	if _, err := s.masterDB.Exec(`INSERT INTO orderdetails VALUES (10100, 'S18_1749', 30), (10100, 'S18_2248', 50)`); err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := s.RetryQuarantined("orderdetails", "10100|S18_2248")
+	if err != nil || recovered != 1 {
+		t.Fatalf("retry recovered %d rows (err %v), want 1", recovered, err)
+	}
+
+	var quantity int
+	s.backupDB.QueryRow("SELECT quantityOrdered FROM orderdetails WHERE productCode = 'S18_2248'").Scan(&quantity)
+	if quantity != 50 {
+		t.Fatalf("retried row has quantity %d, want 50", quantity)
+	}
+
+	quarantined, _ = s.ListQuarantined("orderdetails")
+	if len(quarantined) != 1 || quarantined[0].PKValue != "10100|S18_1749" {
+		t.Fatalf("remaining quarantine = %+v, want only 10100|S18_1749", quarantined)
+	}
+}
+
+func TestRowKeyRoundTrip(t *testing.T) {
+	pkColumns := []string{"a", "b"}
+	tests := []struct {
+		name string
+		row  map[string]interface{}
+		key  string
+	}{
+		{"plain", map[string]interface{}{"a": int64(10100), "b": "S18_1749"}, "10100|S18_1749"},
+		{"pipe in value", map[string]interface{}{"a": "x|y", "b": "z"}, `x\|y|z`},
+		{"backslash in value", map[string]interface{}{"a": `x\`, "b": "|"}, `x\\|\|`},
+		{"empty values", map[string]interface{}{"a": "", "b": ""}, "|"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := rowKey(tt.row, pkColumns)
+			if key != tt.key {
+				t.Fatalf("rowKey = %q, want %q", key, tt.key)
+			}
+			values, err := rowKeyValues(key, pkColumns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, pk := range pkColumns {
+				if values[i] != formatPKValue(tt.row[pk]) {
+					t.Errorf("value %d = %q, want %q", i, values[i], formatPKValue(tt.row[pk]))
+				}
+			}
+		})
+	}
+
+	if _, err := rowKeyValues("a|b|c", pkColumns); err == nil {
+		t.Error("expected error for key with too many parts")
+	}
+}
+
+func TestDeferForeignKeysRejectedForSQLiteBackup(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Sync.DeferForeignKeys = true
+	s := newSQLiteSyncService(t, cfg, nil, nil)
+
+	if s.schemaService.ForeignKeysDeferred() {
+		t.Error("foreign keys are deferred for a SQLite backup, they would never be created")
+	}
+	if err := s.StartSync(); err == nil {
+		s.StopSync()
+		t.Fatal("StartSync accepted DEFER_FOREIGN_KEYS with a SQLite backup")
+	}
+}
+
+func TestSyncTableLastSyncTimeIsReadStart(t *testing.T) {
+	ddl := []string{`CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT, updated_at DATETIME)`}
+	s := newSQLiteSyncService(t, nil, ddl, ddl)
+	s.isRunning = true
+
+	if _, err := s.masterDB.Exec(`INSERT INTO customers VALUES (1, 'Atelier', '2024-01-01 00:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Koneksi snapshot di-pin, metadata dibaca lewat koneksi lain
+	s.masterDB.SetMaxOpenConns(2)
+	conn, err := s.masterDB.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Snapshot dibuka sebelum tabel dibaca; baris yang berubah setelahnya harus
+	// tetap lebih baru dari LastSyncTime
+	startedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	s.syncTable(&masterSnapshot{Conn: conn, startedAt: startedAt}, s.backupDB, "customers")
+	conn.Close()
+
+	status := s.tableStatus["customers"]
+	if status.Status != "success" {
+		t.Fatalf("status = %s (%s), want success", status.Status, status.ErrorMessage)
+	}
+	if !status.LastSyncTime.Equal(startedAt) {
+		t.Fatalf("LastSyncTime = %s, want snapshot start %s", status.LastSyncTime, startedAt)
+	}
+
+	before := time.Now()
+	s.syncTable(s.masterDB, s.backupDB, "customers")
+	if got := s.tableStatus["customers"].LastSyncTime; got.Before(before) || got.After(time.Now()) {
+		t.Fatalf("LastSyncTime = %s, want the start of the second read", got)
+	}
+}
+
+func TestSyncTableWaitsForShadowCopy(t *testing.T) {
+	master := []string{
+		`CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT)`,
+		`INSERT INTO offices VALUES (1, 'Tokyo')`,
+	}
+	backup := []string{`CREATE TABLE offices (id INTEGER PRIMARY KEY, city TEXT)`}
+	s := newSQLiteSyncService(t, nil, master, backup)
+	s.isRunning = true
+
+	count := func() int {
+		var n int
+		if err := s.backupDB.QueryRow("SELECT COUNT(*) FROM offices").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Shadow copy memegang lock tabel selama penyalinan dan penukaran
+	lock := s.schemaService.tableLock("offices")
+	lock.Lock()
+	s.syncTable(s.masterDB, s.backupDB, "offices")
+	if got := count(); got != 0 {
+		t.Errorf("rows written while table was locked = %d, want 0", got)
+	}
+	lock.Unlock()
+
+	s.syncTable(s.masterDB, s.backupDB, "offices")
+	if got := count(); got != 1 {
+		t.Errorf("rows after lock released = %d, want 1", got)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	SnapshotScopeNone  = "none"
+	SnapshotScopeRun   = "run"
+	SnapshotScopeLevel = "level"
+)
+
+// masterSnapshot adalah koneksi master yang di-pin dengan satu transaksi
+// read-only, sehingga semua tabel yang dibaca melihat titik waktu yang sama.
+// startedAt diambil sebelum snapshot dibuka, perubahan sesudahnya belum terlihat.
+type masterSnapshot struct {
+	*sql.Conn
+	startedAt time.Time
+}
+
+// beginMasterSnapshot mengambil satu koneksi dari pool master lalu memulai snapshot
+func (s *SyncService) beginMasterSnapshot() (*masterSnapshot, error) {
+	statements := s.source.SnapshotStatements()
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("consistent snapshot is not supported for %s master", s.source.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := s.masterDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin master connection: %v", err)
+	}
+
+	startedAt := time.Now()
+	for _, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to start master snapshot: %v", err)
+		}
+	}
+
+	return &masterSnapshot{Conn: conn, startedAt: startedAt}, nil
+}
+
+// Close mengakhiri transaksi snapshot dan mengembalikan koneksi ke pool
+func (m *masterSnapshot) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := m.ExecContext(ctx, "COMMIT"); err != nil {
+		log.Printf("Warning: failed to end master snapshot: %v", err)
+	}
+	m.Conn.Close()
+
+	log.Printf("Master snapshot released after %s", time.Since(m.startedAt).Round(time.Millisecond))
+}
+
+// snapshotReader memberikan executor master untuk level dependency berikutnya.
+// Dengan scope level, snapshot lama ditutup dan snapshot baru dibuka setiap kali
+// level berganti. Jika snapshot gagal dibuat, run dilanjutkan tanpa snapshot.
+type snapshotReader struct {
+	s        *SyncService
+	scope    string
+	snapshot *masterSnapshot
+	level    int
+}
+
+func (r *snapshotReader) executorFor(level int) sqlExecutor {
+	if r.scope == SnapshotScopeNone {
+		return r.s.masterDB
+	}
+
+	if r.snapshot != nil && r.scope == SnapshotScopeLevel && level != r.level {
+		r.snapshot.Close()
+		r.snapshot = nil
+	}
+
+	if r.snapshot == nil {
+		snapshot, err := r.s.beginMasterSnapshot()
+		if err != nil {
+			log.Printf("Warning: %v, reading tables without snapshot", err)
+			r.scope = SnapshotScopeNone
+			return r.s.masterDB
+		}
+		r.snapshot = snapshot
+		r.level = level
+		log.Printf("Master snapshot started (scope: %s, level: %d)", r.scope, level)
+	}
+
+	return r.snapshot
+}
+
+func (r *snapshotReader) Close() {
+	if r.snapshot != nil {
+		r.snapshot.Close()
+		r.snapshot = nil
+	}
+}